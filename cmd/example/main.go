@@ -2,30 +2,39 @@ package main
 
 import (
 	"fmt"
-	"nanonets/nanonets"
+	"os"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
 )
 
 func main() {
-	client := nanonets.NewClient("YOUR_API_KEY")
+	client := nanonets.NewClient(os.Getenv("NANONETS_API_KEY"))
 	workflowID := "your_workflow_id"
 	documentID := "your_document_id"
-	pageID := "your_page_id"
 
 	// List documents
-	docs, _ := client.ListDocuments(workflowID, 1, 10)
-	fmt.Println("Documents:", docs)
+	docs, err := client.Documents.ListWithPagination(workflowID, 1, 10)
+	if err != nil {
+		fmt.Println("Error listing documents:", err)
+		return
+	}
+	fmt.Println("Documents:", len(docs))
 
 	// Get document
-	doc, _ := client.GetDocument(workflowID, documentID)
-	fmt.Println("Document:", doc)
+	doc, err := client.Documents.Get(workflowID, documentID)
+	if err != nil {
+		fmt.Println("Error getting document:", err)
+		return
+	}
 
-	// Get page data
-	page, _ := client.GetPageData(workflowID, documentID, pageID)
-	fmt.Println("Page:", page)
+	// Page data
+	for _, page := range doc.Pages {
+		fmt.Printf("Page %d: %d fields, %d tables\n", page.PageNumber, len(page.Data.Fields), len(page.Data.Tables))
+	}
 
 	// Moderation: Update a field value (example)
-	// client.UpdateFieldValue(workflowID, documentID, pageID, "field_data_id", "new_value")
+	// client.Moderation.UpdateField(workflowID, documentID, pageID, "field_data_id", nanonets.UpdateFieldRequest{Value: nanonets.String("new_value")})
 
 	// Moderation: Add a table (example)
-	// client.AddTable(workflowID, documentID, pageID, []int{10,10,100,100}, []string{"col1","col2"}, "unverified", "", []map[string]interface{}{{"row":0,"col":0,"header":"col1","text":"cell","bbox":[]int{10,10,20,20},"verification_status":"unverified","verification_message":""}})
+	// client.Moderation.AddTable(workflowID, documentID, pageID, nanonets.AddTableRequest{Bbox: []float64{10, 10, 100, 100}, Headers: []string{"col1", "col2"}, Cells: []nanonets.Cell{{Row: 0, Col: 0, Header: "col1", Text: "cell", Bbox: []float64{10, 10, 20, 20}}}})
 }
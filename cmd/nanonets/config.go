@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by the CLI
+const (
+	envAPIKey  = "NANONETS_API_KEY"
+	envBaseURL = "NANONETS_BASE_URL"
+	envProfile = "NANONETS_PROFILE"
+	envConfig  = "NANONETS_CONFIG"
+	// envWorkflow is the default for --workflow
+	envWorkflow = "NANONETS_WORKFLOW"
+)
+
+// Config is the CLI config file:
+//
+//	default_profile: work
+//	profiles:
+//	  work:
+//	    api_key: ...
+//	  staging:
+//	    api_key: ...
+//	    base_url: https://staging.example.com/api/v4
+type Config struct {
+	DefaultProfile string              `yaml:"default_profile"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile holds the credentials of one account
+type Profile struct {
+	APIKey  string `yaml:"api_key"`
+	BaseURL string `yaml:"base_url,omitempty"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join("~", ".config", "nanonets", "config.yaml")
+	}
+	return filepath.Join(dir, "nanonets", "config.yaml")
+}
+
+// loadConfig reads the config file. A missing file yields an empty config
+// unless the path was given explicitly.
+func loadConfig(path string, explicit bool) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// credentials resolves the API key and base URL. Flags take precedence
+// over the environment, which takes precedence over the profile.
+func (a *app) credentials() (Profile, error) {
+	path, explicit := a.config, a.config != ""
+	if !explicit {
+		path = os.Getenv(envConfig)
+		explicit = path != ""
+	}
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	cfg, err := loadConfig(path, explicit)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	name := a.profile
+	if name == "" {
+		name = os.Getenv(envProfile)
+	}
+	var creds Profile
+	switch {
+	case name != "":
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return Profile{}, usagef("profile %q not found in %s (have %s)", name, path, profileNames(cfg))
+		}
+		creds = *p
+	case cfg.DefaultProfile != "":
+		if p, ok := cfg.Profiles[cfg.DefaultProfile]; ok {
+			creds = *p
+		}
+	case len(cfg.Profiles) == 1:
+		for _, p := range cfg.Profiles {
+			creds = *p
+		}
+	}
+
+	if v := os.Getenv(envAPIKey); v != "" {
+		creds.APIKey = v
+	}
+	if v := os.Getenv(envBaseURL); v != "" {
+		creds.BaseURL = v
+	}
+	if a.apiKey != "" {
+		creds.APIKey = a.apiKey
+	}
+	if a.baseURL != "" {
+		creds.BaseURL = a.baseURL
+	}
+	if creds.APIKey == "" {
+		return Profile{}, usagef("no API key: use --api-key, set %s or add a profile to %s", envAPIKey, path)
+	}
+	return creds, nil
+}
+
+func profileNames(cfg *Config) string {
+	if len(cfg.Profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,703 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+var docsCommands = map[string]command{
+	"upload":            {"Upload files or URLs to a workflow", docsUpload},
+	"list":              {"List a workflow's documents", docsList},
+	"get":               {"Show a document's fields and tables", docsGet},
+	"delete":            {"Delete documents", docsDelete},
+	"download-original": {"Download a document's original file", docsDownloadOriginal},
+	"export":            {"Export extracted data as CSV, JSON Lines or XLSX", docsExport},
+}
+
+// keyValues is a repeatable key=value flag
+type keyValues map[string]string
+
+func (kv keyValues) String() string {
+	pairs := make([]string, 0, len(kv))
+	for k, v := range kv {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (kv keyValues) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	kv[k] = v
+	return nil
+}
+
+// workflowFlag registers the --workflow flag, which falls back to
+// NANONETS_WORKFLOW
+func workflowFlag(fs *flag.FlagSet) *string {
+	return fs.String("workflow", os.Getenv(envWorkflow), "workflow ID (default "+envWorkflow+")")
+}
+
+// requireWorkflow checks that a workflow was given
+func requireWorkflow(workflowID string) error {
+	if workflowID == "" {
+		return usagef("--workflow is required")
+	}
+	return nil
+}
+
+// uploadResult is the outcome of uploading one file
+type uploadResult struct {
+	Source   string             `json:"source"`
+	Document *nanonets.Document `json:"document,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+func docsUpload(a *app, args []string) error {
+	fs := a.flagSet("docs upload")
+	workflowID := workflowFlag(fs)
+	async := fs.Bool("async", false, "return as soon as each file is accepted")
+	wait := fs.Bool("wait", false, "wait until each document has finished processing")
+	timeout := fs.Duration("timeout", 10*time.Minute, "how long --wait waits for each document")
+	interval := fs.Duration("interval", 2*time.Second, "how often --wait polls")
+	metadata := keyValues{}
+	fs.Var(metadata, "metadata", "metadata key=value attached to every upload (repeatable)")
+	sources, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireWorkflow(*workflowID); err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return usagef("expected one or more files or URLs")
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+
+	// Each source is attempted even if an earlier one fails; the first
+	// error decides the exit code
+	var firstErr error
+	failed := 0
+	results := make([]uploadResult, 0, len(sources))
+	for _, source := range sources {
+		doc, err := uploadOne(c, *workflowID, source, *async, metadata)
+		if err == nil && *wait {
+			doc, err = waitForDocument(a.ctx, c, *workflowID, doc, *interval, *timeout)
+		}
+		result := uploadResult{Source: source, Document: doc}
+		if err != nil {
+			result.Error = errorMessage(err)
+			fmt.Fprintf(a.stderr, "nanonets: %s: %s\n", source, result.Error)
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if err := a.render(results, func(w io.Writer) {
+		row(w, "SOURCE", "DOCUMENT ID", "STATUS", "PAGES")
+		for _, r := range results {
+			if r.Document == nil {
+				row(w, r.Source, "-", "error", "-")
+				continue
+			}
+			row(w, r.Source, r.Document.DocumentID, r.Document.Status, len(r.Document.Pages))
+		}
+	}); err != nil {
+		return err
+	}
+	if firstErr != nil {
+		return fmt.Errorf("%d of %d upload(s) failed: %w", failed, len(sources), firstErr)
+	}
+	return nil
+}
+
+// uploadOne uploads a local file, or a URL with UploadFromURL
+func uploadOne(c *nanonets.Client, workflowID, source string, async bool, metadata keyValues) (*nanonets.Document, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req := nanonets.UploadDocumentFromURLRequest{URL: source, Async: async}
+		if len(metadata) > 0 {
+			data, err := json.Marshal(metadata)
+			if err != nil {
+				return nil, err
+			}
+			req.Metadata = string(data)
+		}
+		return c.Documents.UploadFromURL(workflowID, req)
+	}
+	return c.Documents.Upload(workflowID, nanonets.UploadDocumentRequest{File: source, Async: async, Metadata: metadata})
+}
+
+// waitForDocument polls a document until its status is terminal. A failed
+// document is returned with an error.
+func waitForDocument(ctx context.Context, c *nanonets.Client, workflowID string, doc *nanonets.Document, interval, timeout time.Duration) (*nanonets.Document, error) {
+	deadline := time.Now().Add(timeout)
+	for !doc.Status.IsTerminal() {
+		if time.Now().After(deadline) {
+			return doc, fmt.Errorf("document %s still %s after %s", doc.DocumentID, doc.Status, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return doc, ctx.Err()
+		case <-time.After(interval):
+		}
+		next, err := c.Documents.Get(workflowID, doc.DocumentID)
+		if err != nil {
+			return doc, err
+		}
+		doc = next
+	}
+	if doc.Status == nanonets.DocumentStatusFailed {
+		return doc, fmt.Errorf("document %s failed to process", doc.DocumentID)
+	}
+	return doc, nil
+}
+
+// filterFlags are the document filters shared by list and export
+type filterFlags struct {
+	statuses      stringList
+	verifications stringList
+	after, before string
+	metadata      keyValues
+	page, limit   int
+}
+
+func addFilterFlags(fs *flag.FlagSet) *filterFlags {
+	f := &filterFlags{metadata: keyValues{}}
+	fs.Var(&f.statuses, "status", "processing status to match: pending, processing, completed or failed (repeatable)")
+	fs.Var(&f.verifications, "verification-status", "verification status to match: unverified, verified or rejected (repeatable)")
+	fs.Var(f.metadata, "metadata", "metadata key=value to match (repeatable)")
+	fs.StringVar(&f.after, "after", "", "only documents uploaded at or after this date or time")
+	fs.StringVar(&f.before, "before", "", "only documents uploaded before this date or time")
+	fs.IntVar(&f.page, "page", 0, "fetch only this page of documents (default all documents)")
+	fs.IntVar(&f.limit, "limit", 50, "page size for --page")
+	return f
+}
+
+// filter builds a DocumentFilter from the flags
+func (f *filterFlags) filter() (nanonets.DocumentFilter, error) {
+	var filter nanonets.DocumentFilter
+	for _, s := range f.statuses {
+		status := nanonets.DocumentStatus(strings.ToLower(s))
+		if !status.IsValid() {
+			return filter, usagef("unknown status %q", s)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	for _, s := range f.verifications {
+		status := nanonets.VerificationStatus(strings.ToLower(s))
+		if !status.IsValid() {
+			return filter, usagef("unknown verification status %q", s)
+		}
+		filter.VerificationStatuses = append(filter.VerificationStatuses, status)
+	}
+	var err error
+	if filter.UploadedAfter, err = parseTimeFlag("after", f.after); err != nil {
+		return filter, err
+	}
+	if filter.UploadedBefore, err = parseTimeFlag("before", f.before); err != nil {
+		return filter, err
+	}
+	if len(f.metadata) > 0 {
+		filter.Metadata = f.metadata
+	}
+	return filter, nil
+}
+
+// parseTimeFlag accepts a date or any timestamp accepted by
+// nanonets.ParseTime
+func parseTimeFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := nanonets.ParseTime(value)
+	if err != nil {
+		return time.Time{}, usagef("--%s: %v", name, err)
+	}
+	return t.Time, nil
+}
+
+// listDocuments fetches one page or all of a workflow's documents and
+// applies the filters
+func (f *filterFlags) listDocuments(c *nanonets.Client, workflowID string) ([]nanonets.Document, error) {
+	filter, err := f.filter()
+	if err != nil {
+		return nil, err
+	}
+	var docs []nanonets.Document
+	if f.page > 0 {
+		docs, err = c.Documents.ListWithPagination(workflowID, f.page, f.limit)
+	} else {
+		docs, err = c.Documents.List(workflowID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nanonets.FilterDocuments(docs, filter), nil
+}
+
+func docsList(a *app, args []string) error {
+	fs := a.flagSet("docs list")
+	workflowID := workflowFlag(fs)
+	filters := addFilterFlags(fs)
+	newest := fs.Bool("newest", false, "sort newest first (default oldest first)")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireWorkflow(*workflowID); err != nil {
+		return err
+	}
+	if err := positional(rest); err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	docs, err := filters.listDocuments(c, *workflowID)
+	if err != nil {
+		return err
+	}
+	nanonets.SortDocumentsByUploadedAt(docs, *newest)
+	if docs == nil {
+		docs = []nanonets.Document{}
+	}
+	return a.render(docs, func(w io.Writer) {
+		row(w, "ID", "NAME", "STATUS", "VERIFICATION", "UPLOADED", "PAGES")
+		for _, d := range docs {
+			row(w, d.DocumentID, d.OriginalDocumentName, d.Status, d.VerificationStatus, formatTime(d.UploadedAt), len(d.Pages))
+		}
+	})
+}
+
+func docsGet(a *app, args []string) error {
+	fs := a.flagSet("docs get")
+	workflowID := workflowFlag(fs)
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireWorkflow(*workflowID); err != nil {
+		return err
+	}
+	if err := positional(rest, "<document-id>"); err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	doc, err := c.Documents.Get(*workflowID, rest[0])
+	if err != nil {
+		return err
+	}
+	return a.render(doc, func(w io.Writer) {
+		row(w, "ID:", doc.DocumentID)
+		row(w, "Name:", doc.OriginalDocumentName)
+		row(w, "Status:", doc.Status)
+		row(w, "Verification:", doc.VerificationStatus)
+		row(w, "Uploaded:", formatTime(doc.UploadedAt))
+		row(w, "Pages:", len(doc.Pages))
+		if len(doc.Metadata) > 0 {
+			data, _ := json.Marshal(doc.Metadata)
+			row(w, "Metadata:", string(data))
+		}
+		fmt.Fprintln(w)
+		row(w, "PAGE", "FIELD", "VALUE", "CONFIDENCE", "STATUS", "ID")
+		for _, p := range doc.Pages {
+			for _, name := range nanonets.FieldNames(p.Data.Fields) {
+				for _, fd := range p.Data.Fields[name] {
+					row(w, p.PageNumber, name, fd.Value, fmt.Sprintf("%.2f", fd.Confidence), fd.VerificationStatus, fd.FieldDataID)
+				}
+			}
+		}
+		for _, p := range doc.Pages {
+			for _, t := range p.Data.Tables {
+				fmt.Fprintln(w)
+				row(w, fmt.Sprintf("Table %s (page %d)", t.TableID, p.PageNumber))
+				headers, rows := tableRows(t)
+				row(w, stringsToCells(append([]string{"ROW"}, headers...))...)
+				for i, r := range rows {
+					row(w, stringsToCells(append([]string{fmt.Sprint(i)}, r...))...)
+				}
+			}
+		}
+	})
+}
+
+type deleteResult struct {
+	DocumentID string `json:"document_id"`
+	Deleted    bool   `json:"deleted"`
+	Error      string `json:"error,omitempty"`
+}
+
+func docsDelete(a *app, args []string) error {
+	fs := a.flagSet("docs delete")
+	workflowID := workflowFlag(fs)
+	ids, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireWorkflow(*workflowID); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return usagef("expected one or more document IDs")
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	// Each ID is attempted even if an earlier one fails; the first error
+	// decides the exit code
+	var firstErr error
+	failed := 0
+	results := make([]deleteResult, 0, len(ids))
+	for _, id := range ids {
+		result := deleteResult{DocumentID: id, Deleted: true}
+		if err := c.Documents.Delete(*workflowID, id); err != nil {
+			result.Deleted = false
+			result.Error = errorMessage(err)
+			fmt.Fprintf(a.stderr, "nanonets: %s: %s\n", id, result.Error)
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if err := a.render(results, func(w io.Writer) {
+		for _, r := range results {
+			if r.Deleted {
+				row(w, "Deleted", r.DocumentID)
+			} else {
+				row(w, "Failed", r.DocumentID)
+			}
+		}
+	}); err != nil {
+		return err
+	}
+	if firstErr != nil {
+		return fmt.Errorf("%d of %d deletion(s) failed: %w", failed, len(ids), firstErr)
+	}
+	return nil
+}
+
+func docsDownloadOriginal(a *app, args []string) error {
+	fs := a.flagSet("docs download-original")
+	workflowID := workflowFlag(fs)
+	out := fs.String("out", "", "output file, or - for stdout (default the original file name)")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireWorkflow(*workflowID); err != nil {
+		return err
+	}
+	if err := positional(rest, "<document-id>"); err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	documentID := rest[0]
+
+	path := *out
+	if path == "" {
+		doc, err := c.Documents.Get(*workflowID, documentID)
+		if err != nil {
+			return err
+		}
+		path = filepath.Base(doc.OriginalDocumentName)
+		if path == "" || path == "." || path == string(filepath.Separator) {
+			path = documentID
+		}
+	}
+	data, err := c.Documents.GetOriginalFile(*workflowID, documentID)
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		_, err := a.stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stderr, "Wrote %s (%d bytes)\n", path, len(data))
+	return nil
+}
+
+func docsExport(a *app, args []string) error {
+	fs := a.flagSet("docs export")
+	workflowID := workflowFlag(fs)
+	format := fs.String("format", "csv", "export format: csv, jsonl or xlsx")
+	out := fs.String("out", "", "output file (default stdout)")
+	tables := fs.Bool("tables", false, "export one row per table row instead of one row per document")
+	filters := addFilterFlags(fs)
+	ids, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := requireWorkflow(*workflowID); err != nil {
+		return err
+	}
+	switch *format {
+	case "csv", "jsonl", "xlsx":
+	default:
+		return usagef("unknown export format %q", *format)
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+
+	// Documents named on the command line are fetched one by one;
+	// otherwise the filtered list is exported
+	var docs []nanonets.Document
+	if len(ids) > 0 {
+		for _, id := range ids {
+			doc, err := c.Documents.Get(*workflowID, id)
+			if err != nil {
+				return fmt.Errorf("fetching %s: %w", id, err)
+			}
+			docs = append(docs, *doc)
+		}
+	} else {
+		if docs, err = filters.listDocuments(c, *workflowID); err != nil {
+			return err
+		}
+		nanonets.SortDocumentsByUploadedAt(docs, false)
+	}
+
+	var header []string
+	var records [][]string
+	if *tables {
+		header, records = tableRecords(docs)
+	} else {
+		header, records = documentRecords(docs)
+	}
+
+	if *out == "" {
+		return writeExport(a.stdout, *format, header, records)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := writeExport(f, *format, header, records); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stderr, "Exported %d row(s) to %s\n", len(records), *out)
+	return nil
+}
+
+func writeExport(w io.Writer, format string, header []string, records [][]string) error {
+	switch format {
+	case "jsonl":
+		return writeJSONLines(w, header, records)
+	case "xlsx":
+		return writeXLSX(w, "Documents", append([][]string{header}, records...))
+	}
+	return writeCSV(w, header, records)
+}
+
+// documentColumns are the leading columns of every export row
+var documentColumns = []string{"document_id", "original_document_name", "status", "verification_status", "uploaded_at"}
+
+func documentCells(d *nanonets.Document) []string {
+	uploaded := ""
+	if !d.UploadedAt.IsZero() {
+		uploaded = d.UploadedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{d.DocumentID, d.OriginalDocumentName, string(d.Status), string(d.VerificationStatus), uploaded}
+}
+
+// documentRecords returns one row per document with a column per field
+// name and per metadata key. Repeated values of a field are joined
+// with "; ".
+func documentRecords(docs []nanonets.Document) ([]string, [][]string) {
+	fieldSet := map[string]bool{}
+	metaSet := map[string]bool{}
+	for _, d := range docs {
+		for _, p := range d.Pages {
+			for name := range p.Data.Fields {
+				fieldSet[name] = true
+			}
+		}
+		for key := range d.Metadata {
+			metaSet[key] = true
+		}
+	}
+	fields := sortedKeys(fieldSet)
+	meta := sortedKeys(metaSet)
+
+	header := append([]string{}, documentColumns...)
+	header = append(header, fields...)
+	for _, key := range meta {
+		header = append(header, "metadata."+key)
+	}
+
+	records := make([][]string, 0, len(docs))
+	for i := range docs {
+		d := &docs[i]
+		values := map[string][]string{}
+		for _, p := range d.Pages {
+			for name, data := range p.Data.Fields {
+				for _, fd := range data {
+					values[name] = append(values[name], fd.Value)
+				}
+			}
+		}
+		record := documentCells(d)
+		for _, name := range fields {
+			record = append(record, strings.Join(values[name], "; "))
+		}
+		for _, key := range meta {
+			record = append(record, metadataString(d.Metadata, key))
+		}
+		records = append(records, record)
+	}
+	return header, records
+}
+
+// tableRecords returns one row per table row with a column per table
+// header, in order of first appearance
+func tableRecords(docs []nanonets.Document) ([]string, [][]string) {
+	var headers []string
+	seen := map[string]bool{}
+	for _, d := range docs {
+		for _, p := range d.Pages {
+			for _, t := range p.Data.Tables {
+				names, _ := tableRows(t)
+				for _, h := range names {
+					if !seen[h] {
+						seen[h] = true
+						headers = append(headers, h)
+					}
+				}
+			}
+		}
+	}
+
+	header := append([]string{}, documentColumns...)
+	header = append(header, "page", "table_id", "row")
+	header = append(header, headers...)
+
+	var records [][]string
+	for i := range docs {
+		d := &docs[i]
+		for _, p := range d.Pages {
+			for _, t := range p.Data.Tables {
+				names, rows := tableRows(t)
+				for r, cells := range rows {
+					byName := map[string]string{}
+					for j, h := range names {
+						byName[h] = cells[j]
+					}
+					record := documentCells(d)
+					record = append(record, fmt.Sprint(p.PageNumber), t.TableID, fmt.Sprint(r))
+					for _, h := range headers {
+						record = append(record, byName[h])
+					}
+					records = append(records, record)
+				}
+			}
+		}
+	}
+	return header, records
+}
+
+// tableRows lays a table's cells out as rows under its headers. Columns
+// are named by their cells' headers, or col_N where a header is missing.
+func tableRows(t nanonets.Table) ([]string, [][]string) {
+	nRows, nCols := 0, 0
+	for _, cell := range t.Cells {
+		if cell.Row+1 > nRows {
+			nRows = cell.Row + 1
+		}
+		if cell.Col+1 > nCols {
+			nCols = cell.Col + 1
+		}
+	}
+	headers := make([]string, nCols)
+	rows := make([][]string, nRows)
+	for i := range rows {
+		rows[i] = make([]string, nCols)
+	}
+	for _, cell := range t.Cells {
+		if cell.Row < 0 || cell.Col < 0 {
+			continue
+		}
+		rows[cell.Row][cell.Col] = cell.Text
+		if headers[cell.Col] == "" {
+			headers[cell.Col] = cell.Header
+		}
+	}
+	for i, h := range headers {
+		if h == "" {
+			headers[i] = fmt.Sprintf("col_%d", i)
+		}
+	}
+	return headers, rows
+}
+
+func metadataString(m nanonets.Metadata, key string) string {
+	if s, ok := m.String(key); ok {
+		return s
+	}
+	v, ok := m.Get(key)
+	if !ok || v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func stringsToCells(s []string) []interface{} {
+	cells := make([]interface{}, len(s))
+	for i, v := range s {
+		cells[i] = v
+	}
+	return cells
+}
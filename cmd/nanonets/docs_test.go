@@ -0,0 +1,223 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetsmock"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+func TestEscapeFormula(t *testing.T) {
+	tests := map[string]string{
+		"":                         "",
+		"Acme Ltd":                 "Acme Ltd",
+		"=HYPERLINK(\"http://x\")": "'=HYPERLINK(\"http://x\")",
+		"+1 555 0100":              "'+1 555 0100",
+		"@SUM(A1:A2)":              "'@SUM(A1:A2)",
+		"-cmd":                     "'-cmd",
+		"\tindented":               "'\tindented",
+		"-12.50":                   "-12.50",
+		"+3":                       "+3",
+		"total = quantity * price": "total = quantity * price",
+	}
+	for in, want := range tests {
+		if got := escapeFormula(in); got != want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 1: "B", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+	for i, want := range tests {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	rows := [][]string{{"name", "total"}, {"A & B <Ltd>", ""}, {"", "=1+1"}}
+	if err := writeXLSX(&buf, "Q1 & Q2", rows); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/workbook.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("workbook has no %s", name)
+		}
+	}
+	if !strings.Contains(files["xl/workbook.xml"], `<sheet name="Q1 &amp; Q2"`) {
+		t.Errorf("sheet name not escaped: %s", files["xl/workbook.xml"])
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t xml:space="preserve">name</t></is></c><c r="B1"`,
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">A &amp; B &lt;Ltd&gt;</t></is></c></row>`,
+		// Inline strings are never evaluated, so formulas need no escaping
+		`<row r="3"><c r="B3" t="inlineStr"><is><t xml:space="preserve">=1+1</t>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet has no %s:\n%s", want, sheet)
+		}
+	}
+	if strings.Contains(sheet, `r="B2"`) || strings.Contains(sheet, `r="A3"`) {
+		t.Errorf("sheet has cells for empty values:\n%s", sheet)
+	}
+}
+
+func TestDocsExportEscapesFormulas(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	doc, err := s.AddDocument(wf.ID, nanonets.Document{Pages: []nanonets.Page{{Data: nanonets.PageData{
+		Fields: map[string][]nanonets.FieldData{
+			"vendor": {{Value: `=HYPERLINK("http://evil.example")`}},
+			"total":  {{Value: "-12.50"}},
+		},
+	}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCLI(t, s, "docs", "export", "--workflow", wf.ID, doc.DocumentID)
+	if code != exitOK {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("export %q: %v", stdout, err)
+	}
+	got := map[string]string{}
+	for i, name := range records[0] {
+		got[name] = records[1][i]
+	}
+	if got["vendor"] != `'=HYPERLINK("http://evil.example")` || got["total"] != "-12.50" {
+		t.Errorf("vendor %q, total %q", got["vendor"], got["total"])
+	}
+}
+
+func TestDocsUploadWaitPartialFailure(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	s.ProcessingTime = 20 * time.Millisecond
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "a.pdf"), filepath.Join(dir, "b.pdf")
+	for _, f := range []string{first, second} {
+		if err := os.WriteFile(f, []byte("%PDF-1.7"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first poll fails, so the first file's wait fails while the
+	// second file is still uploaded and waited for
+	s.InjectFault(nanonetstest.Fault{Method: http.MethodGet, Path: "/workflows/*/documents/*", Status: http.StatusBadGateway, Times: 1})
+
+	code, stdout, stderr := runCLI(t, s, "-o", "json", "docs", "upload", "--workflow", wf.ID,
+		"--async", "--wait", "--interval", "10ms", first, filepath.Join(dir, "missing.pdf"), second)
+	if code != exitServer {
+		t.Errorf("exit code %d, want %d for the first error", code, exitServer)
+	}
+	if !strings.Contains(stderr, "2 of 3 upload(s) failed") {
+		t.Errorf("stderr %q has no failure count", stderr)
+	}
+	var results []uploadResult
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("%v in %s", err, stdout)
+	}
+	if len(results) != 3 {
+		t.Fatalf("%d results, want one per source", len(results))
+	}
+	if results[0].Error == "" || results[0].Document == nil {
+		t.Errorf("first file: %+v, want the uploaded document with the wait error", results[0])
+	}
+	if results[1].Error == "" || results[1].Document != nil {
+		t.Errorf("missing file: %+v, want an error and no document", results[1])
+	}
+	if results[2].Error != "" || results[2].Document == nil || results[2].Document.Status != nanonets.DocumentStatusCompleted {
+		t.Errorf("second file: %+v, want it completed", results[2])
+	}
+}
+
+func TestWaitForFailedDocument(t *testing.T) {
+	client, _, documents, _ := nanonetsmock.NewClient()
+	documents.GetFunc = func(workflowID, documentID string) (*nanonets.Document, error) {
+		return &nanonets.Document{DocumentID: documentID, Status: nanonets.DocumentStatusFailed}, nil
+	}
+	ctx := context.Background()
+	doc := &nanonets.Document{DocumentID: "doc_1", Status: nanonets.DocumentStatusProcessing}
+	got, err := waitForDocument(ctx, client, "wf_1", doc, time.Millisecond, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "failed to process") || got.Status != nanonets.DocumentStatusFailed {
+		t.Errorf("waitForDocument = %+v, %v; want the failed document and an error", got, err)
+	}
+
+	documents.GetFunc = func(workflowID, documentID string) (*nanonets.Document, error) {
+		return &nanonets.Document{DocumentID: documentID, Status: nanonets.DocumentStatusProcessing}, nil
+	}
+	if _, err := waitForDocument(ctx, client, "wf_1", doc, time.Millisecond, 5*time.Millisecond); err == nil || !strings.Contains(err.Error(), "still processing") {
+		t.Errorf("timeout: err = %v", err)
+	}
+}
+
+func TestDocsDeleteAttemptsEveryID(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	var ids []string
+	for i := 0; i < 2; i++ {
+		doc, err := s.AddDocument(wf.ID, nanonets.Document{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, doc.DocumentID)
+	}
+
+	code, stdout, stderr := runCLI(t, s, "-o", "json", "docs", "delete", "--workflow", wf.ID, ids[0], "doc_missing", ids[1])
+	if code != exitNotFound {
+		t.Errorf("exit code %d, want %d", code, exitNotFound)
+	}
+	if !strings.Contains(stderr, "1 of 3 deletion(s) failed") {
+		t.Errorf("stderr %q has no failure count", stderr)
+	}
+	var results []deleteResult
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("%v in %s", err, stdout)
+	}
+	if len(results) != 3 || !results[0].Deleted || results[1].Deleted || !results[2].Deleted {
+		t.Errorf("results %+v, want the missing ID to fail alone", results)
+	}
+	for _, id := range ids {
+		if _, ok := s.Document(id); ok {
+			t.Errorf("%s was not deleted", id)
+		}
+	}
+}
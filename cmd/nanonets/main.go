@@ -0,0 +1,290 @@
+// Command nanonets manages Nanonets workflows and documents from the
+// command line.
+//
+// Usage:
+//
+//	nanonets [global flags] <command> <subcommand> [flags] [args]
+//	nanonets [global flags] watch <dir> --workflow <id> [flags]
+//
+// The API key is taken from --api-key, then the NANONETS_API_KEY
+// environment variable, then the selected profile of the config file.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+// Exit codes. API errors map to a code per nanonets.ErrorClass.
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitAuth      = 3
+	exitNotFound  = 4
+	exitInvalid   = 5
+	exitConflict  = 6
+	exitRateLimit = 7
+	exitServer    = 8
+	exitNetwork   = 9
+)
+
+// usageError is an error in the command line
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// app holds the global options and the output streams
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+	ctx    context.Context
+
+	apiKey  string
+	baseURL string
+	profile string
+	output  string
+	config  string
+
+	client *nanonets.Client
+}
+
+// command runs a subcommand with the arguments that follow its name
+type command struct {
+	summary string
+	run     func(a *app, args []string) error
+}
+
+// commands maps each top-level command to its subcommands
+var commands = map[string]map[string]command{
+	"workflows": workflowCommands,
+	"docs":      docsCommands,
+}
+
+// actions are top-level commands without subcommands
+var actions = map[string]command{
+	"watch":  {"Upload files as they appear in a folder", watch},
+	"review": {"Review pending documents in an interactive terminal UI", review},
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	a := &app{stdout: stdout, stderr: stderr, ctx: ctx, output: "table"}
+	fs := a.flagSet("nanonets")
+	fs.Usage = func() { a.usage() }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		a.usage()
+		return exitUsage
+	}
+
+	if action, ok := actions[args[0]]; ok {
+		return a.finish(action.run(a, args[1:]))
+	}
+	subcommands, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "nanonets: unknown command %q\n", args[0])
+		a.usage()
+		return exitUsage
+	}
+	if len(args) < 2 || args[1] == "help" || args[1] == "-h" || args[1] == "--help" {
+		a.commandUsage(args[0], subcommands)
+		if len(args) < 2 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	cmd, ok := subcommands[args[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "nanonets: unknown command %q\n", args[0]+" "+args[1])
+		a.commandUsage(args[0], subcommands)
+		return exitUsage
+	}
+
+	return a.finish(cmd.run(a, args[2:]))
+}
+
+// finish reports a command's error and returns the exit code
+func (a *app) finish(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	fmt.Fprintf(a.stderr, "nanonets: %s\n", errorMessage(err))
+	return exitCode(err)
+}
+
+// errorMessage prefers the message of an API error over its raw body,
+// keeping any context an error wrapping it adds, such as "fetching
+// doc_1: "
+func errorMessage(err error) string {
+	var apiErr *nanonets.APIError
+	if errors.As(err, &apiErr) {
+		msg := apiErr.Message
+		if msg == "" {
+			msg = strings.TrimSpace(apiErr.Body)
+		}
+		if msg == "" {
+			msg = http.StatusText(apiErr.StatusCode)
+		}
+		prefix := strings.TrimSuffix(err.Error(), apiErr.Error())
+		if prefix == err.Error() {
+			prefix = ""
+		}
+		return fmt.Sprintf("%s%s (status %d, %s)", prefix, msg, apiErr.StatusCode, apiErr.Class())
+	}
+	return err.Error()
+}
+
+// exitCode returns the exit code for an error
+func exitCode(err error) int {
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return exitUsage
+	}
+	switch nanonets.ErrorClassOf(err) {
+	case nanonets.ErrorClassAuth:
+		return exitAuth
+	case nanonets.ErrorClassNotFound:
+		return exitNotFound
+	case nanonets.ErrorClassInvalid:
+		return exitInvalid
+	case nanonets.ErrorClassConflict:
+		return exitConflict
+	case nanonets.ErrorClassRateLimit:
+		return exitRateLimit
+	case nanonets.ErrorClassServer:
+		return exitServer
+	}
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return exitNetwork
+	}
+	return exitError
+}
+
+// flagSet returns a flag set with the global flags registered
+func (a *app) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.StringVar(&a.apiKey, "api-key", a.apiKey, "API key (overrides NANONETS_API_KEY and the profile)")
+	fs.StringVar(&a.baseURL, "base-url", a.baseURL, "API base URL")
+	fs.StringVar(&a.profile, "profile", a.profile, "config profile to use (default NANONETS_PROFILE or the config's default)")
+	fs.StringVar(&a.config, "config", a.config, "config file (default NANONETS_CONFIG or "+defaultConfigPath()+")")
+	fs.StringVar(&a.output, "o", a.output, "output format: table, json or yaml")
+	return fs
+}
+
+// parse parses flags that may come before, between or after positional
+// arguments and returns the positional arguments
+func (a *app) parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		if args[0] == "--" {
+			positional = append(positional, args[1:]...)
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	switch a.output {
+	case "table", "json", "yaml":
+	default:
+		return nil, usagef("unknown output format %q", a.output)
+	}
+	return positional, nil
+}
+
+// api returns the client, creating it from the flags, environment and
+// config file on first use
+func (a *app) api() (*nanonets.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+	creds, err := a.credentials()
+	if err != nil {
+		return nil, err
+	}
+	c := nanonets.NewClient(creds.APIKey)
+	if creds.BaseURL != "" {
+		c.BaseURL = strings.TrimRight(creds.BaseURL, "/")
+	}
+	a.client = c
+	return c, nil
+}
+
+func (a *app) usage() {
+	fmt.Fprintln(a.stderr, "Usage: nanonets [global flags] <command> <subcommand> [flags] [args]")
+	fmt.Fprintln(a.stderr, "\nCommands:")
+	summaries := map[string]string{}
+	for name, subcommands := range commands {
+		subs := make([]string, 0, len(subcommands))
+		for sub := range subcommands {
+			subs = append(subs, sub)
+		}
+		sort.Strings(subs)
+		summaries[name] = strings.Join(subs, ", ")
+	}
+	for name, action := range actions {
+		summaries[name] = action.summary
+	}
+	names := make([]string, 0, len(summaries))
+	for name := range summaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  %-10s %s\n", name, summaries[name])
+	}
+	fmt.Fprintln(a.stderr, "\nGlobal flags:")
+	fs := a.flagSet("nanonets")
+	fs.SetOutput(a.stderr)
+	fs.PrintDefaults()
+}
+
+func (a *app) commandUsage(name string, subcommands map[string]command) {
+	fmt.Fprintf(a.stderr, "Usage: nanonets %s <subcommand> [flags] [args]\n\nSubcommands:\n", name)
+	names := make([]string, 0, len(subcommands))
+	for sub := range subcommands {
+		names = append(names, sub)
+	}
+	sort.Strings(names)
+	for _, sub := range names {
+		fmt.Fprintf(a.stderr, "  %-14s %s\n", sub, subcommands[sub].summary)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+// runCLI runs the CLI against a fake server with an empty config file and
+// returns the exit code and output
+func runCLI(t *testing.T, s *nanonetstest.Server, args ...string) (int, string, string) {
+	t.Helper()
+	for _, env := range []string{envAPIKey, envBaseURL, envProfile, envConfig, envWorkflow} {
+		t.Setenv(env, "")
+	}
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	global := []string{"--config", config, "--api-key", nanonetstest.DefaultAPIKey, "--base-url", s.BaseURL()}
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), append(global, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestExitCodes(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	tests := []struct {
+		status int
+		want   int
+	}{
+		{http.StatusUnauthorized, exitAuth},
+		{http.StatusNotFound, exitNotFound},
+		{http.StatusBadRequest, exitInvalid},
+		{http.StatusConflict, exitConflict},
+		{http.StatusTooManyRequests, exitRateLimit},
+		{http.StatusBadGateway, exitServer},
+	}
+	for _, tt := range tests {
+		s.ClearFaults()
+		s.InjectFault(nanonetstest.Fault{Status: tt.status, Body: `{"message": "injected"}`})
+		code, _, stderr := runCLI(t, s, "workflows", "list")
+		if code != tt.want {
+			t.Errorf("status %d: exit code %d, want %d", tt.status, code, tt.want)
+		}
+		if !strings.Contains(stderr, "injected") {
+			t.Errorf("status %d: stderr %q has no API error message", tt.status, stderr)
+		}
+	}
+	s.ClearFaults()
+
+	if code, _, _ := runCLI(t, s, "workflows", "get", "wf_missing"); code != exitNotFound {
+		t.Errorf("missing workflow: exit code %d, want %d", code, exitNotFound)
+	}
+	if code, _, _ := runCLI(t, s, "workflows", "frobnicate"); code != exitUsage {
+		t.Errorf("unknown subcommand: exit code %d, want %d", code, exitUsage)
+	}
+	if code, _, _ := runCLI(t, s, "workflows", "get"); code != exitUsage {
+		t.Errorf("missing argument: exit code %d, want %d", code, exitUsage)
+	}
+	if code, _, _ := runCLI(t, s, "-o", "xml", "workflows", "list"); code != exitUsage {
+		t.Errorf("unknown output format: exit code %d, want %d", code, exitUsage)
+	}
+
+	closed := nanonetstest.NewServer()
+	closed.Close()
+	if code, _, _ := runCLI(t, closed, "workflows", "list"); code != exitNetwork {
+		t.Errorf("unreachable server: exit code %d, want %d", code, exitNetwork)
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	wf := s.AddWorkflow(nanonets.Workflow{
+		Description: "Invoices",
+		Fields:      []nanonets.Field{{Name: "total", Type: nanonets.FieldTypeNumber}},
+	})
+
+	code, stdout, stderr := runCLI(t, s, "workflows", "get", wf.ID, "-o", "json")
+	if code != exitOK {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	var got nanonets.Workflow
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("-o json: %v in %s", err, stdout)
+	}
+	if got.ID != wf.ID || len(got.Fields) != 1 || got.Fields[0].Name != "total" {
+		t.Errorf("-o json decoded as %+v", got)
+	}
+
+	code, stdout, stderr = runCLI(t, s, "-o", "yaml", "workflows", "list")
+	if code != exitOK {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	var list []map[string]interface{}
+	if err := yaml.Unmarshal([]byte(stdout), &list); err != nil {
+		t.Fatalf("-o yaml: %v in %s", err, stdout)
+	}
+	// Keys follow the JSON tags
+	if len(list) != 1 || list[0]["id"] != wf.ID || list[0]["description"] != "Invoices" {
+		t.Errorf("-o yaml decoded as %v", list)
+	}
+
+	code, stdout, _ = runCLI(t, s, "workflows", "list")
+	if code != exitOK || !strings.Contains(stdout, "DESCRIPTION") || !strings.Contains(stdout, wf.ID) {
+		t.Errorf("table output: exit code %d\n%s", code, stdout)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	t.Setenv(envAPIKey, "from-env")
+	t.Setenv(envBaseURL, "")
+	t.Setenv(envProfile, "")
+	t.Setenv(envConfig, "")
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	data := "default_profile: work\nprofiles:\n  work:\n    api_key: from-work\n  staging:\n    api_key: from-staging\n    base_url: https://staging.example.com/api/v4/\n"
+	if err := os.WriteFile(config, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		app  app
+		env  string
+		want Profile
+	}{
+		{app{config: config}, "", Profile{APIKey: "from-work"}},
+		{app{config: config, profile: "staging"}, "", Profile{APIKey: "from-staging", BaseURL: "https://staging.example.com/api/v4/"}},
+		{app{config: config}, "from-env", Profile{APIKey: "from-env"}},
+		{app{config: config, apiKey: "from-flag"}, "from-env", Profile{APIKey: "from-flag"}},
+	}
+	for _, tt := range tests {
+		t.Setenv(envAPIKey, tt.env)
+		got, err := tt.app.credentials()
+		if err != nil || got != tt.want {
+			t.Errorf("%+v with %s=%q: %+v, %v; want %+v", tt.app, envAPIKey, tt.env, got, err, tt.want)
+		}
+	}
+
+	a := app{config: config, profile: "prod"}
+	if _, err := a.credentials(); exitCode(err) != exitUsage || !strings.Contains(err.Error(), "staging, work") {
+		t.Errorf("unknown profile: %v", err)
+	}
+	a = app{config: filepath.Join(t.TempDir(), "missing.yaml")}
+	if _, err := a.credentials(); err == nil {
+		t.Error("missing config file given with --config: no error")
+	}
+}
@@ -0,0 +1,948 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/render"
+	"golang.org/x/term"
+)
+
+// Terminal control sequences
+const (
+	ansiClear      = "\x1b[H\x1b[2J"
+	ansiAltScreen  = "\x1b[?1049h"
+	ansiMainScreen = "\x1b[?1049l"
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+	ansiReverse    = "\x1b[7m"
+	ansiBold       = "\x1b[1m"
+	ansiDim        = "\x1b[2m"
+	ansiGreen      = "\x1b[32m"
+	ansiYellow     = "\x1b[33m"
+	ansiRed        = "\x1b[31m"
+	ansiBlue       = "\x1b[34m"
+	ansiMagenta    = "\x1b[35m"
+	ansiReset      = "\x1b[0m"
+)
+
+// Keys returned by readKey besides printable characters
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyPageUp    = "pgup"
+	keyPageDown  = "pgdn"
+	keyHome      = "home"
+	keyEnd       = "end"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+	keyClearLine = "ctrl-u"
+	keyInterrupt = "ctrl-c"
+)
+
+var keySequences = map[string]string{
+	"\x1b[A": keyUp, "\x1bOA": keyUp,
+	"\x1b[B": keyDown, "\x1bOB": keyDown,
+	"\x1b[5~": keyPageUp, "\x1b[6~": keyPageDown,
+	"\x1b[H": keyHome, "\x1b[1~": keyHome, "\x1bOH": keyHome,
+	"\x1b[F": keyEnd, "\x1b[4~": keyEnd, "\x1bOF": keyEnd,
+	"\r": keyEnter, "\n": keyEnter,
+	"\x1b": keyEscape,
+	"\x7f": keyBackspace, "\x08": keyBackspace,
+	"\x15": keyClearLine,
+	"\x03": keyInterrupt,
+}
+
+const reviewHelp = `Document list
+  up/down, j/k   move             enter    open document
+  r              reload           q        quit
+
+Document
+  up/down, j/k   move             pgup/pgdn, g/G   scroll
+  e, enter       edit value       d        delete field value or cell
+  v              verify           x        reject (asks for a message)
+  t              verify table     a        add a cell to the table
+  V              verify document  X        reject document
+  u              undo             r        reload
+  n/p            next/previous document
+  q, esc         back to the list
+
+Press any key to continue`
+
+// errQuit ends the review session
+var errQuit = errors.New("quit")
+
+func review(a *app, args []string) error {
+	fs := a.flagSet("review")
+	workflowID := workflowFlag(fs)
+	all := fs.Bool("all", false, "include documents that are already verified or rejected")
+	high := fs.Float64("high", render.DefaultHighConfidence, "confidence shown in green at or above")
+	low := fs.Float64("low", render.DefaultLowConfidence, "confidence shown in red below")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := positional(rest); err != nil {
+		return err
+	}
+	if err := requireWorkflow(*workflowID); err != nil {
+		return err
+	}
+	in, out := os.Stdin, os.Stdout
+	if !term.IsTerminal(int(in.Fd())) || !term.IsTerminal(int(out.Fd())) {
+		return usagef("review needs an interactive terminal")
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer term.Restore(int(in.Fd()), state)
+
+	r := newReviewer(a.ctx, c, *workflowID, in, out)
+	r.includeDone = *all
+	r.high, r.low = *high, *low
+	r.size = func() (int, int) {
+		w, h, err := term.GetSize(int(out.Fd()))
+		if err != nil {
+			return 80, 24
+		}
+		return w, h
+	}
+	return r.run()
+}
+
+// reviewer is the state of a review session. It reads keys from in and
+// redraws the whole screen on out after each one.
+type reviewer struct {
+	ctx        context.Context
+	client     *nanonets.Client
+	workflowID string
+	in         io.Reader
+	out        io.Writer
+	size       func() (width, height int)
+
+	includeDone bool
+	high, low   float64
+
+	docs       []nanonets.Document
+	listCursor int
+	listTop    int
+
+	doc    *nanonets.Document
+	rows   []reviewRow
+	cursor int
+	top    int
+
+	undo    []undoEntry
+	message string
+	keys    []string
+}
+
+// reviewRow is a line of the document screen
+type reviewRow struct {
+	page      *nanonets.Page
+	fieldName string
+	field     *nanonets.FieldData
+	table     *nanonets.Table
+	cell      *nanonets.TableCell
+}
+
+// undoEntry reverses one edit
+type undoEntry struct {
+	label string
+	undo  func() error
+	// ids are the field value and cell IDs the edit refers to
+	ids []string
+	// recreates is the ID of a deleted value or cell that undoing adds
+	// back under a new ID
+	recreates string
+}
+
+func newReviewer(ctx context.Context, c *nanonets.Client, workflowID string, in io.Reader, out io.Writer) *reviewer {
+	return &reviewer{
+		ctx:        ctx,
+		client:     c,
+		workflowID: workflowID,
+		in:         in,
+		out:        out,
+		size:       func() (int, int) { return 80, 24 },
+		high:       render.DefaultHighConfidence,
+		low:        render.DefaultLowConfidence,
+	}
+}
+
+func (r *reviewer) run() error {
+	fmt.Fprint(r.out, ansiAltScreen+ansiHideCursor)
+	defer fmt.Fprint(r.out, ansiShowCursor+ansiMainScreen)
+
+	if err := r.loadDocuments(); err != nil {
+		return err
+	}
+	for {
+		r.draw()
+		key, err := r.readKey()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if r.doc == nil {
+			err = r.listKey(key)
+		} else {
+			err = r.documentKey(key)
+		}
+		if errors.Is(err, errQuit) {
+			return nil
+		}
+		if err != nil {
+			r.message = "error: " + errorMessage(err)
+		}
+	}
+}
+
+// loadDocuments fetches the documents awaiting review, oldest first
+func (r *reviewer) loadDocuments() error {
+	docs, err := r.client.Documents.List(r.workflowID)
+	if err != nil {
+		return err
+	}
+	r.docs = r.docs[:0]
+	for _, d := range docs {
+		if r.includeDone || (d.Status == nanonets.DocumentStatusCompleted && !d.VerificationStatus.IsTerminal()) {
+			r.docs = append(r.docs, d)
+		}
+	}
+	nanonets.SortDocumentsByUploadedAt(r.docs, false)
+	if r.listCursor >= len(r.docs) {
+		r.listCursor = len(r.docs) - 1
+	}
+	if r.listCursor < 0 {
+		r.listCursor = 0
+	}
+	r.message = fmt.Sprintf("%d document(s) to review", len(r.docs))
+	return nil
+}
+
+func (r *reviewer) listKey(key string) error {
+	switch key {
+	case "q", keyInterrupt, keyEscape:
+		return errQuit
+	case keyUp, "k":
+		r.listCursor = clamp(r.listCursor-1, 0, len(r.docs)-1)
+	case keyDown, "j":
+		r.listCursor = clamp(r.listCursor+1, 0, len(r.docs)-1)
+	case keyHome, "g":
+		r.listCursor = 0
+	case keyEnd, "G":
+		r.listCursor = clamp(len(r.docs)-1, 0, len(r.docs)-1)
+	case "r":
+		return r.loadDocuments()
+	case "?":
+		return r.showHelp()
+	case keyEnter, "l":
+		if len(r.docs) == 0 {
+			return nil
+		}
+		return r.open(r.docs[r.listCursor].DocumentID)
+	}
+	return nil
+}
+
+// open loads a document and shows it
+func (r *reviewer) open(documentID string) error {
+	doc, err := r.client.Documents.Get(r.workflowID, documentID)
+	if err != nil {
+		return err
+	}
+	if r.doc == nil || r.doc.DocumentID != documentID {
+		r.cursor, r.top = 0, 0
+		r.undo = nil
+	}
+	r.setDocument(doc)
+	return nil
+}
+
+// reload fetches the open document again, keeping the cursor in place
+func (r *reviewer) reload() error {
+	doc, err := r.client.Documents.Get(r.workflowID, r.doc.DocumentID)
+	if err != nil {
+		return err
+	}
+	r.setDocument(doc)
+	return nil
+}
+
+func (r *reviewer) setDocument(doc *nanonets.Document) {
+	r.doc = doc
+	r.rows = r.rows[:0]
+	for i := range doc.Pages {
+		p := &doc.Pages[i]
+		for _, name := range nanonets.FieldNames(p.Data.Fields) {
+			values := p.Data.Fields[name]
+			for j := range values {
+				r.rows = append(r.rows, reviewRow{page: p, fieldName: name, field: &values[j]})
+			}
+		}
+		for j := range p.Data.Tables {
+			t := &p.Data.Tables[j]
+			r.rows = append(r.rows, reviewRow{page: p, table: t})
+			sortCells(t.Cells)
+			for k := range t.Cells {
+				r.rows = append(r.rows, reviewRow{page: p, table: t, cell: &t.Cells[k]})
+			}
+		}
+	}
+	r.cursor = clamp(r.cursor, 0, len(r.rows)-1)
+	for i, d := range r.docs {
+		if d.DocumentID == doc.DocumentID {
+			r.listCursor = i
+		}
+	}
+}
+
+func (r *reviewer) documentKey(key string) error {
+	_, height := r.size()
+	page := height - 6
+	switch key {
+	case keyInterrupt:
+		return errQuit
+	case "q", keyEscape:
+		r.doc = nil
+		return r.loadDocuments()
+	case keyUp, "k":
+		r.cursor = clamp(r.cursor-1, 0, len(r.rows)-1)
+	case keyDown, "j":
+		r.cursor = clamp(r.cursor+1, 0, len(r.rows)-1)
+	case keyPageUp:
+		r.cursor = clamp(r.cursor-page, 0, len(r.rows)-1)
+	case keyPageDown:
+		r.cursor = clamp(r.cursor+page, 0, len(r.rows)-1)
+	case keyHome, "g":
+		r.cursor = 0
+	case keyEnd, "G":
+		r.cursor = clamp(len(r.rows)-1, 0, len(r.rows)-1)
+	case "n", "p":
+		step := 1
+		if key == "p" {
+			step = -1
+		}
+		next := r.listCursor + step
+		if next < 0 || next >= len(r.docs) {
+			r.message = "no more documents"
+			return nil
+		}
+		return r.open(r.docs[next].DocumentID)
+	case "r":
+		if err := r.reload(); err != nil {
+			return err
+		}
+		r.message = "reloaded"
+	case "?":
+		return r.showHelp()
+	case "u":
+		return r.undoLast()
+	case "e", keyEnter:
+		return r.edit()
+	case "v":
+		return r.verify(nanonets.VerificationStatusVerified)
+	case "x":
+		return r.verify(nanonets.VerificationStatusRejected)
+	case "t":
+		return r.verifyTable()
+	case "a":
+		return r.addCell()
+	case "d":
+		return r.delete()
+	case "V":
+		return r.verifyDocument(nanonets.VerificationStatusVerified)
+	case "X":
+		return r.verifyDocument(nanonets.VerificationStatusRejected)
+	}
+	return nil
+}
+
+func (r *reviewer) selected() *reviewRow {
+	if r.cursor < 0 || r.cursor >= len(r.rows) {
+		return nil
+	}
+	return &r.rows[r.cursor]
+}
+
+// apply applies a single-operation change set, pushes its revert onto the
+// undo stack when the operation can be reverted, and reloads the document
+func (r *reviewer) apply(label string, cs *nanonets.ChangeSet) error {
+	if _, err := cs.Apply(r.ctx, r.client.Moderation, 1); err != nil {
+		return err
+	}
+	reversible := true
+	entry := undoEntry{label: label}
+	for _, op := range cs.Operations() {
+		switch op.Kind {
+		case nanonets.OpAddField, nanonets.OpAddTable, nanonets.OpAddTableCell, nanonets.OpVerifyTable:
+			reversible = false
+		}
+		id := op.FieldDataID
+		if op.CellID != "" {
+			id = op.CellID
+		}
+		if id != "" {
+			entry.ids = append(entry.ids, id)
+		}
+		if op.Kind == nanonets.OpDeleteField || op.Kind == nanonets.OpDeleteTableCell {
+			entry.recreates = id
+		}
+	}
+	r.message = label
+	if reversible {
+		entry.undo = func() error {
+			_, err := cs.Revert(r.ctx, r.client.Moderation)
+			return err
+		}
+		r.push(entry)
+	} else {
+		r.message += " (can't be undone)"
+	}
+	return r.reload()
+}
+
+func (r *reviewer) push(entry undoEntry) {
+	r.undo = append(r.undo, entry)
+}
+
+func (r *reviewer) undoLast() error {
+	if len(r.undo) == 0 {
+		r.message = "nothing to undo"
+		return nil
+	}
+	last := r.undo[len(r.undo)-1]
+	if err := last.undo(); err != nil {
+		return fmt.Errorf("undoing %s: %w", last.label, err)
+	}
+	r.undo = r.undo[:len(r.undo)-1]
+	r.message = "undid " + last.label
+
+	// Undoing a delete adds the value back under a new ID, so earlier
+	// edits of it would fail; they are dropped from the stack
+	if last.recreates != "" {
+		kept, dropped := r.undo[:0], 0
+		for _, e := range r.undo {
+			if containsString(e.ids, last.recreates) {
+				dropped++
+				continue
+			}
+			kept = append(kept, e)
+		}
+		r.undo = kept
+		if dropped > 0 {
+			r.message += fmt.Sprintf(" (%d earlier edit(s) of it can no longer be undone)", dropped)
+		}
+	}
+	return r.reload()
+}
+
+func (r *reviewer) edit() error {
+	row := r.selected()
+	if row == nil || (row.field == nil && row.cell == nil) {
+		r.message = "select a field value or cell to edit"
+		return nil
+	}
+	if row.field != nil {
+		value, ok, err := r.prompt("New value for "+row.fieldName, row.field.Value)
+		if err != nil || !ok || value == row.field.Value {
+			return err
+		}
+		cs := nanonets.NewChangeSet(r.workflowID, r.doc).UpdateField(row.page.PageID, row.field.FieldDataID, value)
+		return r.apply(fmt.Sprintf("set %s to %q", row.fieldName, value), cs)
+	}
+	value, ok, err := r.prompt(fmt.Sprintf("New text for %s row %d", cellName(row.cell), row.cell.Row), row.cell.Text)
+	if err != nil || !ok || value == row.cell.Text {
+		return err
+	}
+	cs := nanonets.NewChangeSet(r.workflowID, r.doc).UpdateTableCell(row.page.PageID, row.table.TableID, row.cell.CellID, value)
+	return r.apply(fmt.Sprintf("set %s row %d to %q", cellName(row.cell), row.cell.Row, value), cs)
+}
+
+func (r *reviewer) verify(status nanonets.VerificationStatus) error {
+	row := r.selected()
+	if row == nil || (row.field == nil && row.cell == nil) {
+		r.message = "select a field value or cell to verify"
+		return nil
+	}
+	message, ok, err := r.verificationMessage(status)
+	if err != nil || !ok {
+		return err
+	}
+	cs := nanonets.NewChangeSet(r.workflowID, r.doc)
+	var label string
+	if row.field != nil {
+		cs.VerifyField(row.page.PageID, row.field.FieldDataID, nanonets.VerifyFieldRequest{VerificationStatus: status, VerificationMessage: message})
+		label = fmt.Sprintf("marked %s %s", row.fieldName, status)
+	} else {
+		cs.VerifyTableCell(row.page.PageID, row.table.TableID, row.cell.CellID, nanonets.VerifyTableCellRequest{VerificationStatus: status, VerificationMessage: message})
+		label = fmt.Sprintf("marked %s row %d %s", cellName(row.cell), row.cell.Row, status)
+	}
+	if err := r.apply(label, cs); err != nil {
+		return err
+	}
+	r.cursor = clamp(r.cursor+1, 0, len(r.rows)-1)
+	return nil
+}
+
+// verificationMessage asks for a message when rejecting
+func (r *reviewer) verificationMessage(status nanonets.VerificationStatus) (string, bool, error) {
+	if status != nanonets.VerificationStatusRejected {
+		return "", true, nil
+	}
+	return r.prompt("Reason for rejecting", "")
+}
+
+func (r *reviewer) verifyTable() error {
+	row := r.selected()
+	if row == nil || row.table == nil {
+		r.message = "select a table or one of its cells"
+		return nil
+	}
+	cs := nanonets.NewChangeSet(r.workflowID, r.doc).VerifyTable(row.page.PageID, row.table.TableID,
+		nanonets.VerifyTableRequest{VerificationStatus: nanonets.VerificationStatusVerified})
+	return r.apply("verified table "+row.table.TableID, cs)
+}
+
+func (r *reviewer) addCell() error {
+	row := r.selected()
+	if row == nil || row.table == nil {
+		r.message = "select a table or one of its cells"
+		return nil
+	}
+	defRow, defCol, defHeader := 0, 0, ""
+	if row.cell != nil {
+		defRow, defCol = row.cell.Row, row.cell.Col+1
+	}
+	req := nanonets.AddTableCellRequest{}
+	var ok bool
+	var err error
+	if req.Row, ok, err = r.promptInt("Row", defRow); err != nil || !ok {
+		return err
+	}
+	if req.Col, ok, err = r.promptInt("Column", defCol); err != nil || !ok {
+		return err
+	}
+	for _, c := range row.table.Cells {
+		if c.Col == req.Col && c.Header != "" {
+			defHeader = c.Header
+			break
+		}
+	}
+	if req.Header, ok, err = r.prompt("Header", defHeader); err != nil || !ok {
+		return err
+	}
+	if req.Text, ok, err = r.prompt("Text", ""); err != nil || !ok {
+		return err
+	}
+
+	before := map[string]bool{}
+	for _, c := range row.table.Cells {
+		before[c.CellID] = true
+	}
+	pageID, tableID := row.page.PageID, row.table.TableID
+	cs := nanonets.NewChangeSet(r.workflowID, r.doc).AddTableCell(pageID, tableID, req)
+	if _, err := cs.Apply(r.ctx, r.client.Moderation, 1); err != nil {
+		return err
+	}
+	if err := r.reload(); err != nil {
+		return err
+	}
+	label := fmt.Sprintf("added cell (%d, %d) to table %s", req.Row, req.Col, tableID)
+	r.message = label
+
+	// The API doesn't return the new cell's ID, so it is found by
+	// comparing the table before and after. Undoing deletes it.
+	for _, row := range r.rows {
+		if row.cell != nil && row.table.TableID == tableID && !before[row.cell.CellID] &&
+			row.cell.Row == req.Row && row.cell.Col == req.Col {
+			cellID, snapshot := row.cell.CellID, r.doc
+			r.push(undoEntry{label: label, ids: []string{cellID}, undo: func() error {
+				undo := nanonets.NewChangeSet(r.workflowID, snapshot).DeleteTableCell(pageID, tableID, cellID)
+				_, err := undo.Apply(r.ctx, r.client.Moderation, 1)
+				return err
+			}})
+			return nil
+		}
+	}
+	r.message += " (can't be undone)"
+	return nil
+}
+
+func (r *reviewer) delete() error {
+	row := r.selected()
+	if row == nil || (row.field == nil && row.cell == nil) {
+		r.message = "select a field value or cell to delete"
+		return nil
+	}
+	var label string
+	cs := nanonets.NewChangeSet(r.workflowID, r.doc)
+	if row.field != nil {
+		label = fmt.Sprintf("deleted %s value %q", row.fieldName, row.field.Value)
+		cs.DeleteField(row.page.PageID, row.field.FieldDataID)
+	} else {
+		label = fmt.Sprintf("deleted %s row %d", cellName(row.cell), row.cell.Row)
+		cs.DeleteTableCell(row.page.PageID, row.table.TableID, row.cell.CellID)
+	}
+	ok, err := r.confirm(strings.ToUpper(label[:1]) + label[1:] + "?")
+	if err != nil || !ok {
+		return err
+	}
+	return r.apply(label, cs)
+}
+
+func (r *reviewer) verifyDocument(status nanonets.VerificationStatus) error {
+	message, ok, err := r.verificationMessage(status)
+	if err != nil || !ok {
+		return err
+	}
+	documentID := r.doc.DocumentID
+	prev := nanonets.VerifyDocumentRequest{VerificationStatus: r.doc.VerificationStatus, VerificationMessage: r.doc.VerificationMessage}
+	if !prev.VerificationStatus.IsValid() {
+		prev.VerificationStatus = nanonets.VerificationStatusUnverified
+	}
+	req := nanonets.VerifyDocumentRequest{VerificationStatus: status, VerificationMessage: message}
+	if err := r.client.Moderation.VerifyDocument(r.workflowID, documentID, req); err != nil {
+		return err
+	}
+	label := fmt.Sprintf("marked document %s", status)
+	r.push(undoEntry{label: label, undo: func() error {
+		return r.client.Moderation.VerifyDocument(r.workflowID, documentID, prev)
+	}})
+	r.message = label
+	return r.reload()
+}
+
+// prompt reads a line on the bottom row. ok is false if it was cancelled
+// with escape.
+func (r *reviewer) prompt(label, initial string) (string, bool, error) {
+	value := []rune(initial)
+	fmt.Fprint(r.out, ansiShowCursor)
+	defer fmt.Fprint(r.out, ansiHideCursor)
+	for {
+		width, height := r.size()
+		line := label + ": " + string(value)
+		if n := utf8.RuneCountInString(line); n >= width {
+			line = "…" + string([]rune(line)[n-width+2:])
+		}
+		fmt.Fprintf(r.out, "\x1b[%d;1H\x1b[2K%s", height, line)
+		key, err := r.readKey()
+		if err != nil {
+			return "", false, err
+		}
+		switch key {
+		case keyEnter:
+			return string(value), true, nil
+		case keyEscape, keyInterrupt:
+			r.message = "cancelled"
+			return "", false, nil
+		case keyBackspace:
+			if len(value) > 0 {
+				value = value[:len(value)-1]
+			}
+		case keyClearLine:
+			value = value[:0]
+		default:
+			// Named keys are words; typed characters come one at a time
+			if ch, size := utf8.DecodeRuneInString(key); size == len(key) && unicode.IsPrint(ch) {
+				value = append(value, ch)
+			}
+		}
+	}
+}
+
+func (r *reviewer) promptInt(label string, initial int) (int, bool, error) {
+	for {
+		s, ok, err := r.prompt(label, strconv.Itoa(initial))
+		if err != nil || !ok {
+			return 0, ok, err
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && n >= 0 {
+			return n, true, nil
+		}
+		label = strings.TrimSuffix(label, " (a number)") + " (a number)"
+	}
+}
+
+func (r *reviewer) confirm(question string) (bool, error) {
+	answer, ok, err := r.prompt(question+" [y/N]", "")
+	if err != nil || !ok {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		r.message = "cancelled"
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *reviewer) showHelp() error {
+	var b bytes.Buffer
+	b.WriteString(ansiClear)
+	for _, line := range strings.Split(reviewHelp, "\n") {
+		b.WriteString(line + "\r\n")
+	}
+	r.out.Write(b.Bytes())
+	_, err := r.readKey()
+	return err
+}
+
+// readKey returns the next key press. A read that isn't an escape
+// sequence, such as pasted text, is returned one character at a time.
+func (r *reviewer) readKey() (string, error) {
+	if len(r.keys) > 0 {
+		key := r.keys[0]
+		r.keys = r.keys[1:]
+		return key, nil
+	}
+	buf := make([]byte, 64)
+	n, err := r.in.Read(buf)
+	if n == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return "", err
+	}
+	s := string(buf[:n])
+	if name, ok := namedKey(s); ok {
+		return name, nil
+	}
+	if strings.HasPrefix(s, "\x1b") {
+		// Unknown escape sequence
+		return "", nil
+	}
+	for _, ch := range s {
+		key := string(ch)
+		if name, ok := namedKey(key); ok {
+			key = name
+		}
+		r.keys = append(r.keys, key)
+	}
+	return r.readKey()
+}
+
+func namedKey(s string) (string, bool) {
+	name, ok := keySequences[s]
+	return name, ok
+}
+
+func (r *reviewer) draw() {
+	width, height := r.size()
+	var lines []string
+	var status string
+	if r.doc == nil {
+		lines, status = r.listLines(width, height)
+	} else {
+		lines, status = r.documentLines(width, height)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(ansiClear)
+	for i := 0; i < height-2 && i < len(lines); i++ {
+		b.WriteString(lines[i])
+		b.WriteString(ansiReset + "\r\n")
+	}
+	fmt.Fprintf(&b, "\x1b[%d;1H%s%s%s", height-1, ansiDim, fit(status, width), ansiReset)
+	fmt.Fprintf(&b, "\x1b[%d;1H%s", height, fit(r.message, width))
+	r.out.Write(b.Bytes())
+}
+
+func (r *reviewer) listLines(width, height int) ([]string, string) {
+	lines := []string{ansiBold + fit(fmt.Sprintf("Workflow %s: %d document(s) to review", r.workflowID, len(r.docs)), width) + ansiReset,
+		fit(fmt.Sprintf("  %-24s %-32s %-12s %-12s %s", "ID", "NAME", "STATUS", "VERIFICATION", "UPLOADED"), width)}
+	visible := height - 4
+	r.listTop = scrollTop(r.listCursor, r.listTop, visible)
+	for i := r.listTop; i < len(r.docs) && i < r.listTop+visible; i++ {
+		d := r.docs[i]
+		line := fit(fmt.Sprintf("  %-24s %-32s %-12s %-12s %s", d.DocumentID, d.OriginalDocumentName, d.Status, d.VerificationStatus, formatTime(d.UploadedAt)), width)
+		if i == r.listCursor {
+			line = ansiReverse + pad(line, width)
+		}
+		lines = append(lines, line)
+	}
+	if len(r.docs) == 0 {
+		lines = append(lines, "  Nothing to review. Press r to reload or q to quit.")
+	}
+	return lines, "enter open · r reload · ? help · q quit"
+}
+
+func (r *reviewer) documentLines(width, height int) ([]string, string) {
+	d := r.doc
+	lines := []string{
+		ansiBold + fit(fmt.Sprintf("Document %s  %s  (%d of %d)", d.DocumentID, d.OriginalDocumentName, r.listCursor+1, len(r.docs)), width) + ansiReset,
+		fit(fmt.Sprintf("  status %s · verification %s%s", d.Status, verificationColor(d.VerificationStatus), d.VerificationStatus), width) + ansiReset,
+	}
+	visible := height - 5
+	r.top = scrollTop(r.cursor, r.top, visible)
+	for i := r.top; i < len(r.rows) && i < r.top+visible; i++ {
+		line := r.rowLine(&r.rows[i], width)
+		if i == r.cursor {
+			line = ansiReverse + pad(stripANSI(line), width)
+		}
+		lines = append(lines, line)
+	}
+	if len(r.rows) == 0 {
+		lines = append(lines, "  No fields or tables were extracted.")
+	}
+	undo := ""
+	if len(r.undo) > 0 {
+		undo = fmt.Sprintf(" · u undo (%d)", len(r.undo))
+	}
+	return lines, "e edit · v verify · x reject · t verify table · a add cell · d delete · V/X document · n/p next/prev" + undo + " · ? help · q back"
+}
+
+func (r *reviewer) rowLine(row *reviewRow, width int) string {
+	switch {
+	case row.field != nil:
+		f := row.field
+		return fmt.Sprintf("  p%-3d %-28s %s %s%s",
+			row.page.PageNumber, trim(row.fieldName, 28), pad(trim(f.Value, 40), 40), r.confidence(f.Confidence), statusLabel(f.VerificationStatus))
+	case row.cell != nil:
+		c := row.cell
+		return fmt.Sprintf("       %-28s %s       %s",
+			trim(fmt.Sprintf("r%d %s", c.Row, cellName(c)), 28), pad(trim(c.Text, 40), 40), statusLabel(c.VerificationStatus))
+	default:
+		return ansiBold + fit(fmt.Sprintf("  p%-3d table %s (%d cells)", row.page.PageNumber, row.table.TableID, len(row.table.Cells)), width) + ansiReset
+	}
+}
+
+// confidence formats a confidence score colored by band
+func (r *reviewer) confidence(c float64) string {
+	color := ansiRed
+	switch {
+	case c >= r.high:
+		color = ansiGreen
+	case c >= r.low:
+		color = ansiYellow
+	}
+	return fmt.Sprintf("%s%5.2f%s  ", color, c, ansiReset)
+}
+
+func statusLabel(s nanonets.VerificationStatus) string {
+	if s == "" {
+		s = nanonets.VerificationStatusUnverified
+	}
+	return verificationColor(s) + string(s) + ansiReset
+}
+
+func verificationColor(s nanonets.VerificationStatus) string {
+	switch s {
+	case nanonets.VerificationStatusVerified:
+		return ansiBlue
+	case nanonets.VerificationStatusRejected:
+		return ansiMagenta
+	}
+	return ""
+}
+
+func cellName(c *nanonets.TableCell) string {
+	if c.Header != "" {
+		return c.Header
+	}
+	return fmt.Sprintf("col %d", c.Col)
+}
+
+// sortCells orders cells by row, then column
+func sortCells(cells []nanonets.TableCell) {
+	sort.SliceStable(cells, func(i, j int) bool {
+		if cells[i].Row != cells[j].Row {
+			return cells[i].Row < cells[j].Row
+		}
+		return cells[i].Col < cells[j].Col
+	})
+}
+
+// scrollTop returns the first visible line that keeps the cursor on
+// screen
+func scrollTop(cursor, top, visible int) int {
+	if visible < 1 {
+		visible = 1
+	}
+	if cursor < top {
+		return cursor
+	}
+	if cursor >= top+visible {
+		return cursor - visible + 1
+	}
+	return top
+}
+
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// trim shortens s to n runes, ending it with an ellipsis if it was cut
+func trim(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+func pad(s string, n int) string {
+	if k := utf8.RuneCountInString(stripANSI(s)); k < n {
+		return s + strings.Repeat(" ", n-k)
+	}
+	return s
+}
+
+// fit trims a line without escape sequences to the screen width
+func fit(s string, width int) string {
+	if width < 2 {
+		return s
+	}
+	return trim(s, width-1)
+}
+
+// stripANSI removes CSI escape sequences such as colors
+func stripANSI(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '[' {
+			// Skip to the final byte of the sequence
+			for i += 2; i < len(s) && (s[i] < 0x40 || s[i] > 0x7e); i++ {
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+// newReview opens a document with a vendor field and a one-cell table in
+// a reviewer. Its rows are the vendor value, the table and the cell.
+func newReview(t *testing.T) (*nanonetstest.Server, *reviewer) {
+	t.Helper()
+	s := nanonetstest.NewServer()
+	t.Cleanup(s.Close)
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	doc, err := s.AddDocument(wf.ID, nanonets.Document{Pages: []nanonets.Page{{Data: nanonets.PageData{
+		Fields: map[string][]nanonets.FieldData{"vendor": {{Value: "Acme", Confidence: 0.5}}},
+		Tables: []nanonets.Table{{Cells: []nanonets.TableCell{{Row: 0, Col: 0, Header: "quantity", Text: "1"}}}},
+	}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newReviewer(context.Background(), s.Client(), wf.ID, strings.NewReader(""), io.Discard)
+	if err := r.loadDocuments(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.open(doc.DocumentID); err != nil {
+		t.Fatal(err)
+	}
+	return s, r
+}
+
+// press runs a document screen key with typed input queued for prompts
+func press(t *testing.T, r *reviewer, key string, input ...string) {
+	t.Helper()
+	r.keys = append(r.keys, input...)
+	if err := r.documentKey(key); err != nil {
+		t.Fatalf("%s: %v", key, err)
+	}
+	if len(r.keys) > 0 {
+		t.Fatalf("%s left input %q unread", key, r.keys)
+	}
+}
+
+// typed returns the keys that replace a prompt's value with text
+func typed(text string) []string {
+	keys := []string{keyClearLine}
+	for _, ch := range text {
+		keys = append(keys, string(ch))
+	}
+	return append(keys, keyEnter)
+}
+
+// vendor returns the vendor values stored on the server
+func vendor(t *testing.T, s *nanonetstest.Server, r *reviewer) []nanonets.FieldData {
+	t.Helper()
+	doc, ok := s.Document(r.doc.DocumentID)
+	if !ok {
+		t.Fatal("document gone")
+	}
+	return doc.Pages[0].Data.Fields["vendor"]
+}
+
+func TestReviewUndoStack(t *testing.T) {
+	s, r := newReview(t)
+
+	press(t, r, "e", typed("Globex")...)
+	r.cursor = 0
+	press(t, r, "v")
+	press(t, r, "V")
+	if len(r.undo) != 3 {
+		t.Fatalf("%d undo entries, want 3", len(r.undo))
+	}
+	if got := vendor(t, s, r)[0]; got.Value != "Globex" || got.VerificationStatus != nanonets.VerificationStatusVerified {
+		t.Fatalf("vendor %+v after edit and verify", got)
+	}
+	if doc, _ := s.Document(r.doc.DocumentID); doc.VerificationStatus != nanonets.VerificationStatusVerified {
+		t.Fatalf("document %s, want verified", doc.VerificationStatus)
+	}
+
+	// Edits are undone last first
+	press(t, r, "u")
+	if doc, _ := s.Document(r.doc.DocumentID); doc.VerificationStatus != nanonets.VerificationStatusUnverified {
+		t.Errorf("document %s after undo, want unverified", doc.VerificationStatus)
+	}
+	press(t, r, "u")
+	if got := vendor(t, s, r)[0]; got.VerificationStatus != nanonets.VerificationStatusUnverified || got.Value != "Globex" {
+		t.Errorf("vendor %+v after undoing the verify", got)
+	}
+	press(t, r, "u")
+	if got := vendor(t, s, r)[0]; got.Value != "Acme" {
+		t.Errorf("vendor %q after undoing the edit, want Acme", got.Value)
+	}
+	press(t, r, "u")
+	if r.message != "nothing to undo" {
+		t.Errorf("message %q on an empty stack", r.message)
+	}
+}
+
+func TestReviewUndoDeleteDropsEdits(t *testing.T) {
+	s, r := newReview(t)
+	originalID := vendor(t, s, r)[0].FieldDataID
+
+	press(t, r, "e", typed("Globex")...)
+	press(t, r, "d", typed("y")...)
+	if got := vendor(t, s, r); len(got) != 0 {
+		t.Fatalf("vendor %+v after delete", got)
+	}
+
+	// The value comes back under a new ID, so the edit before the delete
+	// can no longer be undone and is dropped
+	press(t, r, "u")
+	got := vendor(t, s, r)
+	if len(got) != 1 || got[0].Value != "Globex" || got[0].FieldDataID == originalID {
+		t.Errorf("vendor %+v, want Globex back under a new ID", got)
+	}
+	if len(r.undo) != 0 || !strings.Contains(r.message, "1 earlier edit(s) of it can no longer be undone") {
+		t.Errorf("%d undo entries, message %q", len(r.undo), r.message)
+	}
+}
+
+func TestReviewUndoAddCell(t *testing.T) {
+	s, r := newReview(t)
+	// The table row
+	r.cursor = 1
+	press(t, r, "a", append(append(append(typed("1"), typed("0")...), keyEnter), typed("7")...)...)
+	doc, _ := s.Document(r.doc.DocumentID)
+	cells := doc.Pages[0].Data.Tables[0].Cells
+	if len(cells) != 2 {
+		t.Fatalf("%d cells after adding one", len(cells))
+	}
+	var added nanonets.TableCell
+	for _, c := range cells {
+		if c.Row == 1 {
+			added = c
+		}
+	}
+	// The header defaults to that of the column
+	if added.Text != "7" || added.Header != "quantity" {
+		t.Errorf("added cell %+v", added)
+	}
+
+	press(t, r, "u")
+	doc, _ = s.Document(r.doc.DocumentID)
+	if cells := doc.Pages[0].Data.Tables[0].Cells; len(cells) != 1 || cells[0].Row != 0 {
+		t.Errorf("cells %+v after undo, want the added cell deleted", cells)
+	}
+}
+
+func TestReviewCancelledPromptChangesNothing(t *testing.T) {
+	s, r := newReview(t)
+	press(t, r, "e", "x", keyEscape)
+	press(t, r, "d", "n", keyEnter)
+	if got := vendor(t, s, r); len(got) != 1 || got[0].Value != "Acme" {
+		t.Errorf("vendor %+v after cancelled edits", got)
+	}
+	if len(r.undo) != 0 {
+		t.Errorf("%d undo entries for cancelled edits", len(r.undo))
+	}
+	if n := len(s.Requests()); n != 2 {
+		t.Errorf("%d requests, want only the list and the document read", n)
+	}
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/fsnotify/fsnotify"
+)
+
+// partialSuffixes mark files that are still being written by a browser,
+// scanner or copy tool
+var partialSuffixes = []string{".tmp", ".part", ".partial", ".crdownload", ".download", ".filepart"}
+
+// watcher uploads files from a folder once they stop changing
+type watcher struct {
+	client     *nanonets.Client
+	workflowID string
+	dir        string
+	processed  string
+	failed     string
+	pattern    string
+	metadata   keyValues
+	async      bool
+	interval   time.Duration
+	settle     time.Duration
+	timeout    time.Duration
+	retries    int
+	log        *log.Logger
+
+	mu      sync.Mutex
+	pending map[string]*fileState
+	// unmoved holds files that were handled but could not be moved out of
+	// the folder, so that they aren't uploaded again
+	unmoved map[string]*fileState
+	counts  struct{ processed, failed int }
+}
+
+// fileState tracks a file until it is handed to a worker
+type fileState struct {
+	size        int64
+	modTime     time.Time
+	stableSince time.Time
+	queued      bool
+}
+
+// sidecar is written next to each processed or failed file, or on its own
+// in the destination folder if the file could not be moved
+type sidecar struct {
+	Source      string             `json:"source"`
+	File        string             `json:"file"`
+	WorkflowID  string             `json:"workflow_id"`
+	DocumentID  string             `json:"document_id,omitempty"`
+	Status      string             `json:"status"`
+	Error       string             `json:"error,omitempty"`
+	ErrorClass  string             `json:"error_class,omitempty"`
+	StartedAt   time.Time          `json:"started_at"`
+	FinishedAt  time.Time          `json:"finished_at"`
+	Document    *nanonets.Document `json:"document,omitempty"`
+	Attempts    int                `json:"attempts"`
+	Interrupted bool               `json:"interrupted,omitempty"`
+}
+
+func watch(a *app, args []string) error {
+	fs := a.flagSet("watch")
+	workflowID := workflowFlag(fs)
+	w := &watcher{metadata: keyValues{}, pending: map[string]*fileState{}, unmoved: map[string]*fileState{}}
+	fs.StringVar(&w.processed, "processed-dir", "", "where uploaded files are moved (default <dir>/processed)")
+	fs.StringVar(&w.failed, "failed-dir", "", "where files that failed are moved (default <dir>/failed)")
+	fs.StringVar(&w.pattern, "pattern", "", "only upload files whose names match this glob, e.g. *.pdf")
+	fs.Var(w.metadata, "metadata", "metadata key=value attached to every upload (repeatable)")
+	fs.BoolVar(&w.async, "async", false, "upload asynchronously and poll until processing finishes")
+	fs.DurationVar(&w.interval, "interval", time.Second, "how often files are checked, and the folder rescanned when polling")
+	fs.DurationVar(&w.settle, "settle", 2*time.Second, "how long a file's size must stay unchanged before it is uploaded")
+	fs.DurationVar(&w.timeout, "timeout", 10*time.Minute, "how long to wait for each document to finish processing")
+	fs.IntVar(&w.retries, "retries", 3, "retries for rate-limited, server and network errors")
+	concurrency := fs.Int("concurrency", 2, "number of files uploaded at once")
+	poll := fs.Bool("poll", false, "poll the folder instead of using filesystem notifications")
+	once := fs.Bool("once", false, "upload the files already in the folder, then exit")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := positional(rest, "<dir>"); err != nil {
+		return err
+	}
+	if err := requireWorkflow(*workflowID); err != nil {
+		return err
+	}
+	if *concurrency < 1 || w.interval <= 0 || w.settle < 0 {
+		return usagef("--concurrency and --interval must be positive and --settle must not be negative")
+	}
+	if w.pattern != "" {
+		if _, err := filepath.Match(w.pattern, ""); err != nil {
+			return usagef("--pattern: %v", err)
+		}
+	}
+	info, err := os.Stat(rest[0])
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return usagef("%s is not a directory", rest[0])
+	}
+	if w.client, err = a.api(); err != nil {
+		return err
+	}
+	w.workflowID = *workflowID
+	w.dir = rest[0]
+	if w.processed == "" {
+		w.processed = filepath.Join(w.dir, "processed")
+	}
+	if w.failed == "" {
+		w.failed = filepath.Join(w.dir, "failed")
+	}
+	for _, dir := range []string{w.processed, w.failed} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	w.log = log.New(a.stderr, "", log.LstdFlags)
+
+	// The first signal stops intake and lets in-flight files finish; a
+	// second one stops retries and waiting for processing. An upload
+	// request that is already being sent can't be interrupted, so it still
+	// completes.
+	intake, stopIntake := context.WithCancel(a.ctx)
+	defer stopIntake()
+	work, stopWork := context.WithCancel(context.Background())
+	defer stopWork()
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+		case <-intake.Done():
+			return
+		}
+		w.log.Print("shutting down; finishing files in progress (interrupt again to stop waiting for them to process)")
+		stopIntake()
+		select {
+		case <-signals:
+			w.log.Print("no longer waiting for processing; uploads already being sent will still complete")
+			stopWork()
+		case <-work.Done():
+		}
+	}()
+
+	queue := make(chan string)
+	var workers sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for path := range queue {
+				w.process(work, path)
+			}
+		}()
+	}
+
+	mode := "polling"
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if !*poll && !*once {
+		if fw, err := fsnotify.NewWatcher(); err != nil {
+			w.log.Printf("filesystem notifications unavailable, polling instead: %v", err)
+		} else if err := fw.Add(w.dir); err != nil {
+			fw.Close()
+			w.log.Printf("filesystem notifications unavailable, polling instead: %v", err)
+		} else {
+			defer fw.Close()
+			events, watchErrors = fw.Events, fw.Errors
+			mode = "notifications"
+		}
+	}
+	w.log.Printf("watching %s for workflow %s using %s", w.dir, w.workflowID, mode)
+
+	// Notifications only say that something changed; readiness is always
+	// decided on the ticker. A periodic rescan catches missed events.
+	w.scan()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	rescanEvery := 1
+	if events != nil {
+		rescanEvery = 30
+	}
+	for tick := 0; ; {
+		select {
+		case <-intake.Done():
+			close(queue)
+			workers.Wait()
+			stopWork()
+			w.log.Printf("stopped: %d processed, %d failed", w.counts.processed, w.counts.failed)
+			if *once && w.counts.failed > 0 {
+				return fmt.Errorf("%d file(s) failed; see %s", w.counts.failed, w.failed)
+			}
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if ev.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Rename|fsnotify.Chmod) != 0 {
+				w.touch(ev.Name)
+			}
+		case err, ok := <-watchErrors:
+			if !ok {
+				watchErrors = nil
+				continue
+			}
+			w.log.Printf("watch error: %v", err)
+		case <-ticker.C:
+			if tick++; tick%rescanEvery == 0 {
+				w.scan()
+			}
+			for _, path := range w.ready() {
+				select {
+				case queue <- path:
+				case <-intake.Done():
+					w.unqueue(path)
+				}
+			}
+			if *once && w.idle() {
+				stopIntake()
+			}
+		}
+	}
+}
+
+// eligible reports whether a directory entry should be uploaded. JSON
+// files are skipped since they may be sidecars, e.g. when --processed-dir
+// is the watched folder.
+func (w *watcher) eligible(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~") {
+		return false
+	}
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".json") {
+		return false
+	}
+	for _, suffix := range partialSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return false
+		}
+	}
+	if w.pattern != "" {
+		ok, _ := filepath.Match(w.pattern, name)
+		return ok
+	}
+	return true
+}
+
+// scan adds the regular files in the folder to the pending set
+func (w *watcher) scan() {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		w.log.Printf("scanning %s: %v", w.dir, err)
+		return
+	}
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			w.touch(filepath.Join(w.dir, e.Name()))
+		}
+	}
+}
+
+// touch starts tracking a file, or restarts its settle time if it
+// changed
+func (w *watcher) touch(path string) {
+	if filepath.Dir(path) != filepath.Clean(w.dir) || !w.eligible(filepath.Base(path)) {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if st, ok := w.unmoved[path]; ok {
+		if st.size == info.Size() && st.modTime.Equal(info.ModTime()) {
+			return
+		}
+		// The file was replaced, so it is a new one
+		delete(w.unmoved, path)
+	}
+	st, ok := w.pending[path]
+	if !ok {
+		w.pending[path] = &fileState{size: info.Size(), modTime: info.ModTime(), stableSince: time.Now()}
+		return
+	}
+	if !st.queued && (st.size != info.Size() || !st.modTime.Equal(info.ModTime())) {
+		st.size, st.modTime, st.stableSince = info.Size(), info.ModTime(), time.Now()
+	}
+}
+
+// ready returns the pending files whose size and modification time have
+// not changed for the settle time, and marks them queued
+func (w *watcher) ready() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now()
+	var out []string
+	for path, st := range w.pending {
+		if st.queued {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			delete(w.pending, path)
+			continue
+		}
+		if info.Size() != st.size || !info.ModTime().Equal(st.modTime) {
+			st.size, st.modTime, st.stableSince = info.Size(), info.ModTime(), now
+			continue
+		}
+		// Empty files are usually placeholders that are about to be
+		// written, so they get another settle period
+		if info.Size() == 0 || now.Sub(st.stableSince) < w.settle {
+			continue
+		}
+		st.queued = true
+		out = append(out, path)
+	}
+	return out
+}
+
+func (w *watcher) unqueue(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if st, ok := w.pending[path]; ok {
+		st.queued = false
+	}
+}
+
+// idle reports whether no file is pending or in progress. Empty files
+// don't count, so that --once doesn't wait on them forever.
+func (w *watcher) idle() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, st := range w.pending {
+		if st.queued || st.size > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// process uploads a file, waits for its extraction, and moves it with a
+// sidecar to the processed or failed folder
+func (w *watcher) process(ctx context.Context, path string) {
+	defer func() {
+		w.mu.Lock()
+		delete(w.pending, path)
+		w.mu.Unlock()
+	}()
+
+	result := sidecar{Source: path, WorkflowID: w.workflowID, StartedAt: time.Now().UTC()}
+	w.log.Printf("uploading %s", filepath.Base(path))
+	doc, err := w.upload(ctx, path, &result.Attempts)
+	if err == nil && !doc.Status.IsTerminal() {
+		doc, err = waitForDocument(ctx, w.client, w.workflowID, doc, w.interval, w.timeout)
+	}
+	result.FinishedAt = time.Now().UTC()
+	result.Document = doc
+	if doc != nil {
+		result.DocumentID = doc.DocumentID
+		result.Status = string(doc.Status)
+	}
+
+	// A file that was uploaded goes to processed even if waiting for it
+	// was interrupted, so that it isn't uploaded twice
+	dest := w.processed
+	switch {
+	case err != nil && doc != nil && errors.Is(err, context.Canceled):
+		result.Interrupted = true
+	case err != nil:
+		result.Error = errorMessage(err)
+		result.ErrorClass = string(nanonets.ErrorClassOf(err))
+		if doc == nil {
+			result.Status = "upload_failed"
+		}
+		dest = w.failed
+	}
+
+	// A file that can't be moved stays where it is and is remembered so
+	// that it isn't uploaded again; its sidecar still goes to dest so that
+	// the watched folder only ever holds input files
+	var sidecarPath string
+	moved, merr := moveFile(path, dest)
+	if merr != nil {
+		w.log.Printf("moving %s: %v; it will not be uploaded again until it changes", path, merr)
+		w.remember(path)
+		moved = path
+		sidecarPath = uniquePath(dest, filepath.Base(path)+".json")
+	} else {
+		sidecarPath = moved + ".json"
+	}
+	result.File = moved
+	if serr := writeSidecar(sidecarPath, &result); serr != nil {
+		w.log.Printf("writing sidecar for %s: %v", moved, serr)
+	}
+
+	w.mu.Lock()
+	if dest == w.failed {
+		w.counts.failed++
+	} else {
+		w.counts.processed++
+	}
+	w.mu.Unlock()
+	switch {
+	case dest == w.failed:
+		w.log.Printf("failed %s: %s", filepath.Base(path), result.Error)
+	case result.Interrupted:
+		w.log.Printf("uploaded %s as %s; interrupted before processing finished", filepath.Base(path), result.DocumentID)
+	default:
+		w.log.Printf("processed %s as %s (%s)", filepath.Base(path), result.DocumentID, result.Status)
+	}
+}
+
+// remember records a file that could not be moved out of the folder, along
+// with its size and modification time
+func (w *watcher) remember(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.unmoved[path] = &fileState{size: info.Size(), modTime: info.ModTime()}
+}
+
+// upload uploads a file, retrying errors that may be temporary
+func (w *watcher) upload(ctx context.Context, path string, attempts *int) (*nanonets.Document, error) {
+	backoff := time.Second
+	for {
+		*attempts++
+		doc, err := w.client.Documents.Upload(w.workflowID, nanonets.UploadDocumentRequest{File: path, Async: w.async, Metadata: w.metadata})
+		if err == nil || *attempts > w.retries || !retryable(err) {
+			return doc, err
+		}
+		delay := backoff
+		var apiErr *nanonets.APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			delay = apiErr.RetryAfter
+		}
+		w.log.Printf("uploading %s: %s; retrying in %s", filepath.Base(path), errorMessage(err), delay)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether an upload error may go away on its own
+func retryable(err error) bool {
+	var apiErr *nanonets.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// rename is os.Rename, replaced in tests to exercise the copy fallback of
+// moveFile
+var rename = os.Rename
+
+// moveFile moves a file into dir, adding a numeric suffix if the name is
+// taken, and returns its new path. If the file can't be renamed, e.g.
+// because dir is on another filesystem, it is copied and then removed.
+func moveFile(path, dir string) (string, error) {
+	dest := uniquePath(dir, filepath.Base(path))
+	if err := rename(path, dest); err == nil {
+		return dest, nil
+	}
+	if err := copyFile(path, dest); err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil {
+		// Keep a single copy so that the file isn't handled twice
+		os.Remove(dest)
+		return "", err
+	}
+	return dest, nil
+}
+
+// uniquePath returns the path of name in dir, adding a numeric suffix
+// before the extension if it is taken
+func uniquePath(dir, name string) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	dest := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(dest); errors.Is(err, os.ErrNotExist) {
+			return dest
+		}
+		dest = filepath.Join(dir, fmt.Sprintf("%s-%d%s", stem, i, ext))
+	}
+}
+
+// copyFile copies src to a new file dst with the same permissions,
+// removing dst if the copy fails
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
+
+// writeSidecar writes the result atomically, so that a reader never sees
+// a partial file
+func writeSidecar(path string, result *sidecar) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
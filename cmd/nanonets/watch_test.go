@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+// newWatcher returns a watcher of a new temporary folder
+func newWatcher(t *testing.T, settle time.Duration) *watcher {
+	t.Helper()
+	return &watcher{
+		dir:     t.TempDir(),
+		settle:  settle,
+		pending: map[string]*fileState{},
+		unmoved: map[string]*fileState{},
+		log:     log.New(io.Discard, "", 0),
+	}
+}
+
+// writeFile writes a file in dir and returns its path
+func writeFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// pendingNames returns the base names of the pending files, sorted
+func pendingNames(w *watcher) []string {
+	var names []string
+	for path := range w.pending {
+		names = append(names, filepath.Base(path))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWatcherScanSkipsIneligibleFiles(t *testing.T) {
+	w := newWatcher(t, 0)
+	w.pattern = "*.pdf"
+	for _, name := range []string{"a.pdf", "b.PDF", "c.txt", ".hidden.pdf", "~lock.pdf", "d.pdf.part", "e.crdownload", "a.pdf.json"} {
+		writeFile(t, w.dir, name, "data")
+	}
+	if err := os.Mkdir(filepath.Join(w.dir, "sub.pdf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w.scan()
+	if got := strings.Join(pendingNames(w), ","); got != "a.pdf" {
+		t.Errorf("pending %s, want a.pdf", got)
+	}
+	// Events for files outside the folder are ignored
+	w.touch(writeFile(t, t.TempDir(), "other.pdf", "data"))
+	if len(w.pending) != 1 {
+		t.Errorf("pending %v after an event outside the folder", pendingNames(w))
+	}
+}
+
+func TestWatcherReadyAndIdle(t *testing.T) {
+	w := newWatcher(t, time.Hour)
+	path := writeFile(t, w.dir, "a.pdf", "data")
+	writeFile(t, w.dir, "empty.pdf", "")
+	w.scan()
+
+	if got := w.ready(); len(got) != 0 {
+		t.Errorf("ready %v before the settle time", got)
+	}
+	if w.idle() {
+		t.Error("idle with a non-empty file pending")
+	}
+
+	w.settle = 0
+	if got := w.ready(); len(got) != 1 || got[0] != path {
+		t.Errorf("ready %v, want only the non-empty file", got)
+	}
+	if got := w.ready(); len(got) != 0 {
+		t.Errorf("queued file returned again: %v", got)
+	}
+
+	// A file handed back by unqueue is ready again
+	w.unqueue(path)
+	if got := w.ready(); len(got) != 1 {
+		t.Errorf("ready %v after unqueue", got)
+	}
+
+	// Once the queued file is done only the empty one is left, which
+	// doesn't keep --once waiting
+	w.mu.Lock()
+	delete(w.pending, path)
+	w.mu.Unlock()
+	if !w.idle() {
+		t.Error("not idle with only an empty file pending")
+	}
+}
+
+func TestWatcherReadyRestartsSettleOnChange(t *testing.T) {
+	w := newWatcher(t, time.Hour)
+	path := writeFile(t, w.dir, "a.pdf", "data")
+	w.scan()
+	// Pretend the file has been stable for longer than the settle time
+	w.pending[path].stableSince = time.Now().Add(-2 * time.Hour)
+
+	writeFile(t, w.dir, "a.pdf", "more data")
+	if got := w.ready(); len(got) != 0 {
+		t.Errorf("ready %v right after the file changed", got)
+	}
+	if st := w.pending[path]; st.size != int64(len("more data")) || time.Since(st.stableSince) > time.Minute {
+		t.Errorf("state %+v not updated for the change", st)
+	}
+
+	// A file that disappears is dropped
+	os.Remove(path)
+	w.ready()
+	if len(w.pending) != 0 {
+		t.Errorf("pending %v after the file was removed", pendingNames(w))
+	}
+}
+
+func TestWatcherSkipsUnmovedFile(t *testing.T) {
+	w := newWatcher(t, 0)
+	path := writeFile(t, w.dir, "a.pdf", "data")
+	w.remember(path)
+	w.scan()
+	if len(w.pending) != 0 {
+		t.Errorf("unmoved file tracked again: %v", pendingNames(w))
+	}
+
+	// A replaced file is a new one
+	writeFile(t, w.dir, "a.pdf", "new data")
+	w.scan()
+	if len(w.pending) != 1 || len(w.unmoved) != 0 {
+		t.Errorf("pending %v, unmoved %d; want the replaced file tracked", pendingNames(w), len(w.unmoved))
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	src, dest := t.TempDir(), t.TempDir()
+	writeFile(t, dest, "a.pdf", "taken")
+	path := writeFile(t, src, "a.pdf", "data")
+
+	moved, err := moveFile(path, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != filepath.Join(dest, "a-1.pdf") {
+		t.Errorf("moved to %s, want a suffix for the taken name", moved)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("source still exists: %v", err)
+	}
+}
+
+func TestMoveFileCopyFallback(t *testing.T) {
+	defer func(r func(string, string) error) { rename = r }(rename)
+	rename = func(string, string) error { return errors.New("invalid cross-device link") }
+
+	src, dest := t.TempDir(), t.TempDir()
+	path := writeFile(t, src, "a.pdf", "data")
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := moveFile(path, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(moved)
+	if err != nil || string(data) != "data" {
+		t.Errorf("copy holds %q, %v", data, err)
+	}
+	if info, err := os.Stat(moved); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("copy mode %v, %v; want the source permissions", info.Mode(), err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("source still exists after the copy: %v", err)
+	}
+
+	// A failed copy leaves the source in place and no partial copy
+	path = writeFile(t, src, "b.pdf", "data")
+	if _, err := moveFile(path, filepath.Join(dest, "missing")); err == nil {
+		t.Error("copy into a missing folder: no error")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("source lost after a failed copy: %v", err)
+	}
+}
+
+func TestWatchOnce(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	dir := t.TempDir()
+	writeFile(t, dir, "good.pdf", "%PDF-1.7")
+	writeFile(t, dir, "skip.pdf.part", "%PDF")
+
+	code, _, stderr := runCLI(t, s, "watch", dir, "--workflow", wf.ID, "--once", "--settle", "0", "--interval", "10ms")
+	if code != exitOK {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	var result sidecar
+	data, err := os.ReadFile(filepath.Join(dir, "processed", "good.pdf.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.DocumentID == "" || result.Status != string(nanonets.DocumentStatusCompleted) || result.File != filepath.Join(dir, "processed", "good.pdf") {
+		t.Errorf("sidecar %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "skip.pdf.part")); err != nil {
+		t.Errorf("partial file was touched: %v", err)
+	}
+
+	// Uploads rejected by the API go to failed and fail --once
+	writeFile(t, dir, "bad.pdf", "%PDF-1.7")
+	s.InjectFault(nanonetstest.Fault{Method: http.MethodPost, Status: http.StatusBadRequest})
+	code, _, stderr = runCLI(t, s, "watch", dir, "--workflow", wf.ID, "--once", "--settle", "0", "--interval", "10ms")
+	if code != exitError || !strings.Contains(stderr, "1 file(s) failed") {
+		t.Errorf("exit code %d: %s", code, stderr)
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "failed", "bad.pdf.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &result); err != nil || result.Status != "upload_failed" || result.ErrorClass != string(nanonets.ErrorClassInvalid) || result.Attempts != 1 {
+		t.Errorf("sidecar %+v, %v; want one failed attempt classed invalid", result, err)
+	}
+}
+
+func TestWatchUsage(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	dir := t.TempDir()
+	code, _, stderr := runCLI(t, s, "watch", dir, "--workflow", "wf_1", "--settle", "-1s")
+	if code != exitUsage || !strings.Contains(stderr, "--settle") {
+		t.Errorf("negative --settle: exit code %d: %s", code, stderr)
+	}
+	if code, _, _ := runCLI(t, s, "watch", dir); code != exitUsage {
+		t.Errorf("no --workflow: exit code %d, want %d", code, exitUsage)
+	}
+	file := writeFile(t, dir, "a.pdf", "data")
+	if code, _, _ := runCLI(t, s, "watch", file, "--workflow", "wf_1"); code != exitUsage {
+		t.Errorf("file instead of a folder: exit code %d, want %d", code, exitUsage)
+	}
+}
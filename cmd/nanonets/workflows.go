@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"gopkg.in/yaml.v3"
+)
+
+var workflowCommands = map[string]command{
+	"list":         {"List workflows", workflowsList},
+	"get":          {"Show a workflow's fields and settings", workflowsGet},
+	"create":       {"Create a workflow", workflowsCreate},
+	"types":        {"List workflow types", workflowsTypes},
+	"set-fields":   {"Replace a workflow's fields and table headers", workflowsSetFields},
+	"update-field": {"Rename a field or table header", workflowsUpdateField},
+	"delete-field": {"Delete a field or table header", workflowsDeleteField},
+	"settings":     {"Show or change a workflow's settings", workflowsSettings},
+}
+
+// stringList is a repeatable flag whose values may also be comma-separated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// positional checks the number of positional arguments
+func positional(args []string, names ...string) error {
+	if len(args) != len(names) {
+		return usagef("expected %d argument(s): %s", len(names), strings.Join(names, " "))
+	}
+	return nil
+}
+
+func workflowsList(a *app, args []string) error {
+	fs := a.flagSet("workflows list")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := positional(rest); err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	workflows, err := c.Workflows.List()
+	if err != nil {
+		return err
+	}
+	if workflows == nil {
+		workflows = []nanonets.Workflow{}
+	}
+	return a.render(workflows, func(w io.Writer) {
+		row(w, "ID", "DESCRIPTION", "TYPE", "FIELDS", "TABLE HEADERS", "CREATED")
+		for _, wf := range workflows {
+			row(w, wf.ID, wf.Description, wf.WorkflowType, len(wf.Fields), len(wf.TableHeaders), formatTime(wf.CreatedAt))
+		}
+	})
+}
+
+func workflowsGet(a *app, args []string) error {
+	fs := a.flagSet("workflows get")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := positional(rest, "<workflow-id>"); err != nil {
+		return err
+	}
+	return a.showWorkflow(rest[0])
+}
+
+// showWorkflow fetches and renders a workflow
+func (a *app) showWorkflow(workflowID string) error {
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	wf, err := c.Workflows.Get(workflowID)
+	if err != nil {
+		return err
+	}
+	return a.render(wf, func(w io.Writer) {
+		row(w, "ID:", wf.ID)
+		row(w, "Description:", wf.Description)
+		row(w, "Type:", wf.WorkflowType)
+		row(w, "Created:", formatTime(wf.CreatedAt))
+		row(w, "Updated:", formatTime(wf.UpdatedAt))
+		row(w, "Settings:", formatSettings(wf.Settings))
+		fmt.Fprintln(w)
+		row(w, "KIND", "ID", "NAME", "TYPE", "REQUIRED")
+		for _, f := range wf.Fields {
+			row(w, "field", f.ID, f.Name, f.Type, f.Required)
+		}
+		for _, h := range wf.TableHeaders {
+			row(w, "table_header", h.ID, h.Name, h.Type, h.Required)
+		}
+	})
+}
+
+func workflowsCreate(a *app, args []string) error {
+	fs := a.flagSet("workflows create")
+	description := fs.String("description", "", "workflow description (required)")
+	workflowType := fs.String("type", "", "workflow type; see 'workflows types' (default instant learning)")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := positional(rest); err != nil {
+		return err
+	}
+	if *description == "" {
+		return usagef("--description is required")
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	wf, err := c.Workflows.Create(nanonets.CreateWorkflowRequest{Description: *description, WorkflowType: *workflowType})
+	if err != nil {
+		return err
+	}
+	return a.render(wf, func(w io.Writer) {
+		row(w, "Created workflow", wf.ID)
+	})
+}
+
+func workflowsTypes(a *app, args []string) error {
+	fs := a.flagSet("workflows types")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := positional(rest); err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	types, err := c.Workflows.GetTypes()
+	if err != nil {
+		return err
+	}
+	if types == nil {
+		types = []nanonets.WorkflowType{}
+	}
+	return a.render(types, func(w io.Writer) {
+		row(w, "ID", "NAME", "DESCRIPTION")
+		for _, t := range types {
+			row(w, t.ID, t.Name, t.Description)
+		}
+	})
+}
+
+func workflowsSetFields(a *app, args []string) error {
+	fs := a.flagSet("workflows set-fields")
+	var fields, headers stringList
+	fs.Var(&fields, "field", "field name, optionally name:type (repeatable or comma-separated)")
+	fs.Var(&headers, "header", "table header name, optionally name:type (repeatable or comma-separated)")
+	file := fs.String("file", "", "JSON or YAML file with fields and table_headers, as used by SetFields")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := positional(rest, "<workflow-id>"); err != nil {
+		return err
+	}
+
+	req := nanonets.SetFieldsRequest{Fields: []nanonets.Field{}, TableHeaders: []nanonets.TableHeader{}}
+	if *file != "" {
+		if len(fields) > 0 || len(headers) > 0 {
+			return usagef("--file can't be combined with --field or --header")
+		}
+		if req, err = readSetFields(*file); err != nil {
+			return err
+		}
+	}
+	for _, f := range fields {
+		name, typ := splitType(f)
+		req.Fields = append(req.Fields, nanonets.Field{Name: name, Type: typ})
+	}
+	for _, h := range headers {
+		name, typ := splitType(h)
+		req.TableHeaders = append(req.TableHeaders, nanonets.TableHeader{Name: name, Type: typ})
+	}
+	if len(req.Fields) == 0 && len(req.TableHeaders) == 0 {
+		return usagef("give --field, --header or --file")
+	}
+
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	if err := c.Workflows.SetFields(rest[0], req); err != nil {
+		return err
+	}
+	return a.showWorkflow(rest[0])
+}
+
+// readSetFields reads a SetFieldsRequest from a JSON or YAML file
+func readSetFields(path string) (nanonets.SetFieldsRequest, error) {
+	var req nanonets.SetFieldsRequest
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return req, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return req, fmt.Errorf("reading %s: %w", path, err)
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return req, fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return req, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if req.Fields == nil {
+		req.Fields = []nanonets.Field{}
+	}
+	if req.TableHeaders == nil {
+		req.TableHeaders = []nanonets.TableHeader{}
+	}
+	return req, nil
+}
+
+// splitType splits "name:type" into its parts
+func splitType(s string) (string, nanonets.FieldType) {
+	name, typ, _ := strings.Cut(s, ":")
+	return strings.TrimSpace(name), nanonets.FieldType(strings.TrimSpace(typ))
+}
+
+func workflowsUpdateField(a *app, args []string) error {
+	fs := a.flagSet("workflows update-field")
+	name := fs.String("name", "", "new name (required)")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := positional(rest, "<workflow-id>", "<field-id>"); err != nil {
+		return err
+	}
+	if *name == "" {
+		return usagef("--name is required")
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	if err := c.Workflows.UpdateField(rest[0], rest[1], nanonets.UpdateFieldRequest{Name: *name}); err != nil {
+		return err
+	}
+	return a.showWorkflow(rest[0])
+}
+
+func workflowsDeleteField(a *app, args []string) error {
+	fs := a.flagSet("workflows delete-field")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := positional(rest, "<workflow-id>", "<field-id>"); err != nil {
+		return err
+	}
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	if err := c.Workflows.DeleteField(rest[0], rest[1]); err != nil {
+		return err
+	}
+	return a.showWorkflow(rest[0])
+}
+
+func workflowsSettings(a *app, args []string) error {
+	fs := a.flagSet("workflows settings")
+	rest, err := a.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return usagef("expected <workflow-id> [key=value ...]")
+	}
+	workflowID, assignments := rest[0], rest[1:]
+
+	c, err := a.api()
+	if err != nil {
+		return err
+	}
+	if len(assignments) > 0 {
+		var req nanonets.UpdateSettingsRequest
+		for _, kv := range assignments {
+			key, raw, ok := strings.Cut(kv, "=")
+			if !ok || key == "" {
+				return usagef("settings must be given as key=value, got %q", kv)
+			}
+			// Values are read as JSON where possible, so that true, 3 and
+			// {"a":1} keep their types; anything else is a string
+			var value interface{} = raw
+			var parsed interface{}
+			if json.Unmarshal([]byte(raw), &parsed) == nil {
+				value = parsed
+			}
+			if err := req.Set(key, value); err != nil {
+				return usagef("setting %s: %v", key, err)
+			}
+		}
+		if err := c.Workflows.UpdateSettings(workflowID, req); err != nil {
+			return err
+		}
+	}
+
+	wf, err := c.Workflows.Get(workflowID)
+	if err != nil {
+		return err
+	}
+	var all map[string]interface{}
+	data, err := json.Marshal(wf.Settings)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	return a.render(wf.Settings, func(w io.Writer) {
+		row(w, "SETTING", "VALUE")
+		keys := make([]string, 0, len(all))
+		for key := range all {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, _ := json.Marshal(all[key])
+			row(w, key, string(value))
+		}
+	})
+}
+
+func formatTime(t nanonets.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func formatSettings(s nanonets.WorkflowSettings) string {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -3,6 +3,12 @@ module github.com/NanoNets/nanonets-go
 go 1.18
 
 require (
+	github.com/fsnotify/fsnotify v1.6.0
 	github.com/google/uuid v1.3.0
 	github.com/stretchr/testify v1.8.2
+	golang.org/x/image v0.18.0
+	golang.org/x/term v0.5.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require golang.org/x/sys v0.5.0 // indirect
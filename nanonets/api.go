@@ -0,0 +1,64 @@
+package nanonets
+
+import (
+	"context"
+	"io"
+)
+
+// WorkflowsAPI is the interface of the workflows API, implemented by
+// *Workflows. Swap Client.Workflows for another implementation, such as
+// one from the nanonetsmock package, in tests.
+type WorkflowsAPI interface {
+	Create(req CreateWorkflowRequest) (*Workflow, error)
+	Get(workflowID string) (*Workflow, error)
+	List() ([]Workflow, error)
+	SetFields(workflowID string, req SetFieldsRequest) error
+	UpdateField(workflowID, fieldID string, req UpdateFieldRequest) error
+	DeleteField(workflowID, fieldID string) error
+	UpdateMetadata(workflowID string, req UpdateMetadataRequest) error
+	UpdateSettings(workflowID string, req UpdateSettingsRequest) error
+	GetTypes() ([]WorkflowType, error)
+	Export(workflowID string, out io.Writer) error
+	Import(in io.Reader) (*Workflow, error)
+	Clone(workflowID string, target *Client) (*CloneResult, error)
+	Plan(workflowID string, spec WorkflowSpec) (*Plan, error)
+	Apply(plan *Plan, opts ApplyOptions) (*Workflow, error)
+}
+
+// DocumentsAPI is the interface of the documents API, implemented by
+// *Documents
+type DocumentsAPI interface {
+	Upload(workflowID string, req UploadDocumentRequest) (*Document, error)
+	UploadFromURL(workflowID string, req UploadDocumentFromURLRequest) (*Document, error)
+	Get(workflowID, documentID string) (*Document, error)
+	List(workflowID string) ([]Document, error)
+	ListWithPagination(workflowID string, page, limit int) ([]Document, error)
+	Delete(workflowID, documentID string) error
+	GetFields(workflowID, documentID string) ([]Field, error)
+	GetTables(workflowID, documentID string) ([]Table, error)
+	GetOriginalFile(workflowID, documentID string) ([]byte, error)
+}
+
+// ModerationAPI is the interface of the moderation API, implemented by
+// *Moderation
+type ModerationAPI interface {
+	UpdateField(workflowID, documentID, pageID, fieldDataID string, req UpdateFieldRequest) error
+	AddField(workflowID, documentID, pageID string, req AddFieldRequest) error
+	DeleteField(workflowID, documentID, pageID, fieldDataID string) error
+	AddTable(workflowID, documentID, pageID string, req AddTableRequest) error
+	DeleteTable(workflowID, documentID, pageID, tableID string) error
+	UpdateTableCell(workflowID, documentID, pageID, tableID, cellID string, req UpdateTableCellRequest) error
+	AddTableCell(workflowID, documentID, pageID, tableID string, req AddTableCellRequest) error
+	DeleteTableCell(workflowID, documentID, pageID, tableID, cellID string) error
+	VerifyField(workflowID, documentID, pageID, fieldDataID string, req VerifyFieldRequest) error
+	VerifyTableCell(workflowID, documentID, pageID, tableID, cellID string, req VerifyTableCellRequest) error
+	VerifyTable(workflowID, documentID, pageID, tableID string, req VerifyTableRequest) error
+	VerifyDocument(workflowID, documentID string, req VerifyDocumentRequest) error
+	AutoVerify(ctx context.Context, workflowID, documentID string, policy AutoVerifyPolicy) (*AutoVerifyResult, error)
+}
+
+var (
+	_ WorkflowsAPI  = (*Workflows)(nil)
+	_ DocumentsAPI  = (*Documents)(nil)
+	_ ModerationAPI = (*Moderation)(nil)
+)
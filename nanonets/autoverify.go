@@ -0,0 +1,217 @@
+package nanonets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultAutoVerifyThreshold is the confidence threshold used when
+// AutoVerifyPolicy.Threshold is nil
+const DefaultAutoVerifyThreshold = 0.9
+
+// Float64 returns a pointer to v, for use in AutoVerifyPolicy.Threshold
+func Float64(v float64) *float64 {
+	return &v
+}
+
+// AutoVerifyPolicy controls how AutoVerify treats a document's values
+type AutoVerifyPolicy struct {
+	// Threshold is the minimum confidence for fields that have no entry in
+	// FieldThresholds; nil uses DefaultAutoVerifyThreshold
+	Threshold *float64
+	// FieldThresholds overrides Threshold per field name
+	FieldThresholds map[string]float64
+	// Rules are run against the document; any value they point at is
+	// flagged regardless of its confidence
+	Rules []Rule
+	// VerifyDocument verifies the document once every value has passed
+	VerifyDocument bool
+	// DryRun computes the actions without calling the API
+	DryRun bool
+}
+
+// AutoVerifyAction is a verification AutoVerify made (or would make in
+// dry-run mode) for a single field value or table cell
+type AutoVerifyAction struct {
+	PageID      string             `json:"page_id"`
+	FieldName   string             `json:"field_name,omitempty"`
+	FieldDataID string             `json:"field_data_id,omitempty"`
+	TableID     string             `json:"table_id,omitempty"`
+	CellID      string             `json:"cell_id,omitempty"`
+	Confidence  float64            `json:"confidence,omitempty"`
+	Status      VerificationStatus `json:"verification_status"`
+	Message     string             `json:"verification_message,omitempty"`
+	Err         error              `json:"-"`
+}
+
+// AutoVerifyResult represents the outcome of AutoVerify
+type AutoVerifyResult struct {
+	Actions          []AutoVerifyAction `json:"actions"`
+	Report           *Report            `json:"report"`
+	DocumentVerified bool               `json:"document_verified"`
+	DryRun           bool               `json:"dry_run"`
+}
+
+// Verified returns the actions that verified a value
+func (r *AutoVerifyResult) Verified() []AutoVerifyAction {
+	var out []AutoVerifyAction
+	for _, a := range r.Actions {
+		if a.Status == VerificationStatusVerified {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Flagged returns the actions that flagged a value for manual review
+func (r *AutoVerifyResult) Flagged() []AutoVerifyAction {
+	var out []AutoVerifyAction
+	for _, a := range r.Actions {
+		if a.Status != VerificationStatusVerified {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// AutoVerify verifies every field value and table cell of a document that
+// clears the policy's confidence threshold and rules, and flags the rest
+// with a message explaining why. Values that are already verified or
+// rejected are left alone. Table cells carry no confidence score, so they
+// are verified unless a rule points at them. If policy.VerifyDocument is
+// set, the document itself is verified once every value has passed, no
+// value is rejected and the rules report no violations.
+//
+// The client's API calls take no context, so ctx is only checked between
+// calls: cancelling it stops AutoVerify before the next call is made but
+// does not interrupt one that is already in flight.
+func (m *Moderation) AutoVerify(ctx context.Context, workflowID, documentID string, policy AutoVerifyPolicy) (*AutoVerifyResult, error) {
+	return AutoVerifyDocument(ctx, m.client.Documents, m, workflowID, documentID, policy)
+}
+
+// AutoVerifyDocument is AutoVerify with the document read through docs and
+// the edits made through m, either of which may be a stub
+func AutoVerifyDocument(ctx context.Context, docs DocumentsAPI, m ModerationAPI, workflowID, documentID string, policy AutoVerifyPolicy) (*AutoVerifyResult, error) {
+	doc, err := docs.Get(workflowID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := DefaultAutoVerifyThreshold
+	if policy.Threshold != nil {
+		threshold = *policy.Threshold
+	}
+	report := Validate(doc, policy.Rules...)
+	result := &AutoVerifyResult{Report: report, DryRun: policy.DryRun}
+
+	// Any violation blocks document verification, including ones on
+	// values that are already verified and ones that don't point at a
+	// value, such as a missing required field
+	allPassed := report.OK()
+	for _, page := range doc.Pages {
+		for _, name := range FieldNames(page.Data.Fields) {
+			values := page.Data.Fields[name]
+			limit := threshold
+			if t, ok := policy.FieldThresholds[name]; ok {
+				limit = t
+			}
+			for _, data := range values {
+				if data.VerificationStatus.IsTerminal() {
+					if data.VerificationStatus == VerificationStatusRejected {
+						allPassed = false
+					}
+					continue
+				}
+				action := AutoVerifyAction{
+					PageID:      page.PageID,
+					FieldName:   name,
+					FieldDataID: data.FieldDataID,
+					Confidence:  data.Confidence,
+					Status:      VerificationStatusVerified,
+				}
+				var reasons []string
+				if data.Confidence < limit {
+					reasons = append(reasons, fmt.Sprintf("confidence %.2f below threshold %.2f", data.Confidence, limit))
+				}
+				for _, v := range report.ForField(data.FieldDataID) {
+					reasons = append(reasons, v.Message)
+				}
+				if len(reasons) > 0 {
+					action.Status = VerificationStatusUnverified
+					action.Message = strings.Join(reasons, "; ")
+					allPassed = false
+				}
+				result.Actions = append(result.Actions, action)
+			}
+		}
+
+		for _, table := range page.Data.Tables {
+			for _, cell := range table.Cells {
+				if cell.VerificationStatus.IsTerminal() {
+					if cell.VerificationStatus == VerificationStatusRejected {
+						allPassed = false
+					}
+					continue
+				}
+				action := AutoVerifyAction{
+					PageID:  page.PageID,
+					TableID: table.TableID,
+					CellID:  cell.CellID,
+					Status:  VerificationStatusVerified,
+				}
+				var reasons []string
+				for _, v := range report.ForCell(cell.CellID) {
+					reasons = append(reasons, v.Message)
+				}
+				if len(reasons) > 0 {
+					action.Status = VerificationStatusUnverified
+					action.Message = strings.Join(reasons, "; ")
+					allPassed = false
+				}
+				result.Actions = append(result.Actions, action)
+			}
+		}
+	}
+
+	if policy.DryRun {
+		result.DocumentVerified = allPassed && policy.VerifyDocument
+		return result, nil
+	}
+
+	failed := 0
+	for i := range result.Actions {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		a := &result.Actions[i]
+		if a.CellID != "" {
+			a.Err = m.VerifyTableCell(workflowID, documentID, a.PageID, a.TableID, a.CellID, VerifyTableCellRequest{
+				VerificationStatus:  a.Status,
+				VerificationMessage: a.Message,
+			})
+		} else {
+			a.Err = m.VerifyField(workflowID, documentID, a.PageID, a.FieldDataID, VerifyFieldRequest{
+				VerificationStatus:  a.Status,
+				VerificationMessage: a.Message,
+			})
+		}
+		if a.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return result, fmt.Errorf("auto-verify: %d of %d verification calls failed", failed, len(result.Actions))
+	}
+
+	if allPassed && policy.VerifyDocument {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := m.VerifyDocument(workflowID, documentID, VerifyDocumentRequest{VerificationStatus: VerificationStatusVerified}); err != nil {
+			return result, err
+		}
+		result.DocumentVerified = true
+	}
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package nanonets_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+// autoVerify runs AutoVerify against the fake server
+func autoVerify(t *testing.T, doc nanonets.Document, policy nanonets.AutoVerifyPolicy) (*nanonets.AutoVerifyResult, *nanonets.Document) {
+	t.Helper()
+	s, workflowID, stored := seedDocument(t, doc)
+	c := s.Client()
+	result, err := c.Moderation.AutoVerify(context.Background(), workflowID, stored.DocumentID, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := s.Document(stored.DocumentID)
+	return result, after
+}
+
+func TestAutoVerifyThreshold(t *testing.T) {
+	result, after := autoVerify(t, invoice(), nanonets.AutoVerifyPolicy{VerifyDocument: true})
+	if len(result.Verified()) != 1 || result.Verified()[0].FieldName != "vendor" {
+		t.Errorf("verified %+v, want only vendor", result.Verified())
+	}
+	if len(result.Flagged()) != 1 || result.Flagged()[0].FieldName != "invoice_number" {
+		t.Errorf("flagged %+v, want only invoice_number", result.Flagged())
+	}
+	if result.DocumentVerified || after.VerificationStatus == nanonets.VerificationStatusVerified {
+		t.Error("document verified with a flagged value")
+	}
+
+	// An explicit zero threshold accepts any confidence
+	result, after = autoVerify(t, invoice(), nanonets.AutoVerifyPolicy{Threshold: nanonets.Float64(0), VerifyDocument: true})
+	if len(result.Flagged()) != 0 {
+		t.Errorf("flagged %+v with a zero threshold", result.Flagged())
+	}
+	if !result.DocumentVerified || after.VerificationStatus != nanonets.VerificationStatusVerified {
+		t.Error("document not verified with a zero threshold")
+	}
+}
+
+func TestAutoVerifyViolationOnVerifiedValue(t *testing.T) {
+	doc := invoice()
+	doc.Pages[0].Data.Fields["invoice_number"] = []nanonets.FieldData{{
+		Value:              "",
+		Confidence:         0.99,
+		VerificationStatus: nanonets.VerificationStatusVerified,
+	}}
+	result, after := autoVerify(t, doc, nanonets.AutoVerifyPolicy{
+		Rules:          []nanonets.Rule{nanonets.RequiredFields("invoice_number")},
+		VerifyDocument: true,
+	})
+	if result.Report.OK() {
+		t.Fatal("report has no violations")
+	}
+	for _, a := range result.Actions {
+		if a.FieldName == "invoice_number" {
+			t.Errorf("action %+v on a value that is already verified", a)
+		}
+	}
+	if result.DocumentVerified || after.VerificationStatus == nanonets.VerificationStatusVerified {
+		t.Error("document verified despite a violation")
+	}
+}
+
+func TestAutoVerifyKeepsRejectedValues(t *testing.T) {
+	doc := invoice()
+	doc.Pages[0].Data.Fields["invoice_number"] = []nanonets.FieldData{{
+		Value:               "INV-1",
+		Confidence:          0.99,
+		VerificationStatus:  nanonets.VerificationStatusRejected,
+		VerificationMessage: "wrong number",
+	}}
+	result, after := autoVerify(t, doc, nanonets.AutoVerifyPolicy{VerifyDocument: true})
+	if len(result.Actions) != 1 || result.Actions[0].FieldName != "vendor" {
+		t.Errorf("actions %+v, want only vendor", result.Actions)
+	}
+	number := after.Pages[0].Data.Fields["invoice_number"][0]
+	if number.VerificationStatus != nanonets.VerificationStatusRejected {
+		t.Errorf("rejected value is now %s", number.VerificationStatus)
+	}
+	if result.DocumentVerified || after.VerificationStatus == nanonets.VerificationStatusVerified {
+		t.Error("document verified with a rejected value")
+	}
+}
+
+func TestAutoVerifyDryRun(t *testing.T) {
+	s, workflowID, stored := seedDocument(t, invoice())
+	c := s.Client()
+	result, err := c.Moderation.AutoVerify(context.Background(), workflowID, stored.DocumentID,
+		nanonets.AutoVerifyPolicy{Threshold: nanonets.Float64(0), VerifyDocument: true, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.DocumentVerified || len(result.Verified()) != 2 {
+		t.Errorf("dry run: document verified %v, %d values verified; want true and 2", result.DocumentVerified, len(result.Verified()))
+	}
+	if n := len(s.Requests()); n != 1 {
+		t.Errorf("dry run sent %d requests, want only the document fetch", n)
+	}
+}
+
+func TestAutoVerifyCancelled(t *testing.T) {
+	s, workflowID, stored := seedDocument(t, invoice())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := s.Client().Moderation.AutoVerify(ctx, workflowID, stored.DocumentID, nanonets.AutoVerifyPolicy{VerifyDocument: true})
+	if err != context.Canceled {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if len(result.Actions) != 2 {
+		t.Errorf("%d planned actions, want 2", len(result.Actions))
+	}
+	if n := len(s.Requests()); n != 1 {
+		t.Errorf("cancelled run sent %d requests, want only the document fetch", n)
+	}
+}
@@ -0,0 +1,272 @@
+package nanonets
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultCoverage is the fraction of a field's area that must lie inside a
+// table for FieldsInTable to report it
+const DefaultCoverage = 0.5
+
+// BBox is an axis-aligned bounding box given by its top-left (X1, Y1) and
+// bottom-right (X2, Y2) corners. The API sends boxes as [x1, y1, x2, y2] in
+// either pixels or coordinates normalized to the page size (0 to 1).
+type BBox struct {
+	X1 float64 `json:"x1"`
+	Y1 float64 `json:"y1"`
+	X2 float64 `json:"x2"`
+	Y2 float64 `json:"y2"`
+}
+
+// NewBBox creates a box from an API [x1, y1, x2, y2] slice. Swapped corners
+// are put in order.
+func NewBBox(coords []float64) (BBox, error) {
+	if len(coords) != 4 {
+		return BBox{}, fmt.Errorf("bbox must have 4 coordinates, got %d", len(coords))
+	}
+	return BBox{X1: coords[0], Y1: coords[1], X2: coords[2], Y2: coords[3]}.Canon(), nil
+}
+
+// BBoxFromXYWH creates a box from its top-left corner and size
+func BBoxFromXYWH(x, y, w, h float64) BBox {
+	return BBox{X1: x, Y1: y, X2: x + w, Y2: y + h}.Canon()
+}
+
+// Slice returns the box as an API [x1, y1, x2, y2] slice
+func (b BBox) Slice() []float64 {
+	return []float64{b.X1, b.Y1, b.X2, b.Y2}
+}
+
+// XYWH returns the top-left corner and size of the box
+func (b BBox) XYWH() (x, y, w, h float64) {
+	return b.X1, b.Y1, b.Width(), b.Height()
+}
+
+// Canon returns the box with X1 <= X2 and Y1 <= Y2
+func (b BBox) Canon() BBox {
+	if b.X1 > b.X2 {
+		b.X1, b.X2 = b.X2, b.X1
+	}
+	if b.Y1 > b.Y2 {
+		b.Y1, b.Y2 = b.Y2, b.Y1
+	}
+	return b
+}
+
+// Width returns the width of the box
+func (b BBox) Width() float64 {
+	return math.Max(0, b.X2-b.X1)
+}
+
+// Height returns the height of the box
+func (b BBox) Height() float64 {
+	return math.Max(0, b.Y2-b.Y1)
+}
+
+// Area returns the area of the box
+func (b BBox) Area() float64 {
+	return b.Width() * b.Height()
+}
+
+// Empty reports whether the box has no area
+func (b BBox) Empty() bool {
+	return b.Area() == 0
+}
+
+// Center returns the center point of the box
+func (b BBox) Center() (x, y float64) {
+	return (b.X1 + b.X2) / 2, (b.Y1 + b.Y2) / 2
+}
+
+// IsNormalized reports whether every coordinate lies between 0 and 1, as
+// for boxes normalized to the page size. A box of pixel coordinates can
+// only look normalized if it covers the top-left pixel of the page.
+func (b BBox) IsNormalized() bool {
+	for _, v := range b.Slice() {
+		if v < 0 || v > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize converts a box in pixels to coordinates normalized to a page
+// of the given size
+func (b BBox) Normalize(pageWidth, pageHeight float64) BBox {
+	if pageWidth <= 0 || pageHeight <= 0 {
+		return b
+	}
+	return BBox{X1: b.X1 / pageWidth, Y1: b.Y1 / pageHeight, X2: b.X2 / pageWidth, Y2: b.Y2 / pageHeight}
+}
+
+// Pixels converts a normalized box to pixel coordinates on a page of the
+// given size
+func (b BBox) Pixels(pageWidth, pageHeight float64) BBox {
+	return BBox{X1: b.X1 * pageWidth, Y1: b.Y1 * pageHeight, X2: b.X2 * pageWidth, Y2: b.Y2 * pageHeight}
+}
+
+// Intersect returns the overlap of two boxes, which is empty if they don't
+// overlap
+func (b BBox) Intersect(o BBox) BBox {
+	r := BBox{
+		X1: math.Max(b.X1, o.X1),
+		Y1: math.Max(b.Y1, o.Y1),
+		X2: math.Min(b.X2, o.X2),
+		Y2: math.Min(b.Y2, o.Y2),
+	}
+	if r.X1 >= r.X2 || r.Y1 >= r.Y2 {
+		return BBox{}
+	}
+	return r
+}
+
+// Union returns the smallest box containing both boxes. An empty box is
+// ignored.
+func (b BBox) Union(o BBox) BBox {
+	if b.Empty() {
+		return o
+	}
+	if o.Empty() {
+		return b
+	}
+	return BBox{
+		X1: math.Min(b.X1, o.X1),
+		Y1: math.Min(b.Y1, o.Y1),
+		X2: math.Max(b.X2, o.X2),
+		Y2: math.Max(b.Y2, o.Y2),
+	}
+}
+
+// IoU returns the intersection over union of two boxes, from 0 for boxes
+// that don't overlap to 1 for identical boxes
+func (b BBox) IoU(o BBox) float64 {
+	inter := b.Intersect(o).Area()
+	union := b.Area() + o.Area() - inter
+	if union == 0 {
+		return 0
+	}
+	return inter / union
+}
+
+// Contains reports whether o lies entirely inside b
+func (b BBox) Contains(o BBox) bool {
+	return o.X1 >= b.X1 && o.Y1 >= b.Y1 && o.X2 <= b.X2 && o.Y2 <= b.Y2
+}
+
+// Coverage returns the fraction of o's area that lies inside b
+func (b BBox) Coverage(o BBox) float64 {
+	if o.Empty() {
+		if b.Contains(o) {
+			return 1
+		}
+		return 0
+	}
+	return b.Intersect(o).Area() / o.Area()
+}
+
+// ReadingOrder returns the indexes of boxes in reading order: top to
+// bottom by line, and left to right within a line. Boxes are on the same
+// line when their vertical centers are closer than half the smaller
+// height.
+func ReadingOrder(boxes []BBox) []int {
+	order := make([]int, len(boxes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return boxes[order[i]].Y1 < boxes[order[j]].Y1
+	})
+
+	var lines [][]int
+	var lineCenter, lineHeight float64
+	for _, i := range order {
+		_, cy := boxes[i].Center()
+		h := boxes[i].Height()
+		if len(lines) > 0 && math.Abs(cy-lineCenter) < math.Min(h, lineHeight)/2 {
+			lines[len(lines)-1] = append(lines[len(lines)-1], i)
+			continue
+		}
+		lines = append(lines, []int{i})
+		lineCenter, lineHeight = cy, h
+	}
+
+	out := order[:0]
+	for _, line := range lines {
+		sort.SliceStable(line, func(a, b int) bool {
+			return boxes[line[a]].X1 < boxes[line[b]].X1
+		})
+		out = append(out, line...)
+	}
+	return out
+}
+
+// Box returns the bounding box of a field value. It reports false if the
+// value has no valid bbox.
+func (f FieldData) Box() (BBox, bool) {
+	b, err := NewBBox(f.Bbox)
+	return b, err == nil
+}
+
+// Box returns the bounding box of a table. It reports false if the table
+// has no valid bbox.
+func (t Table) Box() (BBox, bool) {
+	b, err := NewBBox(t.Bbox)
+	return b, err == nil
+}
+
+// Box returns the bounding box of a table cell. It reports false if the
+// cell has no valid bbox.
+func (c TableCell) Box() (BBox, bool) {
+	b, err := NewBBox(c.Bbox)
+	return b, err == nil
+}
+
+// PageField is a field value located on a page
+type PageField struct {
+	Name string
+	Data FieldData
+	Box  BBox
+}
+
+// FieldsWithin returns the field values on the page with at least
+// minCoverage of their area inside box, in reading order. Values without
+// a bbox are skipped.
+func (p Page) FieldsWithin(box BBox, minCoverage float64) []PageField {
+	var found []PageField
+	for _, name := range FieldNames(p.Data.Fields) {
+		for _, data := range p.Data.Fields[name] {
+			b, ok := data.Box()
+			if !ok || box.Coverage(b) < minCoverage {
+				continue
+			}
+			found = append(found, PageField{Name: name, Data: data, Box: b})
+		}
+	}
+	boxes := make([]BBox, len(found))
+	for i, f := range found {
+		boxes[i] = f.Box
+	}
+	out := make([]PageField, 0, len(found))
+	for _, i := range ReadingOrder(boxes) {
+		out = append(out, found[i])
+	}
+	return out
+}
+
+// FieldsInTable returns the field values on the page that lie inside a
+// table's bbox, using DefaultCoverage
+func (p Page) FieldsInTable(tableID string) ([]PageField, error) {
+	for _, t := range p.Data.Tables {
+		if t.TableID != tableID {
+			continue
+		}
+		box, ok := t.Box()
+		if !ok {
+			return nil, fmt.Errorf("table %s has no bbox", tableID)
+		}
+		return p.FieldsWithin(box, DefaultCoverage), nil
+	}
+	return nil, fmt.Errorf("table %s not found on page %s", tableID, p.PageID)
+}
@@ -0,0 +1,126 @@
+package nanonets_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+func TestNewBBox(t *testing.T) {
+	b, err := nanonets.NewBBox([]float64{30, 40, 10, 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (nanonets.BBox{X1: 10, Y1: 20, X2: 30, Y2: 40}); b != want {
+		t.Errorf("NewBBox put corners as %+v, want %+v", b, want)
+	}
+	if x, y, w, h := b.XYWH(); x != 10 || y != 20 || w != 20 || h != 20 {
+		t.Errorf("XYWH = %v, %v, %v, %v", x, y, w, h)
+	}
+	if got := nanonets.BBoxFromXYWH(10, 20, 20, 20); got != b {
+		t.Errorf("BBoxFromXYWH = %+v, want %+v", got, b)
+	}
+	if _, err := nanonets.NewBBox([]float64{1, 2, 3}); err == nil {
+		t.Error("3 coordinates accepted")
+	}
+	if _, ok := (nanonets.FieldData{}).Box(); ok {
+		t.Error("field without a bbox has a box")
+	}
+}
+
+func TestBBoxGeometry(t *testing.T) {
+	a := nanonets.BBox{X1: 0, Y1: 0, X2: 10, Y2: 10}
+	b := nanonets.BBox{X1: 5, Y1: 5, X2: 15, Y2: 15}
+	far := nanonets.BBox{X1: 20, Y1: 20, X2: 30, Y2: 30}
+
+	if got, want := a.Intersect(b), (nanonets.BBox{X1: 5, Y1: 5, X2: 10, Y2: 10}); got != want {
+		t.Errorf("Intersect = %+v, want %+v", got, want)
+	}
+	if got := a.Intersect(far); !got.Empty() {
+		t.Errorf("Intersect of disjoint boxes = %+v, want empty", got)
+	}
+	if got, want := a.Union(b), (nanonets.BBox{X1: 0, Y1: 0, X2: 15, Y2: 15}); got != want {
+		t.Errorf("Union = %+v, want %+v", got, want)
+	}
+	if got := (nanonets.BBox{}).Union(b); got != b {
+		t.Errorf("Union with an empty box = %+v, want %+v", got, b)
+	}
+	// 25 / (100 + 100 - 25)
+	if got := a.IoU(b); math.Abs(got-25.0/175) > 1e-9 {
+		t.Errorf("IoU = %v, want %v", got, 25.0/175)
+	}
+	if got := a.IoU(a); got != 1 {
+		t.Errorf("IoU with itself = %v, want 1", got)
+	}
+	if got := a.IoU(far); got != 0 {
+		t.Errorf("IoU of disjoint boxes = %v, want 0", got)
+	}
+	if !a.Contains(nanonets.BBox{X1: 1, Y1: 1, X2: 9, Y2: 9}) || a.Contains(b) {
+		t.Error("Contains is wrong")
+	}
+	if got := a.Coverage(b); got != 0.25 {
+		t.Errorf("Coverage = %v, want 0.25", got)
+	}
+}
+
+func TestBBoxNormalize(t *testing.T) {
+	px := nanonets.BBox{X1: 100, Y1: 50, X2: 300, Y2: 150}
+	norm := px.Normalize(1000, 500)
+	if want := (nanonets.BBox{X1: 0.1, Y1: 0.1, X2: 0.3, Y2: 0.3}); norm != want {
+		t.Errorf("Normalize = %+v, want %+v", norm, want)
+	}
+	if !norm.IsNormalized() || px.IsNormalized() {
+		t.Error("IsNormalized is wrong")
+	}
+	if got := norm.Pixels(1000, 500); got != px {
+		t.Errorf("Pixels = %+v, want %+v", got, px)
+	}
+	if got := px.Normalize(0, 500); got != px {
+		t.Errorf("Normalize with no page size = %+v, want the box unchanged", got)
+	}
+}
+
+func TestReadingOrder(t *testing.T) {
+	boxes := []nanonets.BBox{
+		nanonets.BBoxFromXYWH(200, 52, 50, 10), // line 1, right, slightly lower
+		nanonets.BBoxFromXYWH(10, 100, 50, 10), // line 2
+		nanonets.BBoxFromXYWH(10, 50, 50, 10),  // line 1, left
+		nanonets.BBoxFromXYWH(100, 10, 50, 10), // header
+	}
+	if got, want := nanonets.ReadingOrder(boxes), []int{3, 2, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadingOrder = %v, want %v", got, want)
+	}
+}
+
+func TestFieldsInTable(t *testing.T) {
+	page := nanonets.Page{PageID: "p1", Data: nanonets.PageData{
+		Fields: map[string][]nanonets.FieldData{
+			"description": {{FieldDataID: "fd_2", Bbox: []float64{10, 130, 90, 140}}, {FieldDataID: "fd_1", Bbox: []float64{10, 110, 90, 120}}},
+			"total":       {{FieldDataID: "fd_3", Bbox: []float64{150, 110, 190, 120}}},
+			// Mostly outside the table
+			"footer": {{FieldDataID: "fd_4", Bbox: []float64{10, 195, 90, 215}}},
+			"vendor": {{FieldDataID: "fd_5"}},
+		},
+		Tables: []nanonets.Table{{TableID: "t1", Bbox: []float64{0, 100, 200, 200}}, {TableID: "t2"}},
+	}}
+	fields, err := page.FieldsInTable("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, f := range fields {
+		ids = append(ids, f.Data.FieldDataID)
+	}
+	if want := []string{"fd_1", "fd_3", "fd_2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("fields in table %v, want %v", ids, want)
+	}
+
+	if _, err := page.FieldsInTable("t2"); err == nil {
+		t.Error("table without a bbox: no error")
+	}
+	if _, err := page.FieldsInTable("t3"); err == nil {
+		t.Error("missing table: no error")
+	}
+}
@@ -0,0 +1,441 @@
+package nanonets
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLRUCacheSize is the number of entries an LRUCache holds when it is
+// created with a size of zero
+const DefaultLRUCacheSize = 1000
+
+// CacheEntry is an API response stored by CachingTransport. Entries are
+// kept after they expire if they have an ETag, so that they can be
+// revalidated instead of downloaded again.
+type CacheEntry struct {
+	Body        []byte    `json:"body"`
+	ContentType string    `json:"content_type,omitempty"`
+	ETag        string    `json:"etag,omitempty"`
+	Expires     time.Time `json:"expires"`
+}
+
+// Cache stores API responses for CachingTransport. Implementations must be
+// safe for concurrent use and must not modify entries after Set.
+type Cache interface {
+	// Get returns the entry stored under key
+	Get(key string) (*CacheEntry, bool)
+	// Set stores an entry under key, replacing any previous entry
+	Set(key string, entry *CacheEntry)
+	// Delete removes the entry stored under key, if any
+	Delete(key string)
+}
+
+// CacheEndpoint identifies a read endpoint that CachingTransport can cache
+type CacheEndpoint string
+
+// Endpoints cached by CachingTransport
+const (
+	// CacheWorkflowTypes is Workflows.GetTypes
+	CacheWorkflowTypes CacheEndpoint = "workflow_types"
+	// CacheWorkflow is Workflows.Get
+	CacheWorkflow CacheEndpoint = "workflow"
+	// CacheDocument is Documents.Get. Only verified and rejected documents
+	// are cached, since the others are still changing.
+	CacheDocument CacheEndpoint = "document"
+)
+
+// DefaultCacheTTLs are the TTLs NewCachingTransport starts with
+var DefaultCacheTTLs = map[CacheEndpoint]time.Duration{
+	CacheWorkflowTypes: 24 * time.Hour,
+	CacheWorkflow:      5 * time.Minute,
+	CacheDocument:      time.Hour,
+}
+
+// CachingTransport is an http.RoundTripper that serves repeated reads of
+// workflow types, workflows and verified documents from a Cache.
+//
+// A fresh entry is returned without contacting the API. When an entry has
+// expired and the API sent an ETag with it, the request is revalidated
+// with If-None-Match and a 304 response renews the entry. Any other
+// request that changes a workflow or a document, such as Workflows.SetFields
+// or a Moderation call, removes that workflow's or document's entries once
+// it completes. Only requests sent through this transport are seen, so
+// edits made elsewhere show up when the entries expire.
+//
+// Cache keys include a hash of the request's credentials, so clients with
+// different API keys can share a cache.
+type CachingTransport struct {
+	// Cache stores the responses
+	Cache Cache
+	// Transport sends requests; nil uses http.DefaultTransport
+	Transport http.RoundTripper
+	// TTLs is how long responses from each endpoint are fresh. Endpoints
+	// without a positive TTL are not cached.
+	TTLs map[CacheEndpoint]time.Duration
+
+	// mu orders stores against invalidations, which bump generation, so
+	// that a read that was in flight during an edit doesn't store what it
+	// read before the edit
+	mu         sync.Mutex
+	generation uint64
+}
+
+// NewCachingTransport creates a transport that caches in cache with
+// DefaultCacheTTLs
+func NewCachingTransport(cache Cache) *CachingTransport {
+	ttls := make(map[CacheEndpoint]time.Duration, len(DefaultCacheTTLs))
+	for endpoint, ttl := range DefaultCacheTTLs {
+		ttls[endpoint] = ttl
+	}
+	return &CachingTransport{Cache: cache, TTLs: ttls}
+}
+
+// EnableCache sends the client's requests through a CachingTransport that
+// uses cache and wraps the client's current transport. The transport is
+// returned so its TTLs can be changed.
+func (c *Client) EnableCache(cache Cache) *CachingTransport {
+	httpClient := http.Client{}
+	if c.Client != nil {
+		httpClient = *c.Client
+	}
+	t := NewCachingTransport(cache)
+	t.Transport = httpClient.Transport
+	httpClient.Transport = t
+	c.Client = &httpClient
+	return t
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		resp, err := t.transport().RoundTrip(req)
+		if req.Method != http.MethodHead {
+			t.invalidate(req)
+		}
+		return resp, err
+	}
+
+	endpoint, ok := cacheEndpointOf(req)
+	ttl := t.TTLs[endpoint]
+	if !ok || ttl <= 0 || t.Cache == nil || req.Header.Get("If-None-Match") != "" || req.Header.Get("Range") != "" {
+		return t.transport().RoundTrip(req)
+	}
+
+	key := cacheKey(req, req.URL.Path)
+	entry, found := t.Cache.Get(key)
+	if found && time.Now().Before(entry.Expires) {
+		return entry.response(req), nil
+	}
+
+	t.mu.Lock()
+	generation := t.generation
+	t.mu.Unlock()
+
+	out := req
+	if found && entry.ETag != "" {
+		out = req.Clone(req.Context())
+		out.Header.Set("If-None-Match", entry.ETag)
+	}
+	resp, err := t.transport().RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && found && entry.ETag != "":
+		resp.Body.Close()
+		renewed := *entry
+		renewed.Expires = time.Now().Add(ttl)
+		t.store(key, &renewed, generation)
+		return renewed.response(req), nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		t.Cache.Delete(key)
+		return resp, nil
+	case resp.StatusCode != http.StatusOK || strings.Contains(resp.Header.Get("Cache-Control"), "no-store"):
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if endpoint == CacheDocument && !verifiedDocument(body) {
+		if found {
+			t.Cache.Delete(key)
+		}
+		return resp, nil
+	}
+	t.store(key, &CacheEntry{
+		Body:        body,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        resp.Header.Get("ETag"),
+		Expires:     time.Now().Add(ttl),
+	}, generation)
+	return resp, nil
+}
+
+func (t *CachingTransport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// store saves an entry unless the cache was invalidated since generation
+func (t *CachingTransport) store(key string, entry *CacheEntry, generation uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.generation == generation {
+		t.Cache.Set(key, entry)
+	}
+}
+
+// invalidate removes the cached workflow or document a write request
+// changes
+func (t *CachingTransport) invalidate(req *http.Request) {
+	base, rest, ok := splitWorkflowsPath(req.URL.Path)
+	if !ok || len(rest) == 0 || t.Cache == nil {
+		return
+	}
+	// workflows/{wf}/documents/{doc}/... changes the document, an upload
+	// to workflows/{wf}/documents changes nothing cached, and anything
+	// else under workflows/{wf} changes the workflow
+	var resource []string
+	switch {
+	case len(rest) >= 3 && rest[1] == "documents":
+		resource = rest[:3]
+	case len(rest) == 2 && rest[1] == "documents":
+		return
+	default:
+		resource = rest[:1]
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.generation++
+	t.Cache.Delete(cacheKey(req, base+"/"+strings.Join(resource, "/")))
+}
+
+// cacheEndpointOf reports which cached endpoint a GET request is for
+func cacheEndpointOf(req *http.Request) (CacheEndpoint, bool) {
+	if req.URL.RawQuery != "" {
+		return "", false
+	}
+	_, rest, ok := splitWorkflowsPath(req.URL.Path)
+	switch {
+	case !ok:
+		return "", false
+	case len(rest) == 1 && rest[0] == "types":
+		return CacheWorkflowTypes, true
+	case len(rest) == 1:
+		return CacheWorkflow, true
+	case len(rest) == 3 && rest[1] == "documents":
+		return CacheDocument, true
+	}
+	return "", false
+}
+
+// splitWorkflowsPath splits an API path at its workflows segment, so that
+// "/api/v4/workflows/wf_1/fields" gives "/api/v4/workflows" and
+// ["wf_1", "fields"]
+func splitWorkflowsPath(p string) (string, []string, bool) {
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	for i, s := range segments {
+		if s == "workflows" {
+			return "/" + strings.Join(segments[:i+1], "/"), segments[i+1:], true
+		}
+	}
+	return "", nil, false
+}
+
+// cacheKey identifies a resource for the credentials a request is sent
+// with
+func cacheKey(req *http.Request, path string) string {
+	sum := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return fmt.Sprintf("%x %s://%s%s", sum[:8], req.URL.Scheme, req.URL.Host, path)
+}
+
+// verifiedDocument reports whether a document response is verified or
+// rejected
+func verifiedDocument(body []byte) bool {
+	var doc struct {
+		VerificationStatus VerificationStatus `json:"verification_status"`
+	}
+	return json.Unmarshal(body, &doc) == nil && doc.VerificationStatus.IsTerminal()
+}
+
+// response builds the response a cached entry is served as
+func (e *CacheEntry) response(req *http.Request) *http.Response {
+	header := make(http.Header)
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	if e.ETag != "" {
+		header.Set("ETag", e.ETag)
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// LRUCache is an in-memory Cache that holds a fixed number of entries and
+// evicts the least recently used one when it is full
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// lruItem is an element of LRUCache.order
+type lruItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewLRUCache creates an LRU cache that holds up to size entries, or
+// DefaultLRUCacheSize if size is zero
+func NewLRUCache(size int) *LRUCache {
+	if size <= 0 {
+		size = DefaultLRUCacheSize
+	}
+	return &LRUCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get returns the entry stored under key
+func (c *LRUCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*lruItem).entry, true
+}
+
+// Set stores an entry under key, evicting the least recently used entry
+// if the cache is full
+func (c *LRUCache) Set(key string, entry *CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*lruItem).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruItem{key: key, entry: entry})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruItem).key)
+	}
+}
+
+// Delete removes the entry stored under key
+func (c *LRUCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of entries in the cache
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// DiskCache is a Cache that keeps each entry in its own JSON file under
+// dir, named by the hash of its key, so that it survives restarts and can
+// be shared by processes. Unreadable entries are treated as missing, and
+// failed writes leave the entry uncached. Entries are only removed when
+// they are replaced or invalidated; delete the directory to clear it.
+type DiskCache struct {
+	dir string
+}
+
+// diskCacheEntry is the file format of a DiskCache entry
+type diskCacheEntry struct {
+	Key string `json:"key"`
+	CacheEntry
+}
+
+// NewDiskCache creates a disk cache rooted at dir
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir: dir}
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the entry stored under key
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var stored diskCacheEntry
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Key != key {
+		return nil, false
+	}
+	return &stored.CacheEntry, true
+}
+
+// Set writes an entry to its file, replacing it atomically
+func (c *DiskCache) Set(key string, entry *CacheEntry) {
+	data, err := json.Marshal(diskCacheEntry{Key: key, CacheEntry: *entry})
+	if err != nil {
+		return
+	}
+	// Entries hold account data, so only the owner may read them
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Delete removes the entry stored under key
+func (c *DiskCache) Delete(key string) {
+	os.Remove(c.path(key))
+}
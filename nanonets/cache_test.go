@@ -0,0 +1,173 @@
+package nanonets_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+// statusRecorder records the status codes of the responses it passes on
+type statusRecorder struct {
+	transport http.RoundTripper
+	mu        sync.Mutex
+	statuses  []int
+}
+
+func (r *statusRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err == nil {
+		r.mu.Lock()
+		r.statuses = append(r.statuses, resp.StatusCode)
+		r.mu.Unlock()
+	}
+	return resp, err
+}
+
+// take returns the recorded status codes and clears them
+func (r *statusRecorder) take() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := r.statuses
+	r.statuses = nil
+	return out
+}
+
+// cachedClient returns a client for s with caching enabled and a recorder
+// of the responses that reached the server
+func cachedClient(s *nanonetstest.Server, cache nanonets.Cache) (*nanonets.Client, *nanonets.CachingTransport, *statusRecorder) {
+	c := s.Client()
+	// The server's http.Client is shared, so it is copied before its
+	// transport is replaced
+	httpClient := *c.Client
+	rec := &statusRecorder{transport: httpClient.Transport}
+	httpClient.Transport = rec
+	c.Client = &httpClient
+	return c, c.EnableCache(cache), rec
+}
+
+// sameStatuses reports whether two lists of status codes are equal
+func sameStatuses(got, want []int) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCachingTransportServesFreshEntries(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices", Fields: []nanonets.Field{{Name: "total"}}})
+	c, _, rec := cachedClient(s, nanonets.NewLRUCache(0))
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Workflows.Get(wf.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := rec.take(); !sameStatuses(got, []int{http.StatusOK}) {
+		t.Errorf("responses from the server: %v, want one 200", got)
+	}
+
+	// An edit through the same client invalidates the workflow
+	if err := c.Workflows.SetFields(wf.ID, nanonets.SetFieldsRequest{Fields: []nanonets.Field{{Name: "total"}, {Name: "tax"}}}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Workflows.Get(wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Fields) != 2 {
+		t.Errorf("workflow has %d fields after SetFields, want 2", len(got.Fields))
+	}
+	if got := rec.take(); !sameStatuses(got, []int{http.StatusOK, http.StatusOK}) {
+		t.Errorf("responses from the server: %v, want the edit and a fresh read", got)
+	}
+}
+
+func TestCachingTransportRevalidates(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	s.ETags = true
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	c, transport, rec := cachedClient(s, nanonets.NewDiskCache(t.TempDir()))
+	transport.TTLs[nanonets.CacheWorkflow] = time.Nanosecond
+
+	for i := 0; i < 2; i++ {
+		got, err := c.Workflows.Get(wf.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Description != "Invoices" {
+			t.Fatalf("read %d: description %q", i+1, got.Description)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := rec.take(); !sameStatuses(got, []int{http.StatusOK, http.StatusNotModified}) {
+		t.Errorf("responses from the server: %v, want 200 then 304", got)
+	}
+
+	// A change made elsewhere is seen once the ETag no longer matches
+	other := s.Client()
+	if err := other.Workflows.UpdateMetadata(wf.ID, nanonets.UpdateMetadataRequest{Description: "Receipts"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Workflows.Get(wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Description != "Receipts" {
+		t.Errorf("description %q after a change elsewhere, want Receipts", got.Description)
+	}
+	if got := rec.take(); !sameStatuses(got, []int{http.StatusOK}) {
+		t.Errorf("responses from the server: %v, want a 200", got)
+	}
+}
+
+func TestCachingTransportDocuments(t *testing.T) {
+	s, workflowID, doc := seedDocument(t, invoice())
+	c, _, rec := cachedClient(s, nanonets.NewLRUCache(0))
+
+	// Unverified documents are still changing, so they aren't cached
+	for i := 0; i < 2; i++ {
+		if _, err := c.Documents.Get(workflowID, doc.DocumentID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := rec.take(); len(got) != 2 {
+		t.Errorf("%d responses from the server for an unverified document, want 2", len(got))
+	}
+
+	if err := c.Moderation.VerifyDocument(workflowID, doc.DocumentID, nanonets.VerifyDocumentRequest{VerificationStatus: nanonets.VerificationStatusVerified}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Documents.Get(workflowID, doc.DocumentID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := rec.take(); len(got) != 2 {
+		t.Errorf("%d responses from the server for a verified document, want the verification and one read", len(got))
+	}
+
+	// A moderation edit invalidates the document
+	page := doc.Pages[0]
+	if err := c.Moderation.UpdateField(workflowID, doc.DocumentID, page.PageID, page.Data.Fields["vendor"][0].FieldDataID, nanonets.UpdateFieldRequest{Value: nanonets.String("Acme Corp")}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Documents.Get(workflowID, doc.DocumentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Pages[0].Data.Fields["vendor"][0].Value; v != "Acme Corp" {
+		t.Errorf("vendor = %q after an edit, want Acme Corp", v)
+	}
+}
@@ -0,0 +1,650 @@
+package nanonets
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultChangeSetConcurrency is the number of operations applied in
+// parallel when Apply is given a concurrency of zero
+const DefaultChangeSetConcurrency = 4
+
+// OpKind identifies a moderation operation in a ChangeSet
+type OpKind string
+
+// Moderation operations supported by ChangeSet
+const (
+	OpUpdateField     OpKind = "update_field"
+	OpAddField        OpKind = "add_field"
+	OpDeleteField     OpKind = "delete_field"
+	OpVerifyField     OpKind = "verify_field"
+	OpAddTable        OpKind = "add_table"
+	OpDeleteTable     OpKind = "delete_table"
+	OpVerifyTable     OpKind = "verify_table"
+	OpUpdateTableCell OpKind = "update_table_cell"
+	OpAddTableCell    OpKind = "add_table_cell"
+	OpDeleteTableCell OpKind = "delete_table_cell"
+	OpVerifyTableCell OpKind = "verify_table_cell"
+)
+
+// OpStatus is the outcome of a single ChangeSet operation
+type OpStatus string
+
+// Operation outcomes reported in OpResult.Status
+const (
+	OpPending        OpStatus = "pending"
+	OpApplied        OpStatus = "applied"
+	OpFailed         OpStatus = "failed"
+	OpSkipped        OpStatus = "skipped"
+	OpRolledBack     OpStatus = "rolled_back"
+	OpRollbackFailed OpStatus = "rollback_failed"
+	OpNotReversible  OpStatus = "not_reversible"
+)
+
+// Operation is a single edit recorded in a ChangeSet
+type Operation struct {
+	Kind        OpKind `json:"kind"`
+	PageID      string `json:"page_id"`
+	FieldDataID string `json:"field_data_id,omitempty"`
+	TableID     string `json:"table_id,omitempty"`
+	CellID      string `json:"cell_id,omitempty"`
+	Value       string `json:"value,omitempty"`
+
+	AddField            *AddFieldRequest     `json:"add_field,omitempty"`
+	AddTable            *AddTableRequest     `json:"add_table,omitempty"`
+	AddTableCell        *AddTableCellRequest `json:"add_table_cell,omitempty"`
+	VerificationStatus  VerificationStatus   `json:"verification_status,omitempty"`
+	VerificationMessage string               `json:"verification_message,omitempty"`
+}
+
+// OpResult represents the outcome of an operation after Apply
+// (Error and RollbackError carry the messages of Err and RollbackErr in
+// JSON)
+type OpResult struct {
+	Operation     Operation `json:"operation"`
+	Status        OpStatus  `json:"status"`
+	Err           error     `json:"-"`
+	RollbackErr   error     `json:"-"`
+	Error         string    `json:"error,omitempty"`
+	RollbackError string    `json:"rollback_error,omitempty"`
+}
+
+// ChangeSetResult represents the outcome of ChangeSet.Apply
+// (Results are in the order the operations were recorded)
+type ChangeSetResult struct {
+	Results    []OpResult `json:"results"`
+	RolledBack bool       `json:"rolled_back"`
+}
+
+// Failed returns the results of operations that failed
+func (r *ChangeSetResult) Failed() []OpResult {
+	var out []OpResult
+	for _, res := range r.Results {
+		if res.Status == OpFailed {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// ChangeSet records moderation edits against a document snapshot so they
+// can be validated locally and applied as a batch. If an operation fails,
+// Apply undoes the operations that succeeded by restoring the snapshot
+// values.
+type ChangeSet struct {
+	workflowID string
+	snapshot   *Document
+	ops        []Operation
+}
+
+// NewChangeSet creates a change set for a document. The snapshot should be
+// the document as returned by Documents.Get before any edits are made.
+func NewChangeSet(workflowID string, snapshot *Document) *ChangeSet {
+	return &ChangeSet{workflowID: workflowID, snapshot: snapshot}
+}
+
+// Operations returns the recorded operations
+func (cs *ChangeSet) Operations() []Operation {
+	return append([]Operation(nil), cs.ops...)
+}
+
+// Add records operations built elsewhere, such as ones decoded from JSON.
+// They are checked by Validate like any other operation.
+func (cs *ChangeSet) Add(ops ...Operation) *ChangeSet {
+	cs.ops = append(cs.ops, ops...)
+	return cs
+}
+
+// UpdateField records a field value update
+func (cs *ChangeSet) UpdateField(pageID, fieldDataID, value string) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{Kind: OpUpdateField, PageID: pageID, FieldDataID: fieldDataID, Value: value})
+	return cs
+}
+
+// AddField records a new field value
+func (cs *ChangeSet) AddField(pageID string, req AddFieldRequest) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{Kind: OpAddField, PageID: pageID, AddField: &req})
+	return cs
+}
+
+// DeleteField records a field value deletion
+func (cs *ChangeSet) DeleteField(pageID, fieldDataID string) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{Kind: OpDeleteField, PageID: pageID, FieldDataID: fieldDataID})
+	return cs
+}
+
+// VerifyField records a field verification
+func (cs *ChangeSet) VerifyField(pageID, fieldDataID string, req VerifyFieldRequest) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{
+		Kind:                OpVerifyField,
+		PageID:              pageID,
+		FieldDataID:         fieldDataID,
+		VerificationStatus:  req.VerificationStatus,
+		VerificationMessage: req.VerificationMessage,
+	})
+	return cs
+}
+
+// AddTable records a new table
+func (cs *ChangeSet) AddTable(pageID string, req AddTableRequest) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{Kind: OpAddTable, PageID: pageID, AddTable: &req})
+	return cs
+}
+
+// DeleteTable records a table deletion
+func (cs *ChangeSet) DeleteTable(pageID, tableID string) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{Kind: OpDeleteTable, PageID: pageID, TableID: tableID})
+	return cs
+}
+
+// VerifyTable records a table verification
+func (cs *ChangeSet) VerifyTable(pageID, tableID string, req VerifyTableRequest) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{
+		Kind:                OpVerifyTable,
+		PageID:              pageID,
+		TableID:             tableID,
+		VerificationStatus:  req.VerificationStatus,
+		VerificationMessage: req.VerificationMessage,
+	})
+	return cs
+}
+
+// UpdateTableCell records a table cell update
+func (cs *ChangeSet) UpdateTableCell(pageID, tableID, cellID, value string) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{Kind: OpUpdateTableCell, PageID: pageID, TableID: tableID, CellID: cellID, Value: value})
+	return cs
+}
+
+// AddTableCell records a new table cell
+func (cs *ChangeSet) AddTableCell(pageID, tableID string, req AddTableCellRequest) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{Kind: OpAddTableCell, PageID: pageID, TableID: tableID, AddTableCell: &req})
+	return cs
+}
+
+// DeleteTableCell records a table cell deletion
+func (cs *ChangeSet) DeleteTableCell(pageID, tableID, cellID string) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{Kind: OpDeleteTableCell, PageID: pageID, TableID: tableID, CellID: cellID})
+	return cs
+}
+
+// VerifyTableCell records a table cell verification
+func (cs *ChangeSet) VerifyTableCell(pageID, tableID, cellID string, req VerifyTableCellRequest) *ChangeSet {
+	cs.ops = append(cs.ops, Operation{
+		Kind:                OpVerifyTableCell,
+		PageID:              pageID,
+		TableID:             tableID,
+		CellID:              cellID,
+		VerificationStatus:  req.VerificationStatus,
+		VerificationMessage: req.VerificationMessage,
+	})
+	return cs
+}
+
+// Validate checks the recorded operations against the snapshot without
+// calling the API
+func (cs *ChangeSet) Validate() error {
+	if cs.snapshot == nil {
+		return fmt.Errorf("change set: no document snapshot")
+	}
+
+	var problems []string
+	deleted := make(map[string]bool)
+	usedPositions := make(map[string]bool)
+	for _, page := range cs.snapshot.Pages {
+		for _, table := range page.Data.Tables {
+			for _, cell := range table.Cells {
+				usedPositions[cellPositionKey(page.PageID, table.TableID, cell.Row, cell.Col)] = true
+			}
+		}
+	}
+
+	for i, op := range cs.ops {
+		fail := func(format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("operation %d (%s): %s", i, op.Kind, fmt.Sprintf(format, args...)))
+		}
+
+		page := cs.snapshotPage(op.PageID)
+		if page == nil {
+			fail("page %q not found", op.PageID)
+			continue
+		}
+
+		switch op.Kind {
+		case OpUpdateField, OpDeleteField, OpVerifyField:
+			if _, _, ok := cs.snapshotField(op.PageID, op.FieldDataID); !ok {
+				fail("field %q not found", op.FieldDataID)
+				continue
+			}
+			if deleted["field/"+op.FieldDataID] {
+				fail("field %q was deleted earlier in the change set", op.FieldDataID)
+			}
+			if op.Kind == OpDeleteField {
+				deleted["field/"+op.FieldDataID] = true
+			}
+		case OpAddField:
+			if op.AddField == nil {
+				fail("missing add_field")
+				continue
+			}
+			if op.AddField.FieldName == "" {
+				fail("field name is required")
+			}
+			if !validBbox(op.AddField.Bbox) {
+				fail("bbox must have 4 coordinates")
+			}
+		case OpAddTable:
+			if op.AddTable == nil {
+				fail("missing add_table")
+				continue
+			}
+			if !validBbox(op.AddTable.Bbox) {
+				fail("bbox must have 4 coordinates")
+			}
+			for _, cell := range op.AddTable.Cells {
+				if !validBbox(cell.Bbox) {
+					fail("cell (%d, %d): bbox must have 4 coordinates", cell.Row, cell.Col)
+				}
+			}
+		case OpDeleteTable, OpVerifyTable:
+			if cs.snapshotTable(op.PageID, op.TableID) == nil {
+				fail("table %q not found", op.TableID)
+				continue
+			}
+			if deleted["table/"+op.TableID] {
+				fail("table %q was deleted earlier in the change set", op.TableID)
+			}
+			if op.Kind == OpDeleteTable {
+				deleted["table/"+op.TableID] = true
+			}
+		case OpUpdateTableCell, OpDeleteTableCell, OpVerifyTableCell:
+			if cs.snapshotTable(op.PageID, op.TableID) == nil {
+				fail("table %q not found", op.TableID)
+				continue
+			}
+			cell, ok := cs.snapshotCell(op.PageID, op.TableID, op.CellID)
+			if !ok {
+				fail("cell %q not found in table %q", op.CellID, op.TableID)
+				continue
+			}
+			if deleted["table/"+op.TableID] || deleted["cell/"+op.CellID] {
+				fail("cell %q was deleted earlier in the change set", op.CellID)
+			}
+			if op.Kind == OpDeleteTableCell {
+				deleted["cell/"+op.CellID] = true
+				delete(usedPositions, cellPositionKey(op.PageID, op.TableID, cell.Row, cell.Col))
+			}
+		case OpAddTableCell:
+			if op.AddTableCell == nil {
+				fail("missing add_table_cell")
+				continue
+			}
+			if cs.snapshotTable(op.PageID, op.TableID) == nil {
+				fail("table %q not found", op.TableID)
+				continue
+			}
+			if deleted["table/"+op.TableID] {
+				fail("table %q was deleted earlier in the change set", op.TableID)
+			}
+			key := cellPositionKey(op.PageID, op.TableID, op.AddTableCell.Row, op.AddTableCell.Col)
+			if usedPositions[key] {
+				fail("table %q already has a cell at (%d, %d)", op.TableID, op.AddTableCell.Row, op.AddTableCell.Col)
+			}
+			usedPositions[key] = true
+			if !validBbox(op.AddTableCell.Bbox) {
+				fail("bbox must have 4 coordinates")
+			}
+		default:
+			fail("unknown operation")
+		}
+
+		switch op.Kind {
+		case OpVerifyField, OpVerifyTable, OpVerifyTableCell:
+			if !op.VerificationStatus.IsValid() {
+				fail("invalid verification status %q", op.VerificationStatus)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("change set: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Apply validates the change set and applies it with at most concurrency
+// operations in flight. Operations on the same field or table run in the
+// order they were recorded. If any operation fails, the remaining ones are
+// skipped and the applied ones are rolled back in reverse order; the same
+// happens if ctx is cancelled.
+func (cs *ChangeSet) Apply(ctx context.Context, m ModerationAPI, concurrency int) (*ChangeSetResult, error) {
+	if err := cs.Validate(); err != nil {
+		return nil, err
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultChangeSetConcurrency
+	}
+
+	result := &ChangeSetResult{Results: make([]OpResult, len(cs.ops))}
+	for i, op := range cs.ops {
+		result.Results[i] = OpResult{Operation: op, Status: OpPending}
+	}
+
+	// Group operations by the entity they touch so that edits to the same
+	// field or table are applied sequentially
+	var groupKeys []string
+	groups := make(map[string][]int)
+	for i, op := range cs.ops {
+		var key string
+		switch {
+		case op.TableID != "":
+			key = "table/" + op.PageID + "/" + op.TableID
+		case op.FieldDataID != "":
+			key = "field/" + op.PageID + "/" + op.FieldDataID
+		default:
+			key = fmt.Sprintf("op/%d", i)
+		}
+		if _, ok := groups[key]; !ok {
+			groupKeys = append(groupKeys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		applied []int
+		failed  int
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+	)
+	for _, key := range groupKeys {
+		indexes := groups[key]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for _, i := range indexes {
+				if ctx.Err() != nil {
+					mu.Lock()
+					result.Results[i].Status = OpSkipped
+					mu.Unlock()
+					continue
+				}
+				err := cs.apply(m, cs.ops[i])
+				mu.Lock()
+				if err != nil {
+					result.Results[i].Status = OpFailed
+					result.Results[i].Err = err
+					result.Results[i].Error = err.Error()
+					failed++
+					cancel()
+				} else {
+					result.Results[i].Status = OpApplied
+					applied = append(applied, i)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failed == 0 && parent.Err() == nil {
+		return result, nil
+	}
+
+	result.RolledBack = true
+	for j := len(applied) - 1; j >= 0; j-- {
+		cs.rollback(m, &result.Results[applied[j]])
+	}
+	if failed == 0 {
+		return result, parent.Err()
+	}
+	return result, fmt.Errorf("change set: %d of %d operations failed", failed, len(cs.ops))
+}
+
+// Revert undoes a change set that was applied successfully, restoring the
+// snapshot values in reverse order. It is the same rollback that Apply
+// performs on failure, so additions and table verifications are reported
+// as OpNotReversible. Reverting stops at the first failure or when ctx is
+// cancelled, leaving the remaining operations OpSkipped.
+func (cs *ChangeSet) Revert(ctx context.Context, m ModerationAPI) (*ChangeSetResult, error) {
+	if cs.snapshot == nil {
+		return nil, fmt.Errorf("change set: no document snapshot")
+	}
+	result := &ChangeSetResult{Results: make([]OpResult, len(cs.ops)), RolledBack: true}
+	for i, op := range cs.ops {
+		result.Results[i] = OpResult{Operation: op, Status: OpSkipped}
+	}
+	for i := len(cs.ops) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		res := &result.Results[i]
+		cs.rollback(m, res)
+		if res.Status == OpRollbackFailed {
+			return result, fmt.Errorf("change set: reverting operation %d (%s): %w", i, res.Operation.Kind, res.RollbackErr)
+		}
+	}
+	return result, nil
+}
+
+// rollback reverts one operation and records the outcome in its result
+func (cs *ChangeSet) rollback(m ModerationAPI, res *OpResult) {
+	reversible, err := cs.revert(m, res.Operation)
+	switch {
+	case !reversible:
+		res.Status = OpNotReversible
+	case err != nil:
+		res.Status = OpRollbackFailed
+		res.RollbackErr = err
+		res.RollbackError = err.Error()
+	default:
+		res.Status = OpRolledBack
+	}
+}
+
+// apply performs a single operation
+func (cs *ChangeSet) apply(m ModerationAPI, op Operation) error {
+	wf, doc := cs.workflowID, cs.snapshot.DocumentID
+	switch op.Kind {
+	case OpUpdateField:
+		return m.UpdateField(wf, doc, op.PageID, op.FieldDataID, UpdateFieldRequest{Value: String(op.Value)})
+	case OpAddField:
+		return m.AddField(wf, doc, op.PageID, *op.AddField)
+	case OpDeleteField:
+		return m.DeleteField(wf, doc, op.PageID, op.FieldDataID)
+	case OpVerifyField:
+		return m.VerifyField(wf, doc, op.PageID, op.FieldDataID, VerifyFieldRequest{
+			VerificationStatus:  op.VerificationStatus,
+			VerificationMessage: op.VerificationMessage,
+		})
+	case OpAddTable:
+		return m.AddTable(wf, doc, op.PageID, *op.AddTable)
+	case OpDeleteTable:
+		return m.DeleteTable(wf, doc, op.PageID, op.TableID)
+	case OpVerifyTable:
+		return m.VerifyTable(wf, doc, op.PageID, op.TableID, VerifyTableRequest{
+			VerificationStatus:  op.VerificationStatus,
+			VerificationMessage: op.VerificationMessage,
+		})
+	case OpUpdateTableCell:
+		return m.UpdateTableCell(wf, doc, op.PageID, op.TableID, op.CellID, UpdateTableCellRequest{Value: op.Value})
+	case OpAddTableCell:
+		return m.AddTableCell(wf, doc, op.PageID, op.TableID, *op.AddTableCell)
+	case OpDeleteTableCell:
+		return m.DeleteTableCell(wf, doc, op.PageID, op.TableID, op.CellID)
+	case OpVerifyTableCell:
+		return m.VerifyTableCell(wf, doc, op.PageID, op.TableID, op.CellID, VerifyTableCellRequest{
+			VerificationStatus:  op.VerificationStatus,
+			VerificationMessage: op.VerificationMessage,
+		})
+	}
+	return fmt.Errorf("change set: unknown operation %q", op.Kind)
+}
+
+// revert performs the compensating operation that restores the snapshot
+// value. Additions and table verifications can't be reverted because the
+// API doesn't return the new IDs or the previous table status.
+func (cs *ChangeSet) revert(m ModerationAPI, op Operation) (bool, error) {
+	wf, doc := cs.workflowID, cs.snapshot.DocumentID
+	switch op.Kind {
+	case OpUpdateField:
+		_, data, _ := cs.snapshotField(op.PageID, op.FieldDataID)
+		return true, m.UpdateField(wf, doc, op.PageID, op.FieldDataID, UpdateFieldRequest{Value: String(data.Value)})
+	case OpDeleteField:
+		name, data, _ := cs.snapshotField(op.PageID, op.FieldDataID)
+		return true, m.AddField(wf, doc, op.PageID, AddFieldRequest{
+			FieldName:           name,
+			Value:               data.Value,
+			Bbox:                data.Bbox,
+			Confidence:          data.Confidence,
+			VerificationStatus:  data.VerificationStatus,
+			VerificationMessage: data.VerificationMessage,
+		})
+	case OpVerifyField:
+		_, data, _ := cs.snapshotField(op.PageID, op.FieldDataID)
+		return true, m.VerifyField(wf, doc, op.PageID, op.FieldDataID, VerifyFieldRequest{
+			VerificationStatus:  data.VerificationStatus,
+			VerificationMessage: data.VerificationMessage,
+		})
+	case OpDeleteTable:
+		table := cs.snapshotTable(op.PageID, op.TableID)
+		return true, m.AddTable(wf, doc, op.PageID, addTableRequestFrom(*table))
+	case OpUpdateTableCell:
+		cell, _ := cs.snapshotCell(op.PageID, op.TableID, op.CellID)
+		return true, m.UpdateTableCell(wf, doc, op.PageID, op.TableID, op.CellID, UpdateTableCellRequest{Value: cell.Text})
+	case OpDeleteTableCell:
+		cell, _ := cs.snapshotCell(op.PageID, op.TableID, op.CellID)
+		return true, m.AddTableCell(wf, doc, op.PageID, op.TableID, AddTableCellRequest{
+			Row:                 cell.Row,
+			Col:                 cell.Col,
+			Header:              cell.Header,
+			Text:                cell.Text,
+			Bbox:                cell.Bbox,
+			VerificationStatus:  cell.VerificationStatus,
+			VerificationMessage: cell.VerificationMessage,
+		})
+	case OpVerifyTableCell:
+		cell, _ := cs.snapshotCell(op.PageID, op.TableID, op.CellID)
+		return true, m.VerifyTableCell(wf, doc, op.PageID, op.TableID, op.CellID, VerifyTableCellRequest{
+			VerificationStatus:  cell.VerificationStatus,
+			VerificationMessage: cell.VerificationMessage,
+		})
+	}
+	return false, nil
+}
+
+func (cs *ChangeSet) snapshotPage(pageID string) *Page {
+	for i := range cs.snapshot.Pages {
+		if cs.snapshot.Pages[i].PageID == pageID {
+			return &cs.snapshot.Pages[i]
+		}
+	}
+	return nil
+}
+
+func (cs *ChangeSet) snapshotField(pageID, fieldDataID string) (string, FieldData, bool) {
+	page := cs.snapshotPage(pageID)
+	if page == nil {
+		return "", FieldData{}, false
+	}
+	for name, values := range page.Data.Fields {
+		for _, data := range values {
+			if data.FieldDataID == fieldDataID {
+				return name, data, true
+			}
+		}
+	}
+	return "", FieldData{}, false
+}
+
+func (cs *ChangeSet) snapshotTable(pageID, tableID string) *Table {
+	page := cs.snapshotPage(pageID)
+	if page == nil {
+		return nil
+	}
+	for i := range page.Data.Tables {
+		if page.Data.Tables[i].TableID == tableID {
+			return &page.Data.Tables[i]
+		}
+	}
+	return nil
+}
+
+func (cs *ChangeSet) snapshotCell(pageID, tableID, cellID string) (TableCell, bool) {
+	table := cs.snapshotTable(pageID, tableID)
+	if table == nil {
+		return TableCell{}, false
+	}
+	for _, cell := range table.Cells {
+		if cell.CellID == cellID {
+			return cell, true
+		}
+	}
+	return TableCell{}, false
+}
+
+// addTableRequestFrom builds the request that recreates a table
+func addTableRequestFrom(table Table) AddTableRequest {
+	req := AddTableRequest{Bbox: table.Bbox}
+	cols := make(map[int]string)
+	for _, cell := range table.Cells {
+		if _, ok := cols[cell.Col]; !ok {
+			cols[cell.Col] = cell.Header
+		}
+		req.Cells = append(req.Cells, Cell{
+			Row:                 cell.Row,
+			Col:                 cell.Col,
+			Header:              cell.Header,
+			Text:                cell.Text,
+			Bbox:                cell.Bbox,
+			VerificationStatus:  cell.VerificationStatus,
+			VerificationMessage: cell.VerificationMessage,
+		})
+	}
+	indexes := make([]int, 0, len(cols))
+	for col := range cols {
+		indexes = append(indexes, col)
+	}
+	sort.Ints(indexes)
+	for _, col := range indexes {
+		req.Headers = append(req.Headers, cols[col])
+	}
+	return req
+}
+
+func cellPositionKey(pageID, tableID string, row, col int) string {
+	return fmt.Sprintf("%s/%s/%d/%d", pageID, tableID, row, col)
+}
+
+func validBbox(bbox []float64) bool {
+	return len(bbox) == 0 || len(bbox) == 4
+}
@@ -0,0 +1,88 @@
+package nanonets_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+func TestChangeSetApplyAndRevert(t *testing.T) {
+	s, workflowID, doc := seedDocument(t, invoice())
+	page := doc.Pages[0]
+	number := page.Data.Fields["invoice_number"][0]
+	vendor := page.Data.Fields["vendor"][0]
+
+	cs := nanonets.NewChangeSet(workflowID, doc).
+		UpdateField(page.PageID, number.FieldDataID, "INV-1").
+		UpdateField(page.PageID, vendor.FieldDataID, "")
+	result, err := cs.Apply(context.Background(), s.Client().Moderation, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range result.Results {
+		if r.Status != nanonets.OpApplied {
+			t.Errorf("%s %s: status %s, want applied", r.Operation.Kind, r.Operation.FieldDataID, r.Status)
+		}
+	}
+	if got := fieldValue(t, s, doc.DocumentID, "invoice_number"); got != "INV-1" {
+		t.Errorf("invoice_number = %q after apply, want INV-1", got)
+	}
+	if got := fieldValue(t, s, doc.DocumentID, "vendor"); got != "" {
+		t.Errorf("vendor = %q after apply, want empty", got)
+	}
+
+	// Reverting restores the snapshot values, including the empty one
+	if _, err := cs.Revert(context.Background(), s.Client().Moderation); err != nil {
+		t.Fatal(err)
+	}
+	if got := fieldValue(t, s, doc.DocumentID, "invoice_number"); got != "" {
+		t.Errorf("invoice_number = %q after revert, want empty", got)
+	}
+	if got := fieldValue(t, s, doc.DocumentID, "vendor"); got != "Acme" {
+		t.Errorf("vendor = %q after revert, want Acme", got)
+	}
+}
+
+func TestChangeSetRollback(t *testing.T) {
+	s, workflowID, doc := seedDocument(t, invoice())
+	page := doc.Pages[0]
+	number := page.Data.Fields["invoice_number"][0]
+	vendor := page.Data.Fields["vendor"][0]
+	s.InjectFault(nanonetstest.Fault{
+		Method: http.MethodPost,
+		Path:   "/workflows/*/documents/*/pages/*/fields/*/verify",
+		Status: http.StatusInternalServerError,
+	})
+
+	// Operations on the same field run in order, so the update is applied
+	// before the verification fails
+	cs := nanonets.NewChangeSet(workflowID, doc).
+		UpdateField(page.PageID, number.FieldDataID, "INV-1").
+		VerifyField(page.PageID, number.FieldDataID, nanonets.VerifyFieldRequest{VerificationStatus: nanonets.VerificationStatusVerified}).
+		UpdateField(page.PageID, vendor.FieldDataID, "")
+	result, err := cs.Apply(context.Background(), s.Client().Moderation, 1)
+	if err == nil {
+		t.Fatal("Apply succeeded, want an error")
+	}
+	if !result.RolledBack {
+		t.Error("RolledBack = false, want true")
+	}
+	if got := result.Results[0].Status; got != nanonets.OpRolledBack {
+		t.Errorf("update: status %s, want rolled_back", got)
+	}
+	if got := result.Results[1]; got.Status != nanonets.OpFailed || got.Error == "" {
+		t.Errorf("verification: status %s, error %q, want failed with an error", got.Status, got.Error)
+	}
+	if got := result.Results[2].Status; got != nanonets.OpRolledBack && got != nanonets.OpSkipped {
+		t.Errorf("other field: status %s, want rolled_back or skipped", got)
+	}
+	if got := fieldValue(t, s, doc.DocumentID, "invoice_number"); got != "" {
+		t.Errorf("invoice_number = %q after rollback, want empty", got)
+	}
+	if got := fieldValue(t, s, doc.DocumentID, "vendor"); got != "Acme" {
+		t.Errorf("vendor = %q after rollback, want Acme", got)
+	}
+}
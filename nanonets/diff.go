@@ -0,0 +1,454 @@
+package nanonets
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffKind describes how an element differs between two documents
+type DiffKind string
+
+// Kinds of differences reported by DiffDocuments
+const (
+	DiffAdded   DiffKind = "added"
+	DiffRemoved DiffKind = "removed"
+	DiffChanged DiffKind = "changed"
+)
+
+// DiffEntry describes a single difference between two documents. Path is a
+// JSON pointer to the element in the old document (or the new document for
+// additions). Attribute is set for changes and names the JSON attribute
+// that changed, e.g. "value" or "verification_status".
+type DiffEntry struct {
+	Kind        DiffKind    `json:"kind"`
+	Path        string      `json:"path"`
+	PageID      string      `json:"page_id,omitempty"`
+	FieldName   string      `json:"field_name,omitempty"`
+	FieldDataID string      `json:"field_data_id,omitempty"`
+	TableID     string      `json:"table_id,omitempty"`
+	CellID      string      `json:"cell_id,omitempty"`
+	Attribute   string      `json:"attribute,omitempty"`
+	Old         interface{} `json:"old,omitempty"`
+	New         interface{} `json:"new,omitempty"`
+}
+
+// IsVerificationChange reports whether the entry is a change of
+// verification status or message
+func (e DiffEntry) IsVerificationChange() bool {
+	return e.Kind == DiffChanged && strings.HasPrefix(e.Attribute, "verification_")
+}
+
+// JSONPatchOp is a single RFC 6902 JSON Patch operation
+type JSONPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON always includes the value of add and replace operations, even
+// when it is null
+func (op JSONPatchOp) MarshalJSON() ([]byte, error) {
+	if op.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{op.Op, op.Path, op.Value})
+}
+
+// DocumentDiff represents the differences between two versions of a
+// document
+type DocumentDiff struct {
+	DocumentID string      `json:"document_id"`
+	Entries    []DiffEntry `json:"entries"`
+
+	replaces []JSONPatchOp
+	adds     []JSONPatchOp
+	removes  []JSONPatchOp
+}
+
+// Empty reports whether the documents are identical
+func (d *DocumentDiff) Empty() bool {
+	return len(d.Entries) == 0
+}
+
+// JSONPatch returns the diff as an RFC 6902 JSON Patch that turns the old
+// document into the new one. Added array elements are appended, so the
+// patched document may order pages, field values, tables and cells
+// differently from the new document.
+func (d *DocumentDiff) JSONPatch() []JSONPatchOp {
+	ops := make([]JSONPatchOp, 0, len(d.replaces)+len(d.adds)+len(d.removes))
+	ops = append(ops, d.replaces...)
+	ops = append(ops, d.adds...)
+	// Removals are emitted last and in reverse document order so that no
+	// removal shifts the index of an element referenced by a later one
+	for i := len(d.removes) - 1; i >= 0; i-- {
+		ops = append(ops, d.removes[i])
+	}
+	return ops
+}
+
+// String returns a human-readable description of the diff
+func (d *DocumentDiff) String() string {
+	if d.Empty() {
+		return fmt.Sprintf("document %s: no changes\n", d.DocumentID)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "document %s: %d change(s)\n", d.DocumentID, len(d.Entries))
+	for _, e := range d.Entries {
+		var symbol string
+		switch e.Kind {
+		case DiffAdded:
+			symbol = "+"
+		case DiffRemoved:
+			symbol = "-"
+		default:
+			symbol = "~"
+		}
+		subject := e.subject()
+		switch e.Kind {
+		case DiffAdded:
+			fmt.Fprintf(&b, "%s %s%s\n", symbol, subject, formatDiffScalar(e.New))
+		case DiffRemoved:
+			fmt.Fprintf(&b, "%s %s%s\n", symbol, subject, formatDiffScalar(e.Old))
+		default:
+			fmt.Fprintf(&b, "%s %s %s: %s -> %s\n", symbol, subject, e.Attribute, formatDiffValue(e.Old), formatDiffValue(e.New))
+		}
+	}
+	return b.String()
+}
+
+func (e DiffEntry) subject() string {
+	switch {
+	case strings.Contains(e.Path, "/cells/"):
+		return fmt.Sprintf("page %s table %s cell %s", e.PageID, e.TableID, e.CellID)
+	case e.TableID != "":
+		return fmt.Sprintf("page %s table %s", e.PageID, e.TableID)
+	case e.FieldName != "":
+		if e.FieldDataID != "" {
+			return fmt.Sprintf("page %s field %s [%s]", e.PageID, e.FieldName, e.FieldDataID)
+		}
+		return fmt.Sprintf("page %s field %s", e.PageID, e.FieldName)
+	case e.PageID != "":
+		return fmt.Sprintf("page %s", e.PageID)
+	}
+	return "document"
+}
+
+// formatDiffScalar formats the value of an added or removed field or cell;
+// whole pages and tables are not printed
+func formatDiffScalar(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return " = " + strconv.Quote(s)
+	}
+	return ""
+}
+
+func formatDiffValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// DiffDocuments compares two versions of a document, e.g. the extracted
+// and the moderated version. Pages are matched by PageID, field values by
+// FieldDataID, tables by TableID and cells by CellID, falling back to row
+// and column for cells whose ID doesn't match.
+func DiffDocuments(a, b *Document) *DocumentDiff {
+	if a == nil {
+		a = &Document{}
+	}
+	if b == nil {
+		b = &Document{}
+	}
+	d := &DocumentDiff{DocumentID: b.DocumentID}
+	if d.DocumentID == "" {
+		d.DocumentID = a.DocumentID
+	}
+
+	base := DiffEntry{}
+	d.compare(base, "", "status", a.Status, b.Status)
+	d.compare(base, "", "verification_status", a.VerificationStatus, b.VerificationStatus)
+	d.compare(base, "", "verification_stage", a.VerificationStage, b.VerificationStage)
+	d.compare(base, "", "verification_message", a.VerificationMessage, b.VerificationMessage)
+
+	matched := make(map[int]bool)
+	for i, pa := range a.Pages {
+		j := -1
+		for k, pb := range b.Pages {
+			if !matched[k] && (pa.PageID == pb.PageID && pa.PageID != "" || pa.PageID == "" && pb.PageID == "" && k == i) {
+				j = k
+				break
+			}
+		}
+		path := "/pages/" + strconv.Itoa(i)
+		if j < 0 {
+			d.remove(DiffEntry{PageID: pa.PageID}, path, pa)
+			continue
+		}
+		matched[j] = true
+		d.diffPage(path, &pa, &b.Pages[j])
+	}
+	for j, pb := range b.Pages {
+		if !matched[j] {
+			d.add(DiffEntry{PageID: pb.PageID}, "/pages/"+strconv.Itoa(j), "/pages/-", pb)
+		}
+	}
+	return d
+}
+
+func (d *DocumentDiff) diffPage(path string, a, b *Page) {
+	base := DiffEntry{PageID: b.PageID}
+	fieldsPath := path + "/data/fields"
+
+	names := make(map[string]bool)
+	for name := range a.Data.Fields {
+		names[name] = true
+	}
+	for name := range b.Data.Fields {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		va, inA := a.Data.Fields[name]
+		vb, inB := b.Data.Fields[name]
+		namePath := fieldsPath + "/" + escapePointer(name)
+		entry := base
+		entry.FieldName = name
+		switch {
+		case !inA:
+			for j, data := range vb {
+				e := entry
+				e.Kind = DiffAdded
+				e.Path = namePath + "/" + strconv.Itoa(j)
+				e.FieldDataID = data.FieldDataID
+				e.New = data.Value
+				d.Entries = append(d.Entries, e)
+			}
+			if a.Data.Fields == nil {
+				d.adds = append(d.adds, JSONPatchOp{Op: "add", Path: fieldsPath, Value: map[string][]FieldData{name: vb}})
+				a.Data.Fields = map[string][]FieldData{}
+			} else {
+				d.adds = append(d.adds, JSONPatchOp{Op: "add", Path: namePath, Value: vb})
+			}
+		case !inB:
+			for i, data := range va {
+				e := entry
+				e.Kind = DiffRemoved
+				e.Path = namePath + "/" + strconv.Itoa(i)
+				e.FieldDataID = data.FieldDataID
+				e.Old = data.Value
+				d.Entries = append(d.Entries, e)
+			}
+			d.removes = append(d.removes, JSONPatchOp{Op: "remove", Path: namePath})
+		default:
+			d.diffFieldValues(entry, namePath, va, vb)
+		}
+	}
+
+	matched := make(map[int]bool)
+	for i, ta := range a.Data.Tables {
+		j := -1
+		for k, tb := range b.Data.Tables {
+			if !matched[k] && (ta.TableID == tb.TableID && ta.TableID != "" || ta.TableID == "" && tb.TableID == "" && k == i) {
+				j = k
+				break
+			}
+		}
+		tablePath := path + "/data/tables/" + strconv.Itoa(i)
+		if j < 0 {
+			d.remove(DiffEntry{PageID: base.PageID, TableID: ta.TableID}, tablePath, ta)
+			continue
+		}
+		matched[j] = true
+		d.diffTable(base, tablePath, &ta, &b.Data.Tables[j])
+	}
+	for j, tb := range b.Data.Tables {
+		if matched[j] {
+			continue
+		}
+		e := DiffEntry{PageID: base.PageID, TableID: tb.TableID}
+		if a.Data.Tables == nil {
+			e.Kind = DiffAdded
+			e.Path = path + "/data/tables/" + strconv.Itoa(j)
+			e.New = tb
+			d.Entries = append(d.Entries, e)
+			d.adds = append(d.adds, JSONPatchOp{Op: "add", Path: path + "/data/tables", Value: []Table{tb}})
+			a.Data.Tables = []Table{}
+			continue
+		}
+		d.add(e, path+"/data/tables/"+strconv.Itoa(j), path+"/data/tables/-", tb)
+	}
+}
+
+func (d *DocumentDiff) diffFieldValues(entry DiffEntry, path string, a, b []FieldData) {
+	matched := make(map[int]bool)
+	for i, fa := range a {
+		j := -1
+		for k, fb := range b {
+			if !matched[k] && (fa.FieldDataID == fb.FieldDataID && fa.FieldDataID != "" || fa.FieldDataID == "" && fb.FieldDataID == "" && k == i) {
+				j = k
+				break
+			}
+		}
+		itemPath := path + "/" + strconv.Itoa(i)
+		e := entry
+		e.FieldDataID = fa.FieldDataID
+		if j < 0 {
+			e.Kind = DiffRemoved
+			e.Path = itemPath
+			e.Old = fa.Value
+			d.Entries = append(d.Entries, e)
+			d.removes = append(d.removes, JSONPatchOp{Op: "remove", Path: itemPath})
+			continue
+		}
+		matched[j] = true
+		fb := b[j]
+		d.compare(e, itemPath, "value", fa.Value, fb.Value)
+		d.compare(e, itemPath, "bbox", fa.Bbox, fb.Bbox)
+		d.compare(e, itemPath, "verification_status", fa.VerificationStatus, fb.VerificationStatus)
+		d.compare(e, itemPath, "verification_message", fa.VerificationMessage, fb.VerificationMessage)
+	}
+	for j, fb := range b {
+		if matched[j] {
+			continue
+		}
+		e := entry
+		e.FieldDataID = fb.FieldDataID
+		e.Kind = DiffAdded
+		e.Path = path + "/" + strconv.Itoa(j)
+		e.New = fb.Value
+		d.Entries = append(d.Entries, e)
+		d.adds = append(d.adds, JSONPatchOp{Op: "add", Path: path + "/-", Value: fb})
+	}
+}
+
+func (d *DocumentDiff) diffTable(base DiffEntry, path string, a, b *Table) {
+	entry := base
+	entry.TableID = b.TableID
+	d.compare(entry, path, "bbox", a.Bbox, b.Bbox)
+
+	// Match cells by ID first, then by position among the cells left over
+	matched := make(map[int]bool)
+	pairs := make(map[int]int)
+	for i, ca := range a.Cells {
+		if ca.CellID == "" {
+			continue
+		}
+		for k, cb := range b.Cells {
+			if !matched[k] && cb.CellID == ca.CellID {
+				pairs[i] = k
+				matched[k] = true
+				break
+			}
+		}
+	}
+	for i, ca := range a.Cells {
+		if _, ok := pairs[i]; ok {
+			continue
+		}
+		for k, cb := range b.Cells {
+			if !matched[k] && cb.Row == ca.Row && cb.Col == ca.Col {
+				pairs[i] = k
+				matched[k] = true
+				break
+			}
+		}
+	}
+
+	cellsPath := path + "/cells"
+	for i, ca := range a.Cells {
+		cellPath := cellsPath + "/" + strconv.Itoa(i)
+		e := entry
+		e.CellID = ca.CellID
+		j, ok := pairs[i]
+		if !ok {
+			e.Kind = DiffRemoved
+			e.Path = cellPath
+			e.Old = ca.Text
+			d.Entries = append(d.Entries, e)
+			d.removes = append(d.removes, JSONPatchOp{Op: "remove", Path: cellPath})
+			continue
+		}
+		cb := b.Cells[j]
+		if cb.CellID != "" {
+			e.CellID = cb.CellID
+		}
+		d.compare(e, cellPath, "cell_id", ca.CellID, cb.CellID)
+		d.compare(e, cellPath, "header", ca.Header, cb.Header)
+		d.compare(e, cellPath, "text", ca.Text, cb.Text)
+		d.compare(e, cellPath, "bbox", ca.Bbox, cb.Bbox)
+		d.compare(e, cellPath, "verification_status", ca.VerificationStatus, cb.VerificationStatus)
+		d.compare(e, cellPath, "verification_message", ca.VerificationMessage, cb.VerificationMessage)
+	}
+	for j, cb := range b.Cells {
+		if matched[j] {
+			continue
+		}
+		e := entry
+		e.CellID = cb.CellID
+		e.Kind = DiffAdded
+		e.Path = cellsPath + "/" + strconv.Itoa(j)
+		e.New = cb.Text
+		d.Entries = append(d.Entries, e)
+		if a.Cells == nil {
+			d.adds = append(d.adds, JSONPatchOp{Op: "add", Path: cellsPath, Value: []TableCell{cb}})
+			a.Cells = []TableCell{}
+			continue
+		}
+		d.adds = append(d.adds, JSONPatchOp{Op: "add", Path: cellsPath + "/-", Value: cb})
+	}
+}
+
+// compare records a change of attribute if the two values differ
+func (d *DocumentDiff) compare(entry DiffEntry, path, attribute string, a, b interface{}) {
+	if reflect.DeepEqual(a, b) {
+		return
+	}
+	entry.Kind = DiffChanged
+	entry.Path = path + "/" + attribute
+	entry.Attribute = attribute
+	entry.Old = a
+	entry.New = b
+	d.Entries = append(d.Entries, entry)
+	d.replaces = append(d.replaces, JSONPatchOp{Op: "replace", Path: entry.Path, Value: b})
+}
+
+func (d *DocumentDiff) remove(entry DiffEntry, path string, old interface{}) {
+	entry.Kind = DiffRemoved
+	entry.Path = path
+	entry.Old = old
+	d.Entries = append(d.Entries, entry)
+	d.removes = append(d.removes, JSONPatchOp{Op: "remove", Path: path})
+}
+
+func (d *DocumentDiff) add(entry DiffEntry, path, patchPath string, value interface{}) {
+	entry.Kind = DiffAdded
+	entry.Path = path
+	entry.New = value
+	d.Entries = append(d.Entries, entry)
+	d.adds = append(d.adds, JSONPatchOp{Op: "add", Path: patchPath, Value: value})
+}
+
+// escapePointer escapes a JSON pointer reference token (RFC 6901)
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
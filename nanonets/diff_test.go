@@ -0,0 +1,99 @@
+package nanonets_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+// diffPage returns a one-page document with the given values of the
+// number field and, if any, a table holding cells
+func diffPage(number []nanonets.FieldData, cells ...nanonets.TableCell) *nanonets.Document {
+	page := nanonets.Page{PageID: "p1", Data: nanonets.PageData{Fields: map[string][]nanonets.FieldData{"number": number}}}
+	if len(cells) > 0 {
+		page.Data.Tables = []nanonets.Table{{TableID: "t1", Cells: cells}}
+	}
+	return &nanonets.Document{DocumentID: "doc_1", Pages: []nanonets.Page{page}}
+}
+
+func TestDiffDocumentsJSONPatchOrder(t *testing.T) {
+	a := diffPage([]nanonets.FieldData{
+		{FieldDataID: "fd_1", Value: "A"},
+		{FieldDataID: "fd_2", Value: "B"},
+		{FieldDataID: "fd_3", Value: "C"},
+	})
+	b := diffPage([]nanonets.FieldData{
+		{FieldDataID: "fd_1", Value: "A2"},
+		{FieldDataID: "fd_4", Value: "D"},
+	})
+
+	var got []string
+	for _, op := range nanonets.DiffDocuments(a, b).JSONPatch() {
+		got = append(got, op.Op+" "+op.Path)
+	}
+	// Replacements and additions refer to the old indexes, and removals
+	// run last from the highest index down
+	want := []string{
+		"replace /pages/0/data/fields/number/0/value",
+		"add /pages/0/data/fields/number/-",
+		"remove /pages/0/data/fields/number/2",
+		"remove /pages/0/data/fields/number/1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("patch\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestDiffDocumentsCells(t *testing.T) {
+	a := diffPage(nil,
+		nanonets.TableCell{CellID: "c1", Row: 0, Col: 0, Text: "1"},
+		nanonets.TableCell{CellID: "c2", Row: 0, Col: 1, Text: "2"},
+	)
+	// c2 changed its ID but kept its position, so it is matched by row and
+	// column rather than removed and added
+	b := diffPage(nil,
+		nanonets.TableCell{CellID: "c1", Row: 0, Col: 0, Text: "1"},
+		nanonets.TableCell{CellID: "c9", Row: 0, Col: 1, Text: "3"},
+	)
+	diff := nanonets.DiffDocuments(a, b)
+	var attributes []string
+	for _, e := range diff.Entries {
+		if e.Kind != nanonets.DiffChanged || e.CellID != "c9" {
+			t.Errorf("unexpected entry %+v", e)
+		}
+		attributes = append(attributes, e.Attribute)
+	}
+	if want := []string{"cell_id", "text"}; !reflect.DeepEqual(attributes, want) {
+		t.Errorf("changed attributes %v, want %v", attributes, want)
+	}
+}
+
+func TestJSONPatchKeepsNullValues(t *testing.T) {
+	a := diffPage([]nanonets.FieldData{{FieldDataID: "fd_1", Value: "A", Bbox: []float64{1, 2, 3, 4}}})
+	b := diffPage([]nanonets.FieldData{{FieldDataID: "fd_1", Value: "A"}})
+	ops := nanonets.DiffDocuments(a, b).JSONPatch()
+	if len(ops) != 1 {
+		t.Fatalf("%d operations, want 1", len(ops))
+	}
+	data, err := json.Marshal(ops[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"op":"replace","path":"/pages/0/data/fields/number/0/bbox","value":null}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestDiffDocumentsEmpty(t *testing.T) {
+	a := diffPage([]nanonets.FieldData{{FieldDataID: "fd_1", Value: "A"}})
+	b := diffPage([]nanonets.FieldData{{FieldDataID: "fd_1", Value: "A"}})
+	diff := nanonets.DiffDocuments(a, b)
+	if !diff.Empty() || len(diff.JSONPatch()) != 0 {
+		t.Errorf("diff of identical documents: %+v", diff.Entries)
+	}
+	if got, want := diff.String(), "document doc_1: no changes\n"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package nanonets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorClass groups API errors by how a caller should react to them
+type ErrorClass string
+
+// API error classes
+const (
+	ErrorClassAuth       ErrorClass = "auth"
+	ErrorClassNotFound   ErrorClass = "not_found"
+	ErrorClassInvalid    ErrorClass = "invalid"
+	ErrorClassConflict   ErrorClass = "conflict"
+	ErrorClassRateLimit  ErrorClass = "rate_limit"
+	ErrorClassServer     ErrorClass = "server"
+	ErrorClassUnexpected ErrorClass = "unexpected"
+)
+
+// APIError is returned when the API responds with a status outside the
+// 2xx range
+type APIError struct {
+	StatusCode int
+	// Body is the raw response body
+	Body string
+	// Message is the error message from a JSON body, if there is one
+	Message string
+	// RetryAfter is the delay requested by a Retry-After header
+	RetryAfter time.Duration
+}
+
+// newAPIError reads an error response
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	e := &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+
+	var payload map[string]interface{}
+	if json.Unmarshal(body, &payload) == nil {
+		for _, key := range []string{"message", "error", "detail"} {
+			if msg, ok := payload[key].(string); ok && msg != "" {
+				e.Message = msg
+				break
+			}
+		}
+	}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			e.RetryAfter = time.Duration(secs) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			e.RetryAfter = time.Until(t)
+		}
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %s (status %d)", e.Body, e.StatusCode)
+}
+
+// Class returns the class of the error
+func (e *APIError) Class() ErrorClass {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrorClassAuth
+	case e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone:
+		return ErrorClassNotFound
+	case e.StatusCode == http.StatusConflict || e.StatusCode == http.StatusPreconditionFailed:
+		return ErrorClassConflict
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrorClassRateLimit
+	case e.StatusCode >= 500:
+		return ErrorClassServer
+	case e.StatusCode >= 400:
+		return ErrorClassInvalid
+	}
+	return ErrorClassUnexpected
+}
+
+// Temporary reports whether retrying the request later may succeed
+func (e *APIError) Temporary() bool {
+	c := e.Class()
+	return c == ErrorClassRateLimit || c == ErrorClassServer
+}
+
+// ErrorClassOf returns the class of an APIError in err's chain, or the
+// empty class if there is none
+func ErrorClassOf(err error) ErrorClass {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Class()
+	}
+	return ""
+}
+
+// IsNotFound reports whether err is an APIError for a missing resource
+func IsNotFound(err error) bool {
+	return ErrorClassOf(err) == ErrorClassNotFound
+}
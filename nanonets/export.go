@@ -0,0 +1,151 @@
+package nanonets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// WorkflowExportVersion is the version of the export format written by
+// Export
+const WorkflowExportVersion = 1
+
+// WorkflowExport is the portable representation of a workflow's
+// configuration written by Export and read by Import. Field IDs are those
+// of the source workflow and are only kept for reference.
+type WorkflowExport struct {
+	Version      int              `json:"version"`
+	SourceID     string           `json:"source_id,omitempty"`
+	Description  string           `json:"description"`
+	WorkflowType string           `json:"workflow_type"`
+	Fields       []Field          `json:"fields"`
+	TableHeaders []TableHeader    `json:"table_headers"`
+	Settings     WorkflowSettings `json:"settings"`
+}
+
+// NewWorkflowExport creates the portable representation of a workflow
+func NewWorkflowExport(workflow *Workflow) *WorkflowExport {
+	return &WorkflowExport{
+		Version:      WorkflowExportVersion,
+		SourceID:     workflow.ID,
+		Description:  workflow.Description,
+		WorkflowType: workflow.WorkflowType,
+		Fields:       workflow.Fields,
+		TableHeaders: workflow.TableHeaders,
+		Settings:     workflow.Settings,
+	}
+}
+
+// Export writes a workflow's configuration (fields, table headers,
+// settings and type) to out as JSON
+func (w *Workflows) Export(workflowID string, out io.Writer) error {
+	return ExportWorkflow(w, workflowID, out)
+}
+
+// ExportWorkflow is Export through any WorkflowsAPI, such as a stub
+func ExportWorkflow(w WorkflowsAPI, workflowID string, out io.Writer) error {
+	workflow, err := w.Get(workflowID)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewWorkflowExport(workflow))
+}
+
+// Import reads a configuration written by Export and recreates it as a new
+// workflow with Create, SetFields and UpdateSettings
+func (w *Workflows) Import(in io.Reader) (*Workflow, error) {
+	return ImportWorkflow(w, in)
+}
+
+// ImportWorkflow is Import through any WorkflowsAPI
+func ImportWorkflow(w WorkflowsAPI, in io.Reader) (*Workflow, error) {
+	var export WorkflowExport
+	if err := json.NewDecoder(in).Decode(&export); err != nil {
+		return nil, err
+	}
+	return importExport(w, &export)
+}
+
+// importExport recreates an exported workflow through w, which may belong
+// to another client
+func importExport(w WorkflowsAPI, export *WorkflowExport) (*Workflow, error) {
+	if export.Version != WorkflowExportVersion {
+		return nil, fmt.Errorf("unsupported workflow export version %d", export.Version)
+	}
+
+	created, err := w.Create(CreateWorkflowRequest{
+		Description:  export.Description,
+		WorkflowType: export.WorkflowType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := SetFieldsRequest{Fields: []Field{}, TableHeaders: []TableHeader{}}
+	for _, f := range export.Fields {
+		f.ID = ""
+		req.Fields = append(req.Fields, f)
+	}
+	for _, h := range export.TableHeaders {
+		h.ID = ""
+		req.TableHeaders = append(req.TableHeaders, h)
+	}
+	if len(req.Fields) > 0 || len(req.TableHeaders) > 0 {
+		if err := w.SetFields(created.ID, req); err != nil {
+			return nil, fmt.Errorf("workflow %s created but fields not set: %w", created.ID, err)
+		}
+	}
+	if err := w.UpdateSettings(created.ID, export.Settings.UpdateRequest()); err != nil {
+		return nil, fmt.Errorf("workflow %s created but settings not updated: %w", created.ID, err)
+	}
+	return w.Get(created.ID)
+}
+
+// CloneResult represents the outcome of Clone
+type CloneResult struct {
+	Workflow *Workflow `json:"workflow"`
+	// FieldIDs maps the ID of each source field and table header to the ID
+	// of its copy
+	FieldIDs map[string]string `json:"field_ids"`
+}
+
+// Clone copies a workflow's configuration to the account of target, which
+// may be a client for a different API key
+func (w *Workflows) Clone(workflowID string, target *Client) (*CloneResult, error) {
+	return CloneWorkflow(w, target.Workflows, workflowID)
+}
+
+// CloneWorkflow is Clone from one WorkflowsAPI to another
+func CloneWorkflow(from, to WorkflowsAPI, workflowID string) (*CloneResult, error) {
+	source, err := from.Get(workflowID)
+	if err != nil {
+		return nil, err
+	}
+	copied, err := importExport(to, NewWorkflowExport(source))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CloneResult{Workflow: copied, FieldIDs: make(map[string]string)}
+	fieldIDs := make(map[string]string)
+	for _, f := range copied.Fields {
+		fieldIDs[f.Name] = f.ID
+	}
+	for _, f := range source.Fields {
+		if id, ok := fieldIDs[f.Name]; ok && f.ID != "" {
+			result.FieldIDs[f.ID] = id
+		}
+	}
+	headerIDs := make(map[string]string)
+	for _, h := range copied.TableHeaders {
+		headerIDs[h.Name] = h.ID
+	}
+	for _, h := range source.TableHeaders {
+		if id, ok := headerIDs[h.Name]; ok && h.ID != "" {
+			result.FieldIDs[h.ID] = id
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package nanonets_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+// exportSource starts a fake server with an invoice workflow to export
+func exportSource(t *testing.T) (*nanonetstest.Server, *nanonets.Workflow) {
+	t.Helper()
+	s := nanonetstest.NewServer()
+	t.Cleanup(s.Close)
+	wf := s.AddWorkflow(nanonets.Workflow{
+		Description:  "Invoices",
+		WorkflowType: "invoice",
+		Fields:       []nanonets.Field{{Name: "invoice_number", Required: true}, {Name: "total", Type: "number"}},
+		TableHeaders: []nanonets.TableHeader{{Name: "description"}},
+	})
+	return s, wf
+}
+
+func TestExportImport(t *testing.T) {
+	source, wf := exportSource(t)
+	var buf bytes.Buffer
+	if err := source.Client().Workflows.Export(wf.ID, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var export nanonets.WorkflowExport
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatal(err)
+	}
+	if export.Version != nanonets.WorkflowExportVersion || export.SourceID != wf.ID {
+		t.Errorf("export version %d, source %q", export.Version, export.SourceID)
+	}
+
+	target := nanonetstest.NewServer()
+	defer target.Close()
+	imported, err := target.Client().Workflows.Import(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Description != "Invoices" || imported.WorkflowType != "invoice" {
+		t.Errorf("imported workflow %+v", imported)
+	}
+	if len(imported.Fields) != 2 || len(imported.TableHeaders) != 1 {
+		t.Fatalf("imported %d fields and %d table headers, want 2 and 1", len(imported.Fields), len(imported.TableHeaders))
+	}
+	for _, f := range imported.Fields {
+		if f.Name == "invoice_number" && !f.Required || f.Name == "total" && f.Type != "number" {
+			t.Errorf("imported field %+v lost its definition", f)
+		}
+	}
+}
+
+func TestImportUnsupportedVersion(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	_, err := s.Client().Workflows.Import(strings.NewReader(`{"version": 99, "description": "Invoices"}`))
+	if err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("err = %v, want an unsupported version error", err)
+	}
+	if len(s.Requests()) != 0 {
+		t.Errorf("import of an unsupported version sent %d requests", len(s.Requests()))
+	}
+}
+
+func TestClone(t *testing.T) {
+	source, wf := exportSource(t)
+	target := nanonetstest.NewServer()
+	defer target.Close()
+
+	result, err := source.Client().Workflows.Clone(wf.ID, target.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, ok := target.Workflow(result.Workflow.ID)
+	if !ok {
+		t.Fatalf("clone %s not found on the target", result.Workflow.ID)
+	}
+	if len(result.FieldIDs) != 3 {
+		t.Errorf("FieldIDs %v, want an entry for each field and table header", result.FieldIDs)
+	}
+	targetIDs := make(map[string]string)
+	for _, f := range copied.Fields {
+		targetIDs[f.ID] = f.Name
+	}
+	for _, h := range copied.TableHeaders {
+		targetIDs[h.ID] = h.Name
+	}
+	for _, f := range wf.Fields {
+		if name := targetIDs[result.FieldIDs[f.ID]]; name != f.Name {
+			t.Errorf("field %s (%s) maps to %q on the target", f.ID, f.Name, name)
+		}
+	}
+	if len(source.Requests()) != 1 {
+		t.Errorf("clone sent %d requests to the source, want only the workflow fetch", len(source.Requests()))
+	}
+}
@@ -0,0 +1,293 @@
+package nanonets
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// knownKeysCache maps a struct type to the set of JSON keys it declares
+var knownKeysCache sync.Map
+
+// knownKeys returns the JSON keys declared by the fields of struct type t
+func knownKeys(t reflect.Type) map[string]bool {
+	if keys, ok := knownKeysCache.Load(t); ok {
+		return keys.(map[string]bool)
+	}
+	keys := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		keys[name] = true
+	}
+	knownKeysCache.Store(t, keys)
+	return keys
+}
+
+// unmarshalExtra decodes data into v, a pointer to a struct without custom
+// JSON methods, and returns the keys v doesn't declare
+func unmarshalExtra(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	known := knownKeys(reflect.TypeOf(v).Elem())
+	var extra map[string]json.RawMessage
+	for key, value := range raw {
+		if known[key] {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[key] = value
+	}
+	return extra, nil
+}
+
+// marshalExtra encodes v, a struct without custom JSON methods, and merges
+// in the extra keys. Keys declared by v take precedence.
+func marshalExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	var b bytes.Buffer
+	b.Write(data[:len(data)-1])
+	first := len(data) == 2
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	known := knownKeys(reflect.TypeOf(v))
+	for _, key := range keys {
+		if known[key] {
+			continue
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			b.WriteByte(',')
+		}
+		first = false
+		b.Write(name)
+		b.WriteByte(':')
+		if len(extra[key]) == 0 {
+			b.WriteString("null")
+		} else {
+			b.Write(extra[key])
+		}
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// UnmarshalJSON keeps keys the SDK doesn't model in Extra
+func (v *Workflow) UnmarshalJSON(data []byte) error {
+	type plain Workflow
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*v = Workflow(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled keys followed by those in Extra
+func (v Workflow) MarshalJSON() ([]byte, error) {
+	type plain Workflow
+	return marshalExtra(plain(v), v.Extra)
+}
+
+// UnmarshalJSON keeps keys the SDK doesn't model in Extra
+func (v *Field) UnmarshalJSON(data []byte) error {
+	type plain Field
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*v = Field(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled keys followed by those in Extra
+func (v Field) MarshalJSON() ([]byte, error) {
+	type plain Field
+	return marshalExtra(plain(v), v.Extra)
+}
+
+// UnmarshalJSON keeps keys the SDK doesn't model in Extra
+func (v *TableHeader) UnmarshalJSON(data []byte) error {
+	type plain TableHeader
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*v = TableHeader(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled keys followed by those in Extra
+func (v TableHeader) MarshalJSON() ([]byte, error) {
+	type plain TableHeader
+	return marshalExtra(plain(v), v.Extra)
+}
+
+// UnmarshalJSON keeps keys the SDK doesn't model in Extra
+func (v *WorkflowType) UnmarshalJSON(data []byte) error {
+	type plain WorkflowType
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*v = WorkflowType(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled keys followed by those in Extra
+func (v WorkflowType) MarshalJSON() ([]byte, error) {
+	type plain WorkflowType
+	return marshalExtra(plain(v), v.Extra)
+}
+
+// UnmarshalJSON keeps keys the SDK doesn't model in Extra
+func (v *Document) UnmarshalJSON(data []byte) error {
+	type plain Document
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*v = Document(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled keys followed by those in Extra
+func (v Document) MarshalJSON() ([]byte, error) {
+	type plain Document
+	return marshalExtra(plain(v), v.Extra)
+}
+
+// UnmarshalJSON keeps keys the SDK doesn't model in Extra
+func (v *Page) UnmarshalJSON(data []byte) error {
+	type plain Page
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*v = Page(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled keys followed by those in Extra
+func (v Page) MarshalJSON() ([]byte, error) {
+	type plain Page
+	return marshalExtra(plain(v), v.Extra)
+}
+
+// UnmarshalJSON keeps keys the SDK doesn't model in Extra
+func (v *PageData) UnmarshalJSON(data []byte) error {
+	type plain PageData
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*v = PageData(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled keys followed by those in Extra
+func (v PageData) MarshalJSON() ([]byte, error) {
+	type plain PageData
+	return marshalExtra(plain(v), v.Extra)
+}
+
+// UnmarshalJSON keeps keys the SDK doesn't model in Extra
+func (v *FieldData) UnmarshalJSON(data []byte) error {
+	type plain FieldData
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*v = FieldData(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled keys followed by those in Extra
+func (v FieldData) MarshalJSON() ([]byte, error) {
+	type plain FieldData
+	return marshalExtra(plain(v), v.Extra)
+}
+
+// UnmarshalJSON keeps keys the SDK doesn't model in Extra
+func (v *Table) UnmarshalJSON(data []byte) error {
+	type plain Table
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*v = Table(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled keys followed by those in Extra
+func (v Table) MarshalJSON() ([]byte, error) {
+	type plain Table
+	return marshalExtra(plain(v), v.Extra)
+}
+
+// UnmarshalJSON keeps keys the SDK doesn't model in Extra
+func (v *TableCell) UnmarshalJSON(data []byte) error {
+	type plain TableCell
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*v = TableCell(p)
+	v.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled keys followed by those in Extra
+func (v TableCell) MarshalJSON() ([]byte, error) {
+	type plain TableCell
+	return marshalExtra(plain(v), v.Extra)
+}
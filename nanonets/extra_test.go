@@ -0,0 +1,111 @@
+package nanonets_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+const documentWithUnknownKeys = `{
+	"document_id": "doc_1",
+	"review_url": "https://example.com/review/doc_1",
+	"pages": [{
+		"page_id": "p1",
+		"rotation": 90,
+		"data": {
+			"language": "de",
+			"fields": {"total": [{"field_data_id": "fd_1", "value": "12.00", "ocr_text": "12,00"}]},
+			"tables": [{"table_id": "t1", "kind": "line_items", "cells": [{"cell_id": "c1", "text": "1", "span": {"rows": 2}}]}]
+		}
+	}]
+}`
+
+func TestExtraRoundTrip(t *testing.T) {
+	var doc nanonets.Document
+	if err := json.Unmarshal([]byte(documentWithUnknownKeys), &doc); err != nil {
+		t.Fatal(err)
+	}
+	page := doc.Pages[0]
+	if string(doc.Extra["review_url"]) != `"https://example.com/review/doc_1"` || string(page.Extra["rotation"]) != "90" {
+		t.Errorf("document extra %s, page extra %s", doc.Extra, page.Extra)
+	}
+	if _, ok := doc.Extra["document_id"]; ok {
+		t.Error("modeled key document_id is in Extra")
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		ReviewURL string `json:"review_url"`
+		Pages     []struct {
+			Rotation int `json:"rotation"`
+			Data     struct {
+				Language string `json:"language"`
+				Fields   map[string][]struct {
+					OCRText string `json:"ocr_text"`
+				} `json:"fields"`
+				Tables []struct {
+					Kind  string `json:"kind"`
+					Cells []struct {
+						Span map[string]int `json:"span"`
+					} `json:"cells"`
+				} `json:"tables"`
+			} `json:"data"`
+		} `json:"pages"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	p := out.Pages[0]
+	table := p.Data.Tables[0]
+	if out.ReviewURL == "" || p.Rotation != 90 || p.Data.Language != "de" ||
+		p.Data.Fields["total"][0].OCRText != "12,00" || table.Kind != "line_items" || table.Cells[0].Span["rows"] != 2 {
+		t.Errorf("unknown keys lost on re-marshal: %s", data)
+	}
+}
+
+func TestExtraDoesNotOverrideModeledKeys(t *testing.T) {
+	f := nanonets.Field{
+		Name: "total",
+		Extra: map[string]json.RawMessage{
+			"name":    json.RawMessage(`"shadowed"`),
+			"sources": nil,
+		},
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["name"] != "total" {
+		t.Errorf("name = %v, want total", got["name"])
+	}
+	if v, ok := got["sources"]; !ok || v != nil {
+		t.Errorf("sources = %v, %v; want an explicit null", v, ok)
+	}
+}
+
+func TestExtraNil(t *testing.T) {
+	var wf nanonets.Workflow
+	if err := json.Unmarshal([]byte(`{"id": "wf_1", "description": "Invoices"}`), &wf); err != nil {
+		t.Fatal(err)
+	}
+	if wf.Extra != nil {
+		t.Errorf("Extra = %v, want nil when every key is modeled", wf.Extra)
+	}
+	var again nanonets.Workflow
+	data, _ := json.Marshal(wf)
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(wf, again) {
+		t.Errorf("round trip changed the workflow: %+v != %+v", wf, again)
+	}
+}
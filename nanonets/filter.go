@@ -0,0 +1,85 @@
+package nanonets
+
+import (
+	"sort"
+	"time"
+)
+
+// DocumentFilter selects documents by status and upload time. Zero values
+// match every document.
+type DocumentFilter struct {
+	// Statuses matches any of the listed processing statuses
+	Statuses []DocumentStatus
+	// VerificationStatuses matches any of the listed verification statuses
+	VerificationStatuses []VerificationStatus
+	// UploadedAfter and UploadedBefore bound the upload time (inclusive
+	// and exclusive respectively)
+	UploadedAfter  time.Time
+	UploadedBefore time.Time
+	// Metadata matches documents whose metadata has all of these keys with
+	// equal string values
+	Metadata map[string]string
+}
+
+// Match reports whether a document passes the filter. Documents without
+// an upload time don't match a time bound.
+func (f DocumentFilter) Match(doc *Document) bool {
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, s := range f.Statuses {
+			found = found || doc.Status == s
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.VerificationStatuses) > 0 {
+		found := false
+		for _, s := range f.VerificationStatuses {
+			found = found || doc.VerificationStatus == s
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.UploadedAfter.IsZero() && (doc.UploadedAt.IsZero() || doc.UploadedAt.Before(f.UploadedAfter)) {
+		return false
+	}
+	if !f.UploadedBefore.IsZero() && (doc.UploadedAt.IsZero() || !doc.UploadedAt.Before(f.UploadedBefore)) {
+		return false
+	}
+	for key, want := range f.Metadata {
+		if got, ok := doc.Metadata.String(key); !ok || got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterDocuments returns the documents that pass the filter, in their
+// original order
+func FilterDocuments(docs []Document, f DocumentFilter) []Document {
+	var out []Document
+	for i := range docs {
+		if f.Match(&docs[i]) {
+			out = append(out, docs[i])
+		}
+	}
+	return out
+}
+
+// SortDocumentsByUploadedAt sorts documents by upload time, oldest first
+// unless newestFirst is set. Documents without an upload time sort last
+// and otherwise keep their order.
+func SortDocumentsByUploadedAt(docs []Document, newestFirst bool) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, b := docs[i].UploadedAt, docs[j].UploadedAt
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		if newestFirst {
+			return a.After(b.Time)
+		}
+		return a.Before(b.Time)
+	})
+}
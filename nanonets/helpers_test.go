@@ -0,0 +1,46 @@
+package nanonets_test
+
+import (
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+// seedDocument starts a fake server with a workflow holding doc and
+// returns the server, the workflow ID and the stored document
+func seedDocument(t *testing.T, doc nanonets.Document) (*nanonetstest.Server, string, *nanonets.Document) {
+	t.Helper()
+	s := nanonetstest.NewServer()
+	t.Cleanup(s.Close)
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	stored, err := s.AddDocument(wf.ID, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, wf.ID, stored
+}
+
+// invoice returns a document with a low-confidence, empty invoice number
+// and a high-confidence vendor
+func invoice() nanonets.Document {
+	return nanonets.Document{Pages: []nanonets.Page{{Data: nanonets.PageData{Fields: map[string][]nanonets.FieldData{
+		"invoice_number": {{Value: "", Confidence: 0.4}},
+		"vendor":         {{Value: "Acme", Confidence: 0.95}},
+	}}}}}
+}
+
+// fieldValue returns the value of the first field with the given name on
+// the server
+func fieldValue(t *testing.T, s *nanonetstest.Server, documentID, name string) string {
+	t.Helper()
+	doc, ok := s.Document(documentID)
+	if !ok {
+		t.Fatalf("document %s not found", documentID)
+	}
+	values := doc.Pages[0].Data.Fields[name]
+	if len(values) == 0 {
+		t.Fatalf("field %s not found", name)
+	}
+	return values[0].Value
+}
@@ -0,0 +1,94 @@
+package nanonets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Metadata is the user-supplied metadata attached to a document
+type Metadata map[string]any
+
+// UnmarshalJSON accepts a JSON object, or a string holding one as sent by
+// UploadFromURL. Null and the empty string decode to nil.
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+	if len(bytes.TrimSpace(data)) == 0 || bytes.Equal(data, []byte("null")) {
+		*m = nil
+		return nil
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("metadata is not a JSON object: %w", err)
+	}
+	*m = values
+	return nil
+}
+
+// Get returns the value of a key
+func (m Metadata) Get(key string) (any, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+// String returns the value of a key as a string. Numbers and booleans are
+// formatted; other values are reported as missing.
+func (m Metadata) String(key string) (string, bool) {
+	switch v := m[key].(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// Float64 returns the value of a key as a number. Numeric strings are
+// parsed.
+func (m Metadata) Float64(key string) (float64, bool) {
+	switch v := m[key].(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Bool returns the value of a key as a boolean. The strings accepted by
+// strconv.ParseBool are parsed.
+func (m Metadata) Bool(key string) (bool, bool) {
+	switch v := m[key].(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return false, false
+}
+
+// Decode decodes the value of a key into v, which may be any type the
+// value can be unmarshaled into. It reports whether the key is present.
+func (m Metadata) Decode(key string, v any) (bool, error) {
+	value, ok := m[key]
+	if !ok {
+		return false, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return true, err
+	}
+	return true, json.Unmarshal(data, v)
+}
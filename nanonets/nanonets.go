@@ -16,9 +16,9 @@ type Client struct {
 	APIKey     string
 	BaseURL    string
 	Client     *http.Client
-	Workflows  *Workflows
-	Documents  *Documents
-	Moderation *Moderation
+	Workflows  WorkflowsAPI
+	Documents  DocumentsAPI
+	Moderation ModerationAPI
 }
 
 // NewClient creates a new Nanonets API client
@@ -59,8 +59,7 @@ func (w *Workflows) Create(req CreateWorkflowRequest) (*Workflow, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var workflow Workflow
@@ -85,8 +84,7 @@ func (w *Workflows) Get(workflowID string) (*Workflow, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var workflow Workflow
@@ -111,8 +109,7 @@ func (w *Workflows) List() ([]Workflow, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var workflows []Workflow
@@ -143,8 +140,7 @@ func (w *Workflows) SetFields(workflowID string, req SetFieldsRequest) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -171,8 +167,7 @@ func (w *Workflows) UpdateField(workflowID, fieldID string, req UpdateFieldReque
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -193,8 +188,7 @@ func (w *Workflows) DeleteField(workflowID, fieldID string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -221,8 +215,7 @@ func (w *Workflows) UpdateMetadata(workflowID string, req UpdateMetadataRequest)
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -249,8 +242,7 @@ func (w *Workflows) UpdateSettings(workflowID string, req UpdateSettingsRequest)
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -271,8 +263,7 @@ func (w *Workflows) GetTypes() ([]WorkflowType, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var types []WorkflowType
@@ -326,8 +317,7 @@ func (d *Documents) Upload(workflowID string, req UploadDocumentRequest) (*Docum
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var result Document
@@ -352,8 +342,7 @@ func (d *Documents) Get(workflowID, documentID string) (*Document, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var document Document
@@ -378,8 +367,7 @@ func (d *Documents) List(workflowID string) ([]Document, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var documents []Document
@@ -404,8 +392,7 @@ func (d *Documents) Delete(workflowID, documentID string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -426,8 +413,7 @@ func (d *Documents) GetFields(workflowID, documentID string) ([]Field, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var fields []Field
@@ -452,8 +438,7 @@ func (d *Documents) GetTables(workflowID, documentID string) ([]Table, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var tables []Table
@@ -489,8 +474,7 @@ func (m *Moderation) UpdateField(workflowID, documentID, pageID, fieldDataID str
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -517,8 +501,7 @@ func (m *Moderation) AddField(workflowID, documentID, pageID string, req AddFiel
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -539,8 +522,7 @@ func (m *Moderation) DeleteField(workflowID, documentID, pageID, fieldDataID str
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -567,8 +549,7 @@ func (m *Moderation) AddTable(workflowID, documentID, pageID string, req AddTabl
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -589,8 +570,7 @@ func (m *Moderation) DeleteTable(workflowID, documentID, pageID, tableID string)
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -617,8 +597,7 @@ func (m *Moderation) UpdateTableCell(workflowID, documentID, pageID, tableID, ce
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -645,8 +624,7 @@ func (m *Moderation) AddTableCell(workflowID, documentID, pageID, tableID string
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -667,8 +645,7 @@ func (m *Moderation) DeleteTableCell(workflowID, documentID, pageID, tableID, ce
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -695,8 +672,7 @@ func (m *Moderation) VerifyField(workflowID, documentID, pageID, fieldDataID str
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -723,8 +699,7 @@ func (m *Moderation) VerifyTableCell(workflowID, documentID, pageID, tableID, ce
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -751,8 +726,7 @@ func (m *Moderation) VerifyTable(workflowID, documentID, pageID, tableID string,
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -779,8 +753,7 @@ func (m *Moderation) VerifyDocument(workflowID, documentID string, req VerifyDoc
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
@@ -799,8 +772,11 @@ type SetFieldsRequest struct {
 }
 
 // UpdateFieldRequest represents a request to update a field
+// (Name renames a workflow field, Value updates a moderated field value;
+// Value is a pointer so that a field can be set to "")
 type UpdateFieldRequest struct {
-	Name string `json:"name"`
+	Name  string  `json:"name,omitempty"`
+	Value *string `json:"value,omitempty"`
 }
 
 // UpdateMetadataRequest represents a request to update metadata
@@ -808,9 +784,12 @@ type UpdateMetadataRequest struct {
 	Description string `json:"description"`
 }
 
-// UpdateSettingsRequest represents a request to update settings
+// UpdateSettingsRequest represents a patch of workflow settings
+// (only settings that are set are sent; Extra holds settings the SDK
+// doesn't model and is sent as-is, and Set fills in either by JSON key)
 type UpdateSettingsRequest struct {
-	TableCapture bool `json:"table_capture"`
+	TableCapture *bool                      `json:"table_capture,omitempty"`
+	Extra        map[string]json.RawMessage `json:"-"`
 }
 
 // UploadDocumentRequest represents a request to upload a document
@@ -822,93 +801,120 @@ type UploadDocumentRequest struct {
 
 // Workflow represents a workflow (full schema from API docs)
 type Workflow struct {
-	ID           string           `json:"id"`
-	Description  string           `json:"description"`
-	WorkflowType string           `json:"workflow_type"`
-	Fields       []Field          `json:"fields"`
-	TableHeaders []TableHeader    `json:"table_headers"`
-	Settings     WorkflowSettings `json:"settings"`
-	CreatedAt    string           `json:"created_at"`
-	UpdatedAt    string           `json:"updated_at"`
+	ID           string                     `json:"id"`
+	Description  string                     `json:"description"`
+	WorkflowType string                     `json:"workflow_type"`
+	Fields       []Field                    `json:"fields"`
+	TableHeaders []TableHeader              `json:"table_headers"`
+	Settings     WorkflowSettings           `json:"settings"`
+	CreatedAt    Time                       `json:"created_at"`
+	UpdatedAt    Time                       `json:"updated_at"`
+	Extra        map[string]json.RawMessage `json:"-"`
 }
 
-// WorkflowSettings represents workflow settings (e.g., table capture)
+// WorkflowSettings represents workflow settings. The v4 API reference
+// documents table_capture only; other settings configured in the
+// dashboard are kept in Extra, survive re-marshaling and can be read
+// with Get.
 type WorkflowSettings struct {
-	TableCapture bool `json:"table_capture"`
+	TableCapture bool                       `json:"table_capture"`
+	Extra        map[string]json.RawMessage `json:"-"`
 }
 
 // Document represents a document (full schema from API docs)
 type Document struct {
-	DocumentID           string      `json:"document_id"`
-	Status               string      `json:"status"`
-	UploadedAt           string      `json:"uploaded_at"`
-	Metadata             interface{} `json:"metadata"`
-	OriginalDocumentName string      `json:"original_document_name"`
-	RawDocumentURL       string      `json:"raw_document_url"`
-	VerificationStatus   string      `json:"verification_status"`
-	VerificationStage    string      `json:"verification_stage"`
-	VerificationMessage  string      `json:"verification_message"`
-	AssignedReviewers    []string    `json:"assigned_reviewers"`
-	Pages                []Page      `json:"pages"`
+	DocumentID           string                     `json:"document_id"`
+	Status               DocumentStatus             `json:"status"`
+	UploadedAt           Time                       `json:"uploaded_at"`
+	Metadata             Metadata                   `json:"metadata"`
+	OriginalDocumentName string                     `json:"original_document_name"`
+	RawDocumentURL       string                     `json:"raw_document_url"`
+	VerificationStatus   VerificationStatus         `json:"verification_status"`
+	VerificationStage    VerificationStage          `json:"verification_stage"`
+	VerificationMessage  string                     `json:"verification_message"`
+	AssignedReviewers    []string                   `json:"assigned_reviewers"`
+	Pages                []Page                     `json:"pages"`
+	Extra                map[string]json.RawMessage `json:"-"`
 }
 
 // Page represents a page in a document
 // (nested under Document.Pages)
 type Page struct {
-	PageID     string   `json:"page_id"`
-	PageNumber int      `json:"page_number"`
-	ImageURL   string   `json:"image_url"`
-	Data       PageData `json:"data"`
+	PageID     string                     `json:"page_id"`
+	PageNumber int                        `json:"page_number"`
+	ImageURL   string                     `json:"image_url"`
+	Data       PageData                   `json:"data"`
+	Extra      map[string]json.RawMessage `json:"-"`
 }
 
 // PageData contains fields and tables for a page
 // (nested under Page.Data)
 type PageData struct {
-	Fields map[string][]FieldData `json:"fields"`
-	Tables []Table                `json:"tables"`
+	Fields map[string][]FieldData     `json:"fields"`
+	Tables []Table                    `json:"tables"`
+	Extra  map[string]json.RawMessage `json:"-"`
 }
 
 // FieldData represents a single field value instance
 // (nested under PageData.Fields)
 type FieldData struct {
-	FieldDataID         string    `json:"field_data_id"`
-	Value               string    `json:"value"`
-	Confidence          float64   `json:"confidence"`
-	Bbox                []float64 `json:"bbox"`
-	VerificationStatus  string    `json:"verification_status"`
-	VerificationMessage string    `json:"verification_message"`
-	IsModerated         bool      `json:"is_moderated"`
+	FieldDataID         string                     `json:"field_data_id"`
+	Value               string                     `json:"value"`
+	Confidence          float64                    `json:"confidence"`
+	Bbox                []float64                  `json:"bbox"`
+	VerificationStatus  VerificationStatus         `json:"verification_status"`
+	VerificationMessage string                     `json:"verification_message"`
+	IsModerated         bool                       `json:"is_moderated"`
+	Extra               map[string]json.RawMessage `json:"-"`
 }
 
 // Table represents a table (nested under PageData.Tables)
 type Table struct {
-	TableID string      `json:"table_id"`
-	Bbox    []float64   `json:"bbox"`
-	Cells   []TableCell `json:"cells"`
+	TableID string                     `json:"table_id"`
+	Bbox    []float64                  `json:"bbox"`
+	Cells   []TableCell                `json:"cells"`
+	Extra   map[string]json.RawMessage `json:"-"`
 }
 
 // TableCell represents a cell in a table
 // (nested under Table.Cells)
 type TableCell struct {
-	CellID              string    `json:"cell_id"`
-	Row                 int       `json:"row"`
-	Col                 int       `json:"col"`
-	Header              string    `json:"header"`
-	Text                string    `json:"text"`
-	Bbox                []float64 `json:"bbox"`
-	VerificationStatus  string    `json:"verification_status"`
-	VerificationMessage string    `json:"verification_message"`
-	IsModerated         bool      `json:"is_moderated"`
+	CellID              string                     `json:"cell_id"`
+	Row                 int                        `json:"row"`
+	Col                 int                        `json:"col"`
+	Header              string                     `json:"header"`
+	Text                string                     `json:"text"`
+	Bbox                []float64                  `json:"bbox"`
+	VerificationStatus  VerificationStatus         `json:"verification_status"`
+	VerificationMessage string                     `json:"verification_message"`
+	IsModerated         bool                       `json:"is_moderated"`
+	Extra               map[string]json.RawMessage `json:"-"`
 }
 
 // Field represents a field
+// (Type, Required, AllowedValues and Pattern are checked by SchemaRule)
 type Field struct {
-	Name string `json:"name"`
+	ID            string                     `json:"id,omitempty"`
+	Name          string                     `json:"name"`
+	Type          FieldType                  `json:"type,omitempty"`
+	Required      bool                       `json:"required,omitempty"`
+	Description   string                     `json:"description,omitempty"`
+	AllowedValues []string                   `json:"allowed_values,omitempty"`
+	Pattern       string                     `json:"pattern,omitempty"`
+	Extra         map[string]json.RawMessage `json:"-"`
 }
 
 // TableHeader represents a table header
+// (Type, Required, AllowedValues and Pattern are checked by SchemaRule)
 type TableHeader struct {
-	Name string `json:"name"`
+	ID            string                     `json:"id,omitempty"`
+	Name          string                     `json:"name"`
+	Type          FieldType                  `json:"type,omitempty"`
+	Required      bool                       `json:"required,omitempty"`
+	Description   string                     `json:"description,omitempty"`
+	AllowedValues []string                   `json:"allowed_values,omitempty"`
+	Pattern       string                     `json:"pattern,omitempty"`
+	Extra         map[string]json.RawMessage `json:"-"`
 }
 
 // UploadResult represents the result of an upload
@@ -919,32 +925,32 @@ type UploadResult struct {
 
 // AddFieldRequest represents a request to add a field
 type AddFieldRequest struct {
-	FieldName           string    `json:"field_name"`
-	Value               string    `json:"value"`
-	Bbox                []float64 `json:"bbox"`
-	Confidence          float64   `json:"confidence"`
-	VerificationStatus  string    `json:"verification_status"`
-	VerificationMessage string    `json:"verification_message"`
+	FieldName           string             `json:"field_name"`
+	Value               string             `json:"value"`
+	Bbox                []float64          `json:"bbox"`
+	Confidence          float64            `json:"confidence"`
+	VerificationStatus  VerificationStatus `json:"verification_status"`
+	VerificationMessage string             `json:"verification_message"`
 }
 
 // AddTableRequest represents a request to add a table
 type AddTableRequest struct {
-	Bbox                []float64 `json:"bbox"`
-	Headers             []string  `json:"headers"`
-	VerificationStatus  string    `json:"verification_status"`
-	VerificationMessage string    `json:"verification_message"`
-	Cells               []Cell    `json:"cells"`
+	Bbox                []float64          `json:"bbox"`
+	Headers             []string           `json:"headers"`
+	VerificationStatus  VerificationStatus `json:"verification_status"`
+	VerificationMessage string             `json:"verification_message"`
+	Cells               []Cell             `json:"cells"`
 }
 
 // AddTableCellRequest represents a request to add a table cell
 type AddTableCellRequest struct {
-	Row                 int       `json:"row"`
-	Col                 int       `json:"col"`
-	Header              string    `json:"header"`
-	Text                string    `json:"text"`
-	Bbox                []float64 `json:"bbox"`
-	VerificationStatus  string    `json:"verification_status"`
-	VerificationMessage string    `json:"verification_message"`
+	Row                 int                `json:"row"`
+	Col                 int                `json:"col"`
+	Header              string             `json:"header"`
+	Text                string             `json:"text"`
+	Bbox                []float64          `json:"bbox"`
+	VerificationStatus  VerificationStatus `json:"verification_status"`
+	VerificationMessage string             `json:"verification_message"`
 }
 
 // UpdateTableCellRequest represents a request to update a table cell
@@ -954,37 +960,37 @@ type UpdateTableCellRequest struct {
 
 // VerifyFieldRequest represents a request to verify a field
 type VerifyFieldRequest struct {
-	VerificationStatus  string `json:"verification_status"`
-	VerificationMessage string `json:"verification_message"`
+	VerificationStatus  VerificationStatus `json:"verification_status"`
+	VerificationMessage string             `json:"verification_message"`
 }
 
 // VerifyTableCellRequest represents a request to verify a table cell
 type VerifyTableCellRequest struct {
-	VerificationStatus  string `json:"verification_status"`
-	VerificationMessage string `json:"verification_message"`
+	VerificationStatus  VerificationStatus `json:"verification_status"`
+	VerificationMessage string             `json:"verification_message"`
 }
 
 // VerifyTableRequest represents a request to verify a table
 type VerifyTableRequest struct {
-	VerificationStatus  string `json:"verification_status"`
-	VerificationMessage string `json:"verification_message"`
+	VerificationStatus  VerificationStatus `json:"verification_status"`
+	VerificationMessage string             `json:"verification_message"`
 }
 
 // VerifyDocumentRequest represents a request to verify a document
 type VerifyDocumentRequest struct {
-	VerificationStatus  string `json:"verification_status"`
-	VerificationMessage string `json:"verification_message"`
+	VerificationStatus  VerificationStatus `json:"verification_status"`
+	VerificationMessage string             `json:"verification_message"`
 }
 
 // Cell represents a cell in a table
 type Cell struct {
-	Row                 int       `json:"row"`
-	Col                 int       `json:"col"`
-	Header              string    `json:"header"`
-	Text                string    `json:"text"`
-	Bbox                []float64 `json:"bbox"`
-	VerificationStatus  string    `json:"verification_status"`
-	VerificationMessage string    `json:"verification_message"`
+	Row                 int                `json:"row"`
+	Col                 int                `json:"col"`
+	Header              string             `json:"header"`
+	Text                string             `json:"text"`
+	Bbox                []float64          `json:"bbox"`
+	VerificationStatus  VerificationStatus `json:"verification_status"`
+	VerificationMessage string             `json:"verification_message"`
 }
 
 // UploadFromURL uploads a document to a workflow from a URL
@@ -1007,8 +1013,7 @@ func (d *Documents) UploadFromURL(workflowID string, req UploadDocumentFromURLRe
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var result Document
@@ -1034,8 +1039,7 @@ func (d *Documents) ListWithPagination(workflowID string, page, limit int) ([]Do
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	var documents []Document
@@ -1061,8 +1065,7 @@ func (d *Documents) GetOriginalFile(workflowID, documentID string) ([]byte, erro
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s (status %d)", string(body), resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	return io.ReadAll(resp.Body)
@@ -1072,9 +1075,10 @@ func (d *Documents) GetOriginalFile(workflowID, documentID string) ([]byte, erro
 // Add this type for GetTypes
 // Example: {"id": "invoice", "name": "Invoice", "description": "Extracts data from invoices"}
 type WorkflowType struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	ID          string                     `json:"id"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description"`
+	Extra       map[string]json.RawMessage `json:"-"`
 }
 
 // UploadDocumentFromURLRequest represents a request to upload a document from a URL
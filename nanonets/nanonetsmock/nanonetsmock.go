@@ -0,0 +1,479 @@
+// Package nanonetsmock provides hand-written stubs of the nanonets service
+// interfaces for unit tests. Set the function fields for the methods a test
+// needs and inspect the recorded calls afterwards. Methods built on other
+// API calls, such as Export or AutoVerify, run the SDK's implementation on
+// top of the stubbed calls unless their own function field is set:
+//
+//	client, workflows, _, _ := nanonetsmock.NewClient()
+//	workflows.GetFunc = func(id string) (*nanonets.Workflow, error) {
+//		return &nanonets.Workflow{ID: id}, nil
+//	}
+//	runCodeUnderTest(client)
+//	calls := workflows.CallsTo("Get")
+package nanonetsmock
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+// ErrNotStubbed is returned by a stub method whose function field is nil
+var ErrNotStubbed = errors.New("nanonetsmock: method not stubbed")
+
+// Call is a recorded method call
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// recorder keeps the calls made to a stub
+type recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *recorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns every call made so far, in order
+func (r *recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the calls made to one method, in order
+func (r *recorder) CallsTo(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Call
+	for _, c := range r.calls {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Reset forgets the recorded calls
+func (r *recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// NewClient returns a client whose services are new stubs, along with the
+// stubs
+func NewClient() (*nanonets.Client, *Workflows, *Documents, *Moderation) {
+	w, d := &Workflows{}, &Documents{}
+	m := &Moderation{Documents: d}
+	c := nanonets.NewClient("")
+	c.Workflows, c.Documents, c.Moderation = w, d, m
+	return c, w, d, m
+}
+
+var (
+	_ nanonets.WorkflowsAPI  = (*Workflows)(nil)
+	_ nanonets.DocumentsAPI  = (*Documents)(nil)
+	_ nanonets.ModerationAPI = (*Moderation)(nil)
+)
+
+// Workflows is a stub of the workflows API. Each method records the call and
+// runs the matching function field, or returns ErrNotStubbed if it is nil.
+// Export, Import, Clone, Plan and Apply fall back to the SDK's
+// implementation instead.
+type Workflows struct {
+	recorder
+
+	CreateFunc         func(req nanonets.CreateWorkflowRequest) (*nanonets.Workflow, error)
+	GetFunc            func(workflowID string) (*nanonets.Workflow, error)
+	ListFunc           func() ([]nanonets.Workflow, error)
+	SetFieldsFunc      func(workflowID string, req nanonets.SetFieldsRequest) error
+	UpdateFieldFunc    func(workflowID, fieldID string, req nanonets.UpdateFieldRequest) error
+	DeleteFieldFunc    func(workflowID, fieldID string) error
+	UpdateMetadataFunc func(workflowID string, req nanonets.UpdateMetadataRequest) error
+	UpdateSettingsFunc func(workflowID string, req nanonets.UpdateSettingsRequest) error
+	GetTypesFunc       func() ([]nanonets.WorkflowType, error)
+	ExportFunc         func(workflowID string, out io.Writer) error
+	ImportFunc         func(in io.Reader) (*nanonets.Workflow, error)
+	CloneFunc          func(workflowID string, target *nanonets.Client) (*nanonets.CloneResult, error)
+	PlanFunc           func(workflowID string, spec nanonets.WorkflowSpec) (*nanonets.Plan, error)
+	ApplyFunc          func(plan *nanonets.Plan, opts nanonets.ApplyOptions) (*nanonets.Workflow, error)
+}
+
+// Create calls CreateFunc
+func (w *Workflows) Create(req nanonets.CreateWorkflowRequest) (*nanonets.Workflow, error) {
+	w.record("Create", req)
+	if w.CreateFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return w.CreateFunc(req)
+}
+
+// Get calls GetFunc
+func (w *Workflows) Get(workflowID string) (*nanonets.Workflow, error) {
+	w.record("Get", workflowID)
+	if w.GetFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return w.GetFunc(workflowID)
+}
+
+// List calls ListFunc
+func (w *Workflows) List() ([]nanonets.Workflow, error) {
+	w.record("List")
+	if w.ListFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return w.ListFunc()
+}
+
+// SetFields calls SetFieldsFunc
+func (w *Workflows) SetFields(workflowID string, req nanonets.SetFieldsRequest) error {
+	w.record("SetFields", workflowID, req)
+	if w.SetFieldsFunc == nil {
+		return ErrNotStubbed
+	}
+	return w.SetFieldsFunc(workflowID, req)
+}
+
+// UpdateField calls UpdateFieldFunc
+func (w *Workflows) UpdateField(workflowID, fieldID string, req nanonets.UpdateFieldRequest) error {
+	w.record("UpdateField", workflowID, fieldID, req)
+	if w.UpdateFieldFunc == nil {
+		return ErrNotStubbed
+	}
+	return w.UpdateFieldFunc(workflowID, fieldID, req)
+}
+
+// DeleteField calls DeleteFieldFunc
+func (w *Workflows) DeleteField(workflowID, fieldID string) error {
+	w.record("DeleteField", workflowID, fieldID)
+	if w.DeleteFieldFunc == nil {
+		return ErrNotStubbed
+	}
+	return w.DeleteFieldFunc(workflowID, fieldID)
+}
+
+// UpdateMetadata calls UpdateMetadataFunc
+func (w *Workflows) UpdateMetadata(workflowID string, req nanonets.UpdateMetadataRequest) error {
+	w.record("UpdateMetadata", workflowID, req)
+	if w.UpdateMetadataFunc == nil {
+		return ErrNotStubbed
+	}
+	return w.UpdateMetadataFunc(workflowID, req)
+}
+
+// UpdateSettings calls UpdateSettingsFunc
+func (w *Workflows) UpdateSettings(workflowID string, req nanonets.UpdateSettingsRequest) error {
+	w.record("UpdateSettings", workflowID, req)
+	if w.UpdateSettingsFunc == nil {
+		return ErrNotStubbed
+	}
+	return w.UpdateSettingsFunc(workflowID, req)
+}
+
+// GetTypes calls GetTypesFunc
+func (w *Workflows) GetTypes() ([]nanonets.WorkflowType, error) {
+	w.record("GetTypes")
+	if w.GetTypesFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return w.GetTypesFunc()
+}
+
+// Export calls ExportFunc, or nanonets.ExportWorkflow if it is nil
+func (w *Workflows) Export(workflowID string, out io.Writer) error {
+	w.record("Export", workflowID, out)
+	if w.ExportFunc == nil {
+		return nanonets.ExportWorkflow(w, workflowID, out)
+	}
+	return w.ExportFunc(workflowID, out)
+}
+
+// Import calls ImportFunc, or nanonets.ImportWorkflow if it is nil
+func (w *Workflows) Import(in io.Reader) (*nanonets.Workflow, error) {
+	w.record("Import", in)
+	if w.ImportFunc == nil {
+		return nanonets.ImportWorkflow(w, in)
+	}
+	return w.ImportFunc(in)
+}
+
+// Clone calls CloneFunc, or nanonets.CloneWorkflow if it is nil
+func (w *Workflows) Clone(workflowID string, target *nanonets.Client) (*nanonets.CloneResult, error) {
+	w.record("Clone", workflowID, target)
+	if w.CloneFunc == nil {
+		return nanonets.CloneWorkflow(w, target.Workflows, workflowID)
+	}
+	return w.CloneFunc(workflowID, target)
+}
+
+// Plan calls PlanFunc, or nanonets.PlanWorkflow if it is nil
+func (w *Workflows) Plan(workflowID string, spec nanonets.WorkflowSpec) (*nanonets.Plan, error) {
+	w.record("Plan", workflowID, spec)
+	if w.PlanFunc == nil {
+		return nanonets.PlanWorkflow(w, workflowID, spec)
+	}
+	return w.PlanFunc(workflowID, spec)
+}
+
+// Apply calls ApplyFunc, or nanonets.ApplyPlan if it is nil
+func (w *Workflows) Apply(plan *nanonets.Plan, opts nanonets.ApplyOptions) (*nanonets.Workflow, error) {
+	w.record("Apply", plan, opts)
+	if w.ApplyFunc == nil {
+		return nanonets.ApplyPlan(w, plan, opts)
+	}
+	return w.ApplyFunc(plan, opts)
+}
+
+// Documents is a stub of the documents API. Each method records the call and
+// runs the matching function field, or returns ErrNotStubbed if it is nil.
+type Documents struct {
+	recorder
+
+	UploadFunc             func(workflowID string, req nanonets.UploadDocumentRequest) (*nanonets.Document, error)
+	UploadFromURLFunc      func(workflowID string, req nanonets.UploadDocumentFromURLRequest) (*nanonets.Document, error)
+	GetFunc                func(workflowID, documentID string) (*nanonets.Document, error)
+	ListFunc               func(workflowID string) ([]nanonets.Document, error)
+	ListWithPaginationFunc func(workflowID string, page, limit int) ([]nanonets.Document, error)
+	DeleteFunc             func(workflowID, documentID string) error
+	GetFieldsFunc          func(workflowID, documentID string) ([]nanonets.Field, error)
+	GetTablesFunc          func(workflowID, documentID string) ([]nanonets.Table, error)
+	GetOriginalFileFunc    func(workflowID, documentID string) ([]byte, error)
+}
+
+// Upload calls UploadFunc
+func (d *Documents) Upload(workflowID string, req nanonets.UploadDocumentRequest) (*nanonets.Document, error) {
+	d.record("Upload", workflowID, req)
+	if d.UploadFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return d.UploadFunc(workflowID, req)
+}
+
+// UploadFromURL calls UploadFromURLFunc
+func (d *Documents) UploadFromURL(workflowID string, req nanonets.UploadDocumentFromURLRequest) (*nanonets.Document, error) {
+	d.record("UploadFromURL", workflowID, req)
+	if d.UploadFromURLFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return d.UploadFromURLFunc(workflowID, req)
+}
+
+// Get calls GetFunc
+func (d *Documents) Get(workflowID, documentID string) (*nanonets.Document, error) {
+	d.record("Get", workflowID, documentID)
+	if d.GetFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return d.GetFunc(workflowID, documentID)
+}
+
+// List calls ListFunc
+func (d *Documents) List(workflowID string) ([]nanonets.Document, error) {
+	d.record("List", workflowID)
+	if d.ListFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return d.ListFunc(workflowID)
+}
+
+// ListWithPagination calls ListWithPaginationFunc
+func (d *Documents) ListWithPagination(workflowID string, page, limit int) ([]nanonets.Document, error) {
+	d.record("ListWithPagination", workflowID, page, limit)
+	if d.ListWithPaginationFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return d.ListWithPaginationFunc(workflowID, page, limit)
+}
+
+// Delete calls DeleteFunc
+func (d *Documents) Delete(workflowID, documentID string) error {
+	d.record("Delete", workflowID, documentID)
+	if d.DeleteFunc == nil {
+		return ErrNotStubbed
+	}
+	return d.DeleteFunc(workflowID, documentID)
+}
+
+// GetFields calls GetFieldsFunc
+func (d *Documents) GetFields(workflowID, documentID string) ([]nanonets.Field, error) {
+	d.record("GetFields", workflowID, documentID)
+	if d.GetFieldsFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return d.GetFieldsFunc(workflowID, documentID)
+}
+
+// GetTables calls GetTablesFunc
+func (d *Documents) GetTables(workflowID, documentID string) ([]nanonets.Table, error) {
+	d.record("GetTables", workflowID, documentID)
+	if d.GetTablesFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return d.GetTablesFunc(workflowID, documentID)
+}
+
+// GetOriginalFile calls GetOriginalFileFunc
+func (d *Documents) GetOriginalFile(workflowID, documentID string) ([]byte, error) {
+	d.record("GetOriginalFile", workflowID, documentID)
+	if d.GetOriginalFileFunc == nil {
+		return nil, ErrNotStubbed
+	}
+	return d.GetOriginalFileFunc(workflowID, documentID)
+}
+
+// Moderation is a stub of the moderation API. Each method records the call and
+// runs the matching function field, or returns ErrNotStubbed if it is nil.
+// AutoVerify falls back to the SDK's implementation instead, reading the
+// document through Documents.
+type Moderation struct {
+	recorder
+
+	// Documents serves the document read by AutoVerify; NewClient sets it
+	// to the client's Documents stub
+	Documents nanonets.DocumentsAPI
+
+	UpdateFieldFunc     func(workflowID, documentID, pageID, fieldDataID string, req nanonets.UpdateFieldRequest) error
+	AddFieldFunc        func(workflowID, documentID, pageID string, req nanonets.AddFieldRequest) error
+	DeleteFieldFunc     func(workflowID, documentID, pageID, fieldDataID string) error
+	AddTableFunc        func(workflowID, documentID, pageID string, req nanonets.AddTableRequest) error
+	DeleteTableFunc     func(workflowID, documentID, pageID, tableID string) error
+	UpdateTableCellFunc func(workflowID, documentID, pageID, tableID, cellID string, req nanonets.UpdateTableCellRequest) error
+	AddTableCellFunc    func(workflowID, documentID, pageID, tableID string, req nanonets.AddTableCellRequest) error
+	DeleteTableCellFunc func(workflowID, documentID, pageID, tableID, cellID string) error
+	VerifyFieldFunc     func(workflowID, documentID, pageID, fieldDataID string, req nanonets.VerifyFieldRequest) error
+	VerifyTableCellFunc func(workflowID, documentID, pageID, tableID, cellID string, req nanonets.VerifyTableCellRequest) error
+	VerifyTableFunc     func(workflowID, documentID, pageID, tableID string, req nanonets.VerifyTableRequest) error
+	VerifyDocumentFunc  func(workflowID, documentID string, req nanonets.VerifyDocumentRequest) error
+	AutoVerifyFunc      func(ctx context.Context, workflowID, documentID string, policy nanonets.AutoVerifyPolicy) (*nanonets.AutoVerifyResult, error)
+}
+
+// UpdateField calls UpdateFieldFunc
+func (m *Moderation) UpdateField(workflowID, documentID, pageID, fieldDataID string, req nanonets.UpdateFieldRequest) error {
+	m.record("UpdateField", workflowID, documentID, pageID, fieldDataID, req)
+	if m.UpdateFieldFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.UpdateFieldFunc(workflowID, documentID, pageID, fieldDataID, req)
+}
+
+// AddField calls AddFieldFunc
+func (m *Moderation) AddField(workflowID, documentID, pageID string, req nanonets.AddFieldRequest) error {
+	m.record("AddField", workflowID, documentID, pageID, req)
+	if m.AddFieldFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.AddFieldFunc(workflowID, documentID, pageID, req)
+}
+
+// DeleteField calls DeleteFieldFunc
+func (m *Moderation) DeleteField(workflowID, documentID, pageID, fieldDataID string) error {
+	m.record("DeleteField", workflowID, documentID, pageID, fieldDataID)
+	if m.DeleteFieldFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.DeleteFieldFunc(workflowID, documentID, pageID, fieldDataID)
+}
+
+// AddTable calls AddTableFunc
+func (m *Moderation) AddTable(workflowID, documentID, pageID string, req nanonets.AddTableRequest) error {
+	m.record("AddTable", workflowID, documentID, pageID, req)
+	if m.AddTableFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.AddTableFunc(workflowID, documentID, pageID, req)
+}
+
+// DeleteTable calls DeleteTableFunc
+func (m *Moderation) DeleteTable(workflowID, documentID, pageID, tableID string) error {
+	m.record("DeleteTable", workflowID, documentID, pageID, tableID)
+	if m.DeleteTableFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.DeleteTableFunc(workflowID, documentID, pageID, tableID)
+}
+
+// UpdateTableCell calls UpdateTableCellFunc
+func (m *Moderation) UpdateTableCell(workflowID, documentID, pageID, tableID, cellID string, req nanonets.UpdateTableCellRequest) error {
+	m.record("UpdateTableCell", workflowID, documentID, pageID, tableID, cellID, req)
+	if m.UpdateTableCellFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.UpdateTableCellFunc(workflowID, documentID, pageID, tableID, cellID, req)
+}
+
+// AddTableCell calls AddTableCellFunc
+func (m *Moderation) AddTableCell(workflowID, documentID, pageID, tableID string, req nanonets.AddTableCellRequest) error {
+	m.record("AddTableCell", workflowID, documentID, pageID, tableID, req)
+	if m.AddTableCellFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.AddTableCellFunc(workflowID, documentID, pageID, tableID, req)
+}
+
+// DeleteTableCell calls DeleteTableCellFunc
+func (m *Moderation) DeleteTableCell(workflowID, documentID, pageID, tableID, cellID string) error {
+	m.record("DeleteTableCell", workflowID, documentID, pageID, tableID, cellID)
+	if m.DeleteTableCellFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.DeleteTableCellFunc(workflowID, documentID, pageID, tableID, cellID)
+}
+
+// VerifyField calls VerifyFieldFunc
+func (m *Moderation) VerifyField(workflowID, documentID, pageID, fieldDataID string, req nanonets.VerifyFieldRequest) error {
+	m.record("VerifyField", workflowID, documentID, pageID, fieldDataID, req)
+	if m.VerifyFieldFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.VerifyFieldFunc(workflowID, documentID, pageID, fieldDataID, req)
+}
+
+// VerifyTableCell calls VerifyTableCellFunc
+func (m *Moderation) VerifyTableCell(workflowID, documentID, pageID, tableID, cellID string, req nanonets.VerifyTableCellRequest) error {
+	m.record("VerifyTableCell", workflowID, documentID, pageID, tableID, cellID, req)
+	if m.VerifyTableCellFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.VerifyTableCellFunc(workflowID, documentID, pageID, tableID, cellID, req)
+}
+
+// VerifyTable calls VerifyTableFunc
+func (m *Moderation) VerifyTable(workflowID, documentID, pageID, tableID string, req nanonets.VerifyTableRequest) error {
+	m.record("VerifyTable", workflowID, documentID, pageID, tableID, req)
+	if m.VerifyTableFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.VerifyTableFunc(workflowID, documentID, pageID, tableID, req)
+}
+
+// VerifyDocument calls VerifyDocumentFunc
+func (m *Moderation) VerifyDocument(workflowID, documentID string, req nanonets.VerifyDocumentRequest) error {
+	m.record("VerifyDocument", workflowID, documentID, req)
+	if m.VerifyDocumentFunc == nil {
+		return ErrNotStubbed
+	}
+	return m.VerifyDocumentFunc(workflowID, documentID, req)
+}
+
+// AutoVerify calls AutoVerifyFunc, or nanonets.AutoVerifyDocument if it is
+// nil and Documents is set
+func (m *Moderation) AutoVerify(ctx context.Context, workflowID, documentID string, policy nanonets.AutoVerifyPolicy) (*nanonets.AutoVerifyResult, error) {
+	m.record("AutoVerify", ctx, workflowID, documentID, policy)
+	if m.AutoVerifyFunc == nil {
+		if m.Documents == nil {
+			return nil, ErrNotStubbed
+		}
+		return nanonets.AutoVerifyDocument(ctx, m.Documents, m, workflowID, documentID, policy)
+	}
+	return m.AutoVerifyFunc(ctx, workflowID, documentID, policy)
+}
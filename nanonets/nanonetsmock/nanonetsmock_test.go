@@ -0,0 +1,142 @@
+package nanonetsmock_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetsmock"
+)
+
+func TestCallRecording(t *testing.T) {
+	client, workflows, documents, _ := nanonetsmock.NewClient()
+	workflows.GetFunc = func(id string) (*nanonets.Workflow, error) {
+		return &nanonets.Workflow{ID: id, Description: "Invoices"}, nil
+	}
+
+	wf, err := client.Workflows.Get("wf_1")
+	if err != nil || wf.Description != "Invoices" {
+		t.Fatalf("Get = %+v, %v; want the stubbed workflow", wf, err)
+	}
+	client.Workflows.Get("wf_2")
+	client.Workflows.List()
+	client.Documents.ListWithPagination("wf_1", 2, 10)
+
+	want := []nanonetsmock.Call{
+		{Method: "Get", Args: []interface{}{"wf_1"}},
+		{Method: "Get", Args: []interface{}{"wf_2"}},
+		{Method: "List", Args: nil},
+	}
+	if got := workflows.Calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Calls = %+v, want %+v", got, want)
+	}
+	if got := workflows.CallsTo("Get"); len(got) != 2 || got[1].Args[0] != "wf_2" {
+		t.Errorf("CallsTo(Get) = %+v", got)
+	}
+	if got := documents.CallsTo("ListWithPagination"); len(got) != 1 || !reflect.DeepEqual(got[0].Args, []interface{}{"wf_1", 2, 10}) {
+		t.Errorf("documents calls %+v", got)
+	}
+
+	workflows.Reset()
+	if got := workflows.Calls(); len(got) != 0 {
+		t.Errorf("after Reset: %+v", got)
+	}
+}
+
+func TestNotStubbed(t *testing.T) {
+	client, workflows, documents, moderation := nanonetsmock.NewClient()
+	calls := map[string]error{
+		"Workflows.Create": func() error {
+			_, err := client.Workflows.Create(nanonets.CreateWorkflowRequest{})
+			return err
+		}(),
+		"Documents.Get": func() error {
+			_, err := client.Documents.Get("wf_1", "doc_1")
+			return err
+		}(),
+		"Moderation.VerifyDocument": client.Moderation.VerifyDocument("wf_1", "doc_1", nanonets.VerifyDocumentRequest{}),
+	}
+	for name, err := range calls {
+		if !errors.Is(err, nanonetsmock.ErrNotStubbed) {
+			t.Errorf("%s: err = %v, want ErrNotStubbed", name, err)
+		}
+	}
+	// Calls are recorded whether or not they were stubbed
+	if len(workflows.Calls()) != 1 || len(documents.Calls()) != 1 || len(moderation.Calls()) != 1 {
+		t.Errorf("recorded %d, %d and %d calls, want one each", len(workflows.Calls()), len(documents.Calls()), len(moderation.Calls()))
+	}
+}
+
+func TestExportFallsBackToSDK(t *testing.T) {
+	client, workflows, _, _ := nanonetsmock.NewClient()
+
+	var buf bytes.Buffer
+	if err := client.Workflows.Export("wf_1", &buf); !errors.Is(err, nanonetsmock.ErrNotStubbed) {
+		t.Errorf("Export without Get stubbed: err = %v, want ErrNotStubbed", err)
+	}
+
+	workflows.GetFunc = func(id string) (*nanonets.Workflow, error) {
+		return &nanonets.Workflow{ID: id, Description: "Invoices"}, nil
+	}
+	if err := client.Workflows.Export("wf_1", &buf); err != nil {
+		t.Fatal(err)
+	}
+	var export nanonets.WorkflowExport
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil || export.SourceID != "wf_1" || export.Description != "Invoices" {
+		t.Errorf("export %+v, %v; want the stubbed workflow", export, err)
+	}
+	if got := workflows.CallsTo("Get"); len(got) != 2 {
+		t.Errorf("%d Get calls, want Export to go through the stub", len(got))
+	}
+
+	// A stubbed Export replaces the SDK's implementation
+	workflows.Reset()
+	workflows.ExportFunc = func(string, io.Writer) error { return nil }
+	if err := client.Workflows.Export("wf_1", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := workflows.CallsTo("Get"); len(got) != 0 {
+		t.Errorf("stubbed Export called Get %d times", len(got))
+	}
+}
+
+func TestAutoVerifyFallsBackToSDK(t *testing.T) {
+	client, _, documents, moderation := nanonetsmock.NewClient()
+	documents.GetFunc = func(workflowID, documentID string) (*nanonets.Document, error) {
+		return &nanonets.Document{DocumentID: documentID, Pages: []nanonets.Page{{
+			PageID: "p1",
+			Data: nanonets.PageData{Fields: map[string][]nanonets.FieldData{
+				"invoice_number": {{FieldDataID: "fd_1", Value: "INV-1", Confidence: 0.99}},
+				"vendor":         {{FieldDataID: "fd_2", Value: "Acme", Confidence: 0.4}},
+			}},
+		}}}, nil
+	}
+	var verified []string
+	moderation.VerifyFieldFunc = func(workflowID, documentID, pageID, fieldDataID string, req nanonets.VerifyFieldRequest) error {
+		verified = append(verified, fieldDataID+" "+string(req.VerificationStatus))
+		return nil
+	}
+
+	result, err := client.Moderation.AutoVerify(context.Background(), "wf_1", "doc_1", nanonets.AutoVerifyPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Verified()) != 1 || len(result.Flagged()) != 1 {
+		t.Errorf("%d verified and %d flagged, want 1 and 1", len(result.Verified()), len(result.Flagged()))
+	}
+	want := []string{"fd_1 verified", "fd_2 unverified"}
+	if !reflect.DeepEqual(verified, want) {
+		t.Errorf("VerifyField calls %v, want %v", verified, want)
+	}
+
+	// Without a Documents stub there is nothing to read the document from
+	_, err = (&nanonetsmock.Moderation{}).AutoVerify(context.Background(), "wf_1", "doc_1", nanonets.AutoVerifyPolicy{})
+	if !errors.Is(err, nanonetsmock.ErrNotStubbed) {
+		t.Errorf("AutoVerify without Documents: err = %v, want ErrNotStubbed", err)
+	}
+}
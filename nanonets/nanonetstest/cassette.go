@@ -0,0 +1,373 @@
+package nanonetstest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// CassetteVersion is the version of the cassette format written by
+// Recorder
+const CassetteVersion = 1
+
+// Scrubbed replaces secrets in recorded interactions
+const Scrubbed = "[scrubbed]"
+
+// ErrNoInteraction is returned by a replaying Recorder when a request has
+// no matching recorded interaction
+var ErrNoInteraction = errors.New("nanonetstest: no matching interaction in cassette")
+
+// Mode selects whether a Recorder records or replays
+type Mode int
+
+// Recorder modes
+const (
+	// ModeReplay serves responses from the cassette without network access
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the real API and records them
+	ModeRecord
+)
+
+// Matching selects how a replaying Recorder matches requests
+type Matching int
+
+// Matching modes
+const (
+	// MatchStrict requires requests to arrive in the recorded order with
+	// the same method, path, query and body
+	MatchStrict Matching = iota
+	// MatchLenient matches any unused interaction with the same method and
+	// path, preferring one with an equivalent body. Once every match has
+	// been used, the last one is replayed again, which suits polling.
+	MatchLenient
+)
+
+// RecordedRequest is the scrubbed form of a request
+type RecordedRequest struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Query       string `json:"query,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body,omitempty"`
+}
+
+// RecordedResponse is the scrubbed form of a response. Binary bodies are
+// base64 encoded.
+type RecordedResponse struct {
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is a sequence of recorded interactions
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a cassette file
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("reading cassette %s: %w", path, err)
+	}
+	if c.Version != CassetteVersion {
+		return nil, fmt.Errorf("cassette %s: unsupported version %d", path, c.Version)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to a file, creating its directory if needed
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Recorder is an http.RoundTripper that records interactions with the API
+// to a cassette file, or replays them from one. While recording, the
+// Authorization header is dropped, the API key is replaced wherever it
+// appears, and uploaded and downloaded document bytes are replaced by
+// their size and hash.
+type Recorder struct {
+	// Transport sends requests while recording; nil uses
+	// http.DefaultTransport
+	Transport http.RoundTripper
+	// Matching selects how requests are matched while replaying
+	Matching Matching
+	// Scrub, if set, is called on each interaction before it is recorded
+	Scrub func(*Interaction)
+
+	path     string
+	mode     Mode
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+	next     int
+}
+
+// NewRecorder creates a recorder for a cassette file. In ModeReplay the
+// cassette is loaded immediately; in ModeRecord it is written by Stop.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, cassette: &Cassette{Version: CassetteVersion}}
+	if mode == ModeReplay {
+		c, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	}
+	return r, nil
+}
+
+// Client returns an http.Client that uses the recorder, for use as
+// nanonets.Client.Client
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop saves the cassette when recording
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	apiKey, _, _ := req.BasicAuth()
+	recorded := recordRequest(req, body, apiKey)
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{Request: recorded, Response: recordResponse(resp, respBody, apiKey)}
+	if r.Scrub != nil {
+		r.Scrub(&interaction)
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := -1
+	switch r.Matching {
+	case MatchStrict:
+		if r.next < len(r.cassette.Interactions) && reflect.DeepEqual(r.cassette.Interactions[r.next].Request, recorded) {
+			index = r.next
+			r.next++
+		}
+	case MatchLenient:
+		last := -1
+		for i, in := range r.cassette.Interactions {
+			if in.Request.Method != recorded.Method || in.Request.Path != recorded.Path {
+				continue
+			}
+			last = i
+			if r.used[i] {
+				continue
+			}
+			if index < 0 || (!sameBody(r.cassette.Interactions[index].Request, recorded) && sameBody(in.Request, recorded)) {
+				index = i
+			}
+		}
+		if index < 0 {
+			index = last
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.Path)
+	}
+	r.used[index] = true
+
+	rec := r.cassette.Interactions[index].Response
+	body := []byte(rec.Body)
+	if rec.BodyEncoding == "base64" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(rec.Body); err != nil {
+			return nil, err
+		}
+	}
+	header := rec.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// sameBody reports whether two requests have equivalent bodies, comparing
+// JSON bodies by value
+func sameBody(a, b RecordedRequest) bool {
+	if a.Body == b.Body {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a.Body), &va) != nil || json.Unmarshal([]byte(b.Body), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// recordRequest returns the scrubbed form of a request. Multipart bodies
+// are written as their sorted form fields, since the boundary changes
+// with every request.
+func recordRequest(req *http.Request, body []byte, apiKey string) RecordedRequest {
+	rec := RecordedRequest{
+		Method: req.Method,
+		Path:   scrubKey(req.URL.Path, apiKey),
+		Query:  scrubKey(req.URL.RawQuery, apiKey),
+	}
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	rec.ContentType = mediaType
+	if mediaType == "multipart/form-data" {
+		rec.Body = scrubKey(multipartSummary(body, params["boundary"]), apiKey)
+		return rec
+	}
+	if len(body) > 0 {
+		rec.Body = scrubKey(string(body), apiKey)
+	}
+	return rec
+}
+
+// multipartSummary lists the fields of a multipart body, one per line,
+// with file contents replaced by their size and hash
+func multipartSummary(body []byte, boundary string) string {
+	var lines []string
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		value, _ := io.ReadAll(part)
+		if part.FileName() != "" {
+			lines = append(lines, fmt.Sprintf("%s=@%s %s", part.FormName(), part.FileName(), digest(value)))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s=%s", part.FormName(), value))
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// recordResponse returns the scrubbed form of a response. Document
+// downloads are replaced by their size and hash.
+func recordResponse(resp *http.Response, body []byte, apiKey string) RecordedResponse {
+	rec := RecordedResponse{Status: resp.StatusCode, Header: make(http.Header)}
+	for _, key := range []string{"Content-Type", "Retry-After", "Etag", "Last-Modified", "Cache-Control"} {
+		if v := resp.Header.Get(key); v != "" {
+			rec.Header.Set(key, scrubKey(v, apiKey))
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case len(body) == 0:
+	case isDocument(mediaType, body):
+		rec.Body = Scrubbed + " " + digest(body)
+	case utf8.Valid(body):
+		rec.Body = scrubKey(string(body), apiKey)
+	default:
+		rec.Body = base64.StdEncoding.EncodeToString(body)
+		rec.BodyEncoding = "base64"
+	}
+	return rec
+}
+
+// isDocument reports whether a response body is a document or image
+// rather than an API payload
+func isDocument(mediaType string, body []byte) bool {
+	switch {
+	case mediaType == "application/json", strings.HasPrefix(mediaType, "text/"):
+		return false
+	case mediaType == "application/pdf", strings.HasPrefix(mediaType, "image/"), mediaType == "application/octet-stream":
+		return true
+	}
+	return !utf8.Valid(body)
+}
+
+func digest(data []byte) string {
+	return fmt.Sprintf("(%d bytes, sha256 %x)", len(data), sha256.Sum256(data))
+}
+
+func scrubKey(s, apiKey string) string {
+	if apiKey == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, apiKey, Scrubbed)
+}
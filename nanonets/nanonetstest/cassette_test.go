@@ -0,0 +1,172 @@
+package nanonetstest_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+// documentBytes stand in for an uploaded document that must not end up in
+// a cassette
+var documentBytes = []byte("%PDF-1.7 confidential invoice")
+
+// session is a sequence of SDK calls recorded in a cassette
+type session struct {
+	workflowID string
+	file       string
+}
+
+func (s session) get(c *nanonets.Client) error {
+	_, err := c.Workflows.Get(s.workflowID)
+	return err
+}
+
+func (s session) upload(c *nanonets.Client) error {
+	_, err := c.Documents.Upload(s.workflowID, nanonets.UploadDocumentRequest{File: s.file})
+	return err
+}
+
+func (s session) list(c *nanonets.Client) error {
+	_, err := c.Documents.List(s.workflowID)
+	return err
+}
+
+// record runs get, upload and list against a fake server and returns the
+// cassette path, the session and the base URL the calls were made to
+func record(t *testing.T) (string, session, string) {
+	t.Helper()
+	srv := nanonetstest.NewServer()
+	defer srv.Close()
+	// The API key and a customer name show up in response bodies, so
+	// scrubbing can be checked
+	wf := srv.AddWorkflow(nanonets.Workflow{Description: "Acme Ltd invoices " + srv.APIKey})
+
+	dir := t.TempDir()
+	sess := session{workflowID: wf.ID, file: filepath.Join(dir, "invoice.pdf")}
+	if err := os.WriteFile(sess.file, documentBytes, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "cassettes", "session.json")
+	rec, err := nanonetstest.NewRecorder(path, nanonetstest.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Transport = srv.Server.Client().Transport
+	rec.Scrub = func(in *nanonetstest.Interaction) {
+		in.Response.Body = strings.ReplaceAll(in.Response.Body, "Acme Ltd", "Customer")
+	}
+	c := srv.Client()
+	c.Client = rec.Client()
+	for _, call := range []func(*nanonets.Client) error{sess.get, sess.upload, sess.list} {
+		if err := call(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	return path, sess, srv.BaseURL()
+}
+
+// replayClient returns a client that replays the cassette at path
+func replayClient(t *testing.T, path, baseURL string, matching nanonetstest.Matching) *nanonets.Client {
+	t.Helper()
+	rec, err := nanonetstest.NewRecorder(path, nanonetstest.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Matching = matching
+	c := nanonets.NewClient(nanonetstest.DefaultAPIKey)
+	c.BaseURL = baseURL
+	c.Client = rec.Client()
+	return c
+}
+
+func TestRecorderScrubs(t *testing.T) {
+	path, _, _ := record(t)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(nanonetstest.DefaultAPIKey)) {
+		t.Error("cassette contains the API key")
+	}
+	if !bytes.Contains(data, []byte("Customer invoices "+nanonetstest.Scrubbed)) {
+		t.Error("API key and customer name in the response body were not replaced")
+	}
+	if bytes.Contains(data, documentBytes) {
+		t.Error("cassette contains the uploaded document")
+	}
+	if bytes.Contains(data, []byte("Authorization")) {
+		t.Error("cassette contains the Authorization header")
+	}
+}
+
+func TestRecorderStrictReplay(t *testing.T) {
+	path, sess, baseURL := record(t)
+
+	c := replayClient(t, path, baseURL, nanonetstest.MatchStrict)
+	wf, err := c.Workflows.Get(sess.workflowID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wf.ID != sess.workflowID {
+		t.Errorf("replayed workflow %s, want %s", wf.ID, sess.workflowID)
+	}
+	if err := sess.upload(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.list(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := sess.list(c); !errors.Is(err, nanonetstest.ErrNoInteraction) {
+		t.Errorf("call beyond the cassette: %v, want ErrNoInteraction", err)
+	}
+
+	// Requests must arrive in the recorded order
+	c = replayClient(t, path, baseURL, nanonetstest.MatchStrict)
+	if err := sess.list(c); !errors.Is(err, nanonetstest.ErrNoInteraction) {
+		t.Errorf("out-of-order call: %v, want ErrNoInteraction", err)
+	}
+
+	// and with the same body
+	other := filepath.Join(t.TempDir(), "invoice.pdf")
+	if err := os.WriteFile(other, []byte("%PDF-1.7 another invoice"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c = replayClient(t, path, baseURL, nanonetstest.MatchStrict)
+	if err := sess.get(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := (session{workflowID: sess.workflowID, file: other}).upload(c); !errors.Is(err, nanonetstest.ErrNoInteraction) {
+		t.Errorf("upload of another file: %v, want ErrNoInteraction", err)
+	}
+}
+
+func TestRecorderLenientReplay(t *testing.T) {
+	path, sess, baseURL := record(t)
+
+	c := replayClient(t, path, baseURL, nanonetstest.MatchLenient)
+	for _, call := range []func(*nanonets.Client) error{sess.list, sess.upload, sess.get, sess.get, sess.list} {
+		if err := call(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Lenient matching only needs the method and path
+	other := filepath.Join(t.TempDir(), "invoice.pdf")
+	if err := os.WriteFile(other, []byte("%PDF-1.7 another invoice"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (session{workflowID: sess.workflowID, file: other}).upload(c); err != nil {
+		t.Errorf("upload of another file: %v", err)
+	}
+	if _, err := c.Documents.Get(sess.workflowID, "doc_missing"); !errors.Is(err, nanonetstest.ErrNoInteraction) {
+		t.Errorf("unrecorded path: %v, want ErrNoInteraction", err)
+	}
+}
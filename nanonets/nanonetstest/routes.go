@@ -0,0 +1,635 @@
+package nanonetstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+// match reports whether the path segments match a pattern in which "*"
+// matches any segment, and returns the segments matched by "*"
+func match(segs []string, pattern ...string) ([]string, bool) {
+	if len(segs) != len(pattern) {
+		return nil, false
+	}
+	var params []string
+	for i, p := range pattern {
+		if p == "*" {
+			params = append(params, segs[i])
+		} else if p != segs[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// route handles a request with s.mu held. It returns the status and the
+// value to encode as JSON, raw bytes, or an error message for statuses of
+// 400 and above.
+func (s *Server) route(r *http.Request, p string, body []byte) (int, interface{}) {
+	segs := strings.Split(strings.Trim(p, "/"), "/")
+	m := r.Method
+
+	if _, ok := match(segs, "workflows"); ok {
+		switch m {
+		case http.MethodGet:
+			out := make([]*nanonets.Workflow, 0, len(s.workflowIDs))
+			for _, id := range s.workflowIDs {
+				out = append(out, s.workflows[id])
+			}
+			return http.StatusOK, out
+		case http.MethodPost:
+			var req nanonets.CreateWorkflowRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				return http.StatusBadRequest, err
+			}
+			wf := &nanonets.Workflow{Description: req.Description, WorkflowType: req.WorkflowType}
+			s.addWorkflow(wf)
+			return http.StatusCreated, wf
+		}
+		return http.StatusMethodNotAllowed, "method not allowed"
+	}
+	if _, ok := match(segs, "workflows", "types"); ok && m == http.MethodGet {
+		return http.StatusOK, s.types
+	}
+	if len(segs) < 2 || segs[0] != "workflows" {
+		return http.StatusNotFound, "not found"
+	}
+	wf, ok := s.workflows[segs[1]]
+	if !ok {
+		return http.StatusNotFound, "workflow not found"
+	}
+
+	if _, ok := match(segs, "workflows", "*"); ok {
+		switch m {
+		case http.MethodGet:
+			return http.StatusOK, wf
+		case http.MethodPatch:
+			var req nanonets.UpdateMetadataRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				return http.StatusBadRequest, err
+			}
+			wf.Description = req.Description
+			wf.UpdatedAt = nanonets.Time{Time: time.Now().UTC()}
+			return http.StatusOK, wf
+		}
+		return http.StatusMethodNotAllowed, "method not allowed"
+	}
+	if _, ok := match(segs, "workflows", "*", "settings"); ok && m == http.MethodPatch {
+		return s.updateSettings(wf, body)
+	}
+	if _, ok := match(segs, "workflows", "*", "fields"); ok && m == http.MethodPut {
+		return s.setFields(wf, body)
+	}
+	if params, ok := match(segs, "workflows", "*", "fields", "*"); ok {
+		return s.workflowField(m, wf, params[1], body)
+	}
+	if _, ok := match(segs, "workflows", "*", "documents"); ok {
+		switch m {
+		case http.MethodGet:
+			return s.listDocuments(wf.ID, r)
+		case http.MethodPost:
+			return s.upload(wf.ID, r, body)
+		}
+		return http.StatusMethodNotAllowed, "method not allowed"
+	}
+
+	if len(segs) < 4 || segs[2] != "documents" {
+		return http.StatusNotFound, "not found"
+	}
+	entry, ok := s.documents[segs[3]]
+	if !ok || entry.workflowID != wf.ID {
+		return http.StatusNotFound, "document not found"
+	}
+	doc := s.view(entry)
+	rest := segs[4:]
+
+	switch {
+	case len(rest) == 0 && m == http.MethodGet:
+		return http.StatusOK, doc
+	case len(rest) == 0 && m == http.MethodDelete:
+		delete(s.documents, doc.DocumentID)
+		return http.StatusOK, map[string]string{"message": "deleted"}
+	case len(rest) == 1 && rest[0] == "fields" && m == http.MethodGet:
+		return http.StatusOK, documentFields(doc)
+	case len(rest) == 1 && rest[0] == "tables" && m == http.MethodGet:
+		tables := []nanonets.Table{}
+		for _, page := range doc.Pages {
+			tables = append(tables, page.Data.Tables...)
+		}
+		return http.StatusOK, tables
+	case len(rest) == 1 && rest[0] == "original" && m == http.MethodGet:
+		if entry.original == nil {
+			return http.StatusNotFound, "original file not available"
+		}
+		return http.StatusOK, entry.original
+	case len(rest) == 1 && rest[0] == "verify" && m == http.MethodPost:
+		var req nanonets.VerifyDocumentRequest
+		if status, err := decodeVerify(body, &req, &req.VerificationStatus); err != nil {
+			return status, err
+		}
+		doc.VerificationStatus = req.VerificationStatus
+		doc.VerificationMessage = req.VerificationMessage
+		return http.StatusOK, doc
+	}
+
+	if len(rest) < 3 || rest[0] != "pages" {
+		return http.StatusNotFound, "not found"
+	}
+	var page *nanonets.Page
+	for i := range doc.Pages {
+		if doc.Pages[i].PageID == rest[1] {
+			page = &doc.Pages[i]
+		}
+	}
+	if page == nil {
+		return http.StatusNotFound, "page not found"
+	}
+	if rest[2] == "fields" {
+		return s.pageField(m, page, rest[3:], body)
+	}
+	if rest[2] == "tables" {
+		return s.pageTable(m, page, rest[3:], body)
+	}
+	return http.StatusNotFound, "not found"
+}
+
+func (s *Server) updateSettings(wf *nanonets.Workflow, body []byte) (int, interface{}) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return http.StatusBadRequest, err
+	}
+	current, _ := json.Marshal(wf.Settings)
+	var all map[string]json.RawMessage
+	json.Unmarshal(current, &all)
+	if all == nil {
+		all = make(map[string]json.RawMessage)
+	}
+	for key, value := range patch {
+		all[key] = value
+	}
+	merged, _ := json.Marshal(all)
+	var settings nanonets.WorkflowSettings
+	if err := json.Unmarshal(merged, &settings); err != nil {
+		return http.StatusBadRequest, err
+	}
+	wf.Settings = settings
+	wf.UpdatedAt = nanonets.Time{Time: time.Now().UTC()}
+	return http.StatusOK, wf.Settings
+}
+
+func (s *Server) setFields(wf *nanonets.Workflow, body []byte) (int, interface{}) {
+	var req nanonets.SetFieldsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	wf.Fields = []nanonets.Field{}
+	for _, f := range req.Fields {
+		if f.ID == "" {
+			f.ID = s.newID("field")
+		}
+		wf.Fields = append(wf.Fields, f)
+	}
+	wf.TableHeaders = []nanonets.TableHeader{}
+	for _, h := range req.TableHeaders {
+		if h.ID == "" {
+			h.ID = s.newID("header")
+		}
+		wf.TableHeaders = append(wf.TableHeaders, h)
+	}
+	wf.UpdatedAt = nanonets.Time{Time: time.Now().UTC()}
+	return http.StatusOK, wf
+}
+
+func (s *Server) workflowField(m string, wf *nanonets.Workflow, fieldID string, body []byte) (int, interface{}) {
+	field, header := -1, -1
+	for i, f := range wf.Fields {
+		if f.ID == fieldID {
+			field = i
+		}
+	}
+	for i, h := range wf.TableHeaders {
+		if h.ID == fieldID {
+			header = i
+		}
+	}
+	if field < 0 && header < 0 {
+		return http.StatusNotFound, "field not found"
+	}
+
+	switch m {
+	case http.MethodPatch:
+		var req nanonets.UpdateFieldRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return http.StatusBadRequest, err
+		}
+		wf.UpdatedAt = nanonets.Time{Time: time.Now().UTC()}
+		if field >= 0 {
+			if req.Name != "" {
+				wf.Fields[field].Name = req.Name
+			}
+			return http.StatusOK, wf.Fields[field]
+		}
+		if req.Name != "" {
+			wf.TableHeaders[header].Name = req.Name
+		}
+		return http.StatusOK, wf.TableHeaders[header]
+	case http.MethodDelete:
+		if field >= 0 {
+			wf.Fields = append(wf.Fields[:field], wf.Fields[field+1:]...)
+		} else {
+			wf.TableHeaders = append(wf.TableHeaders[:header], wf.TableHeaders[header+1:]...)
+		}
+		wf.UpdatedAt = nanonets.Time{Time: time.Now().UTC()}
+		return http.StatusOK, map[string]string{"message": "deleted"}
+	}
+	return http.StatusMethodNotAllowed, "method not allowed"
+}
+
+func (s *Server) listDocuments(workflowID string, r *http.Request) (int, interface{}) {
+	docs := []*nanonets.Document{}
+	for _, entry := range s.documentsOf(workflowID) {
+		docs = append(docs, s.view(entry))
+	}
+	q := r.URL.Query()
+	if q.Get("page") == "" && q.Get("limit") == "" {
+		return http.StatusOK, docs
+	}
+	page, _ := strconv.Atoi(q.Get("page"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		return http.StatusOK, docs
+	}
+	start := (page - 1) * limit
+	if start >= len(docs) {
+		return http.StatusOK, []*nanonets.Document{}
+	}
+	end := start + limit
+	if end > len(docs) {
+		end = len(docs)
+	}
+	return http.StatusOK, docs[start:end]
+}
+
+// documentsOf returns the documents of a workflow in upload order
+func (s *Server) documentsOf(workflowID string) []*document {
+	var out []*document
+	for _, entry := range s.documents {
+		if entry.workflowID == workflowID {
+			out = append(out, entry)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].seq < out[j].seq })
+	return out
+}
+
+func (s *Server) upload(workflowID string, r *http.Request, body []byte) (int, interface{}) {
+	doc := new(nanonets.Document)
+	if template, ok := s.extraction[workflowID]; ok {
+		clone(template, doc)
+		doc.DocumentID = ""
+		for i := range doc.Pages {
+			resetIDs(&doc.Pages[i])
+		}
+	}
+
+	var async bool
+	var original []byte
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		metadata := nanonets.Metadata{}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+			value, err := io.ReadAll(part)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+			switch {
+			case part.FormName() == "file":
+				original = value
+				doc.OriginalDocumentName = part.FileName()
+			case part.FormName() == "async":
+				async = string(value) == "true"
+			default:
+				metadata[part.FormName()] = string(value)
+			}
+		}
+		if original == nil {
+			return http.StatusBadRequest, "missing file"
+		}
+		if len(metadata) > 0 {
+			doc.Metadata = metadata
+		}
+	} else {
+		var req struct {
+			URL      string            `json:"document_url"`
+			Async    bool              `json:"async"`
+			Metadata nanonets.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return http.StatusBadRequest, err
+		}
+		if req.URL == "" {
+			return http.StatusBadRequest, "missing document_url"
+		}
+		async = req.Async
+		doc.RawDocumentURL = req.URL
+		doc.OriginalDocumentName = path.Base(req.URL)
+		doc.Metadata = req.Metadata
+	}
+
+	doc.Status = nanonets.DocumentStatusCompleted
+	entry := s.addDocument(workflowID, doc, original)
+	if async {
+		doc.Status = nanonets.DocumentStatusProcessing
+		entry.readyAt = time.Now().Add(s.ProcessingTime)
+	}
+	return http.StatusOK, doc
+}
+
+// resetIDs clears the IDs of a page copied from the extraction template
+func resetIDs(p *nanonets.Page) {
+	p.PageID = ""
+	for name, values := range p.Data.Fields {
+		for i := range values {
+			values[i].FieldDataID = ""
+		}
+		p.Data.Fields[name] = values
+	}
+	for i := range p.Data.Tables {
+		p.Data.Tables[i].TableID = ""
+		for j := range p.Data.Tables[i].Cells {
+			p.Data.Tables[i].Cells[j].CellID = ""
+		}
+	}
+}
+
+// documentFields lists a document's field values as returned by
+// Documents.GetFields
+func documentFields(doc *nanonets.Document) []map[string]interface{} {
+	out := []map[string]interface{}{}
+	for _, page := range doc.Pages {
+		for _, name := range nanonets.FieldNames(page.Data.Fields) {
+			for _, fd := range page.Data.Fields[name] {
+				out = append(out, map[string]interface{}{
+					"id":                  fd.FieldDataID,
+					"name":                name,
+					"value":               fd.Value,
+					"confidence":          fd.Confidence,
+					"page_id":             page.PageID,
+					"verification_status": fd.VerificationStatus,
+				})
+			}
+		}
+	}
+	return out
+}
+
+// findFieldData returns the name and index of a field value on a page
+func findFieldData(page *nanonets.Page, fieldDataID string) (string, int, bool) {
+	for name, values := range page.Data.Fields {
+		for i, fd := range values {
+			if fd.FieldDataID == fieldDataID {
+				return name, i, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+func (s *Server) pageField(m string, page *nanonets.Page, rest []string, body []byte) (int, interface{}) {
+	if len(rest) == 0 {
+		if m != http.MethodPost {
+			return http.StatusMethodNotAllowed, "method not allowed"
+		}
+		var req nanonets.AddFieldRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return http.StatusBadRequest, err
+		}
+		if req.FieldName == "" {
+			return http.StatusBadRequest, "missing field_name"
+		}
+		fd := nanonets.FieldData{
+			FieldDataID:         s.newID("fd"),
+			Value:               req.Value,
+			Confidence:          req.Confidence,
+			Bbox:                req.Bbox,
+			VerificationStatus:  req.VerificationStatus,
+			VerificationMessage: req.VerificationMessage,
+			IsModerated:         true,
+		}
+		if fd.VerificationStatus == "" {
+			fd.VerificationStatus = nanonets.VerificationStatusUnverified
+		}
+		page.Data.Fields[req.FieldName] = append(page.Data.Fields[req.FieldName], fd)
+		return http.StatusCreated, fd
+	}
+
+	name, i, ok := findFieldData(page, rest[0])
+	if !ok {
+		return http.StatusNotFound, "field not found"
+	}
+	fd := &page.Data.Fields[name][i]
+	switch {
+	case len(rest) == 1 && m == http.MethodPatch:
+		var req nanonets.UpdateFieldRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return http.StatusBadRequest, err
+		}
+		if req.Value != nil {
+			fd.Value = *req.Value
+		}
+		fd.IsModerated = true
+		updated := *fd
+		if req.Name != "" && req.Name != name {
+			values := page.Data.Fields[name]
+			page.Data.Fields[name] = append(values[:i], values[i+1:]...)
+			if len(page.Data.Fields[name]) == 0 {
+				delete(page.Data.Fields, name)
+			}
+			page.Data.Fields[req.Name] = append(page.Data.Fields[req.Name], updated)
+		}
+		return http.StatusOK, updated
+	case len(rest) == 1 && m == http.MethodDelete:
+		values := page.Data.Fields[name]
+		page.Data.Fields[name] = append(values[:i], values[i+1:]...)
+		if len(page.Data.Fields[name]) == 0 {
+			delete(page.Data.Fields, name)
+		}
+		return http.StatusOK, map[string]string{"message": "deleted"}
+	case len(rest) == 2 && rest[1] == "verify" && m == http.MethodPost:
+		var req nanonets.VerifyFieldRequest
+		if status, err := decodeVerify(body, &req, &req.VerificationStatus); err != nil {
+			return status, err
+		}
+		fd.VerificationStatus = req.VerificationStatus
+		fd.VerificationMessage = req.VerificationMessage
+		return http.StatusOK, fd
+	}
+	return http.StatusNotFound, "not found"
+}
+
+func (s *Server) pageTable(m string, page *nanonets.Page, rest []string, body []byte) (int, interface{}) {
+	if len(rest) == 0 {
+		if m != http.MethodPost {
+			return http.StatusMethodNotAllowed, "method not allowed"
+		}
+		var req nanonets.AddTableRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return http.StatusBadRequest, err
+		}
+		table := nanonets.Table{TableID: s.newID("table"), Bbox: req.Bbox, Cells: []nanonets.TableCell{}}
+		setTableVerification(&table, req.VerificationStatus, req.VerificationMessage)
+		for _, c := range req.Cells {
+			cell := nanonets.TableCell{
+				CellID:              s.newID("cell"),
+				Row:                 c.Row,
+				Col:                 c.Col,
+				Header:              c.Header,
+				Text:                c.Text,
+				Bbox:                c.Bbox,
+				VerificationStatus:  c.VerificationStatus,
+				VerificationMessage: c.VerificationMessage,
+				IsModerated:         true,
+			}
+			if cell.VerificationStatus == "" {
+				cell.VerificationStatus = nanonets.VerificationStatusUnverified
+			}
+			table.Cells = append(table.Cells, cell)
+		}
+		page.Data.Tables = append(page.Data.Tables, table)
+		return http.StatusCreated, table
+	}
+
+	ti := -1
+	for i := range page.Data.Tables {
+		if page.Data.Tables[i].TableID == rest[0] {
+			ti = i
+		}
+	}
+	if ti < 0 {
+		return http.StatusNotFound, "table not found"
+	}
+	table := &page.Data.Tables[ti]
+
+	switch {
+	case len(rest) == 1 && m == http.MethodDelete:
+		page.Data.Tables = append(page.Data.Tables[:ti], page.Data.Tables[ti+1:]...)
+		return http.StatusOK, map[string]string{"message": "deleted"}
+	case len(rest) == 2 && rest[1] == "verify" && m == http.MethodPost:
+		var req nanonets.VerifyTableRequest
+		if status, err := decodeVerify(body, &req, &req.VerificationStatus); err != nil {
+			return status, err
+		}
+		setTableVerification(table, req.VerificationStatus, req.VerificationMessage)
+		return http.StatusOK, table
+	case len(rest) == 2 && rest[1] == "cells" && m == http.MethodPost:
+		var req nanonets.AddTableCellRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return http.StatusBadRequest, err
+		}
+		for _, c := range table.Cells {
+			if c.Row == req.Row && c.Col == req.Col {
+				return http.StatusConflict, "a cell already exists at this position"
+			}
+		}
+		cell := nanonets.TableCell{
+			CellID:              s.newID("cell"),
+			Row:                 req.Row,
+			Col:                 req.Col,
+			Header:              req.Header,
+			Text:                req.Text,
+			Bbox:                req.Bbox,
+			VerificationStatus:  req.VerificationStatus,
+			VerificationMessage: req.VerificationMessage,
+			IsModerated:         true,
+		}
+		if cell.VerificationStatus == "" {
+			cell.VerificationStatus = nanonets.VerificationStatusUnverified
+		}
+		table.Cells = append(table.Cells, cell)
+		return http.StatusCreated, cell
+	}
+
+	if len(rest) < 3 || rest[1] != "cells" {
+		return http.StatusNotFound, "not found"
+	}
+	ci := -1
+	for i := range table.Cells {
+		if table.Cells[i].CellID == rest[2] {
+			ci = i
+		}
+	}
+	if ci < 0 {
+		return http.StatusNotFound, "cell not found"
+	}
+	cell := &table.Cells[ci]
+
+	switch {
+	case len(rest) == 3 && m == http.MethodPatch:
+		var req nanonets.UpdateTableCellRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return http.StatusBadRequest, err
+		}
+		cell.Text = req.Value
+		cell.IsModerated = true
+		return http.StatusOK, cell
+	case len(rest) == 3 && m == http.MethodDelete:
+		table.Cells = append(table.Cells[:ci], table.Cells[ci+1:]...)
+		return http.StatusOK, map[string]string{"message": "deleted"}
+	case len(rest) == 4 && rest[3] == "verify" && m == http.MethodPost:
+		var req nanonets.VerifyTableCellRequest
+		if status, err := decodeVerify(body, &req, &req.VerificationStatus); err != nil {
+			return status, err
+		}
+		cell.VerificationStatus = req.VerificationStatus
+		cell.VerificationMessage = req.VerificationMessage
+		return http.StatusOK, cell
+	}
+	return http.StatusNotFound, "not found"
+}
+
+// setTableVerification records a table's verification in Extra, since
+// Table doesn't model it
+func setTableVerification(t *nanonets.Table, status nanonets.VerificationStatus, message string) {
+	if t.Extra == nil {
+		t.Extra = make(map[string]json.RawMessage)
+	}
+	if status == "" {
+		status = nanonets.VerificationStatusUnverified
+	}
+	t.Extra["verification_status"], _ = json.Marshal(status)
+	t.Extra["verification_message"], _ = json.Marshal(message)
+}
+
+// decodeVerify decodes a verify request and checks its status
+func decodeVerify(body []byte, req interface{}, status *nanonets.VerificationStatus) (int, error) {
+	if err := json.Unmarshal(body, req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if !status.IsValid() {
+		return http.StatusBadRequest, fmt.Errorf("invalid verification status %q", *status)
+	}
+	return 0, nil
+}
@@ -0,0 +1,482 @@
+// Package nanonetstest provides an in-process fake of the Nanonets v4 API
+// for tests. The server implements the workflow, document and moderation
+// endpoints used by the nanonets package on top of in-memory state, and
+// can be seeded with documents, slowed down and told to fail.
+//
+//	srv := nanonetstest.NewServer()
+//	defer srv.Close()
+//	wf := srv.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+//	srv.AddDocument(wf.ID, cannedInvoice)
+//	client := srv.Client()
+package nanonetstest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+// DefaultAPIKey is the API key the server accepts unless APIKey is changed
+const DefaultAPIKey = "test-api-key"
+
+// BasePath is the path prefix of the API on the server
+const BasePath = "/api/v4"
+
+// Fault makes the server fail matching requests
+type Fault struct {
+	// Method matches the request method; empty matches any method
+	Method string
+	// Path is a path.Match pattern for the request path below BasePath,
+	// such as "/workflows/*/documents"; empty matches any path
+	Path string
+	// Status is the status code to respond with, such as 429 or 500
+	Status int
+	// Body is the response body; a JSON error message is sent if empty
+	Body string
+	// RetryAfter sets the Retry-After header when non-zero
+	RetryAfter time.Duration
+	// Timeout makes the server hold the request open until the client
+	// gives up or the server is closed, instead of responding
+	Timeout bool
+	// Times is the number of requests to fail; zero fails every request
+	Times int
+}
+
+func (f *Fault) matches(r *http.Request, p string) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+		return false
+	}
+	if f.Path == "" {
+		return true
+	}
+	ok, _ := path.Match(f.Path, p)
+	return ok
+}
+
+// Request is a request received by the server
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   []byte
+}
+
+// Server is a fake Nanonets API server. Its exported fields may be changed
+// between requests.
+type Server struct {
+	*httptest.Server
+
+	// APIKey is the key clients must authenticate with; empty accepts any
+	// request
+	APIKey string
+	// ProcessingTime is how long documents uploaded with async set stay in
+	// the processing status
+	ProcessingTime time.Duration
+	// ETags makes GET responses carry an ETag and answers a request whose
+	// If-None-Match matches it with 304 Not Modified
+	ETags bool
+
+	mu          sync.Mutex
+	latency     time.Duration
+	faults      []*Fault
+	requests    []Request
+	types       []nanonets.WorkflowType
+	workflows   map[string]*nanonets.Workflow
+	workflowIDs []string
+	documents   map[string]*document
+	extraction  map[string]*nanonets.Document
+	nextID      int
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+// document is a stored document along with its uploaded file
+type document struct {
+	workflowID string
+	doc        *nanonets.Document
+	original   []byte
+	readyAt    time.Time
+	seq        int
+}
+
+// NewServer starts a fake server with no workflows. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		APIKey: DefaultAPIKey,
+		types: []nanonets.WorkflowType{
+			{ID: "invoice", Name: "Invoice", Description: "Invoices and bills"},
+			{ID: "receipt", Name: "Receipt", Description: "Receipts"},
+			{ID: "custom", Name: "Custom", Description: "Custom documents"},
+		},
+		workflows:  make(map[string]*nanonets.Workflow),
+		documents:  make(map[string]*document),
+		extraction: make(map[string]*nanonets.Document),
+		closed:     make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Close shuts the server down, releasing requests held by a Timeout fault
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+	s.Server.Close()
+}
+
+// BaseURL returns the base URL of the API, for use as Client.BaseURL
+func (s *Server) BaseURL() string {
+	return s.Server.URL + BasePath
+}
+
+// Client returns a client configured for the server
+func (s *Server) Client() *nanonets.Client {
+	c := nanonets.NewClient(s.APIKey)
+	c.BaseURL = s.BaseURL()
+	c.Client = s.Server.Client()
+	return c
+}
+
+// SetLatency delays every response by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// InjectFault adds a fault. Faults are checked in the order they were
+// added and the first match applies.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every fault
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests returns the requests received so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// SetWorkflowTypes replaces the workflow types returned by GetTypes
+func (s *Server) SetWorkflowTypes(types []nanonets.WorkflowType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.types = append([]nanonets.WorkflowType(nil), types...)
+}
+
+// AddWorkflow stores a workflow, assigning IDs where they are empty, and
+// returns a copy of what was stored
+func (s *Server) AddWorkflow(wf nanonets.Workflow) *nanonets.Workflow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := new(nanonets.Workflow)
+	clone(&wf, stored)
+	s.addWorkflow(stored)
+	out := new(nanonets.Workflow)
+	clone(stored, out)
+	return out
+}
+
+func (s *Server) addWorkflow(wf *nanonets.Workflow) {
+	if wf.ID == "" {
+		wf.ID = s.newID("wf")
+	}
+	now := nanonets.Time{Time: time.Now().UTC()}
+	if wf.CreatedAt.IsZero() {
+		wf.CreatedAt = now
+	}
+	if wf.UpdatedAt.IsZero() {
+		wf.UpdatedAt = now
+	}
+	if wf.Fields == nil {
+		wf.Fields = []nanonets.Field{}
+	}
+	if wf.TableHeaders == nil {
+		wf.TableHeaders = []nanonets.TableHeader{}
+	}
+	for i := range wf.Fields {
+		if wf.Fields[i].ID == "" {
+			wf.Fields[i].ID = s.newID("field")
+		}
+	}
+	for i := range wf.TableHeaders {
+		if wf.TableHeaders[i].ID == "" {
+			wf.TableHeaders[i].ID = s.newID("header")
+		}
+	}
+	if _, ok := s.workflows[wf.ID]; !ok {
+		s.workflowIDs = append(s.workflowIDs, wf.ID)
+	}
+	s.workflows[wf.ID] = wf
+}
+
+// Workflow returns a copy of a stored workflow
+func (s *Server) Workflow(workflowID string) (*nanonets.Workflow, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wf, ok := s.workflows[workflowID]
+	if !ok {
+		return nil, false
+	}
+	out := new(nanonets.Workflow)
+	clone(wf, out)
+	return out, true
+}
+
+// AddDocument seeds a workflow with a processed document, such as canned
+// extraction results. IDs that are empty are assigned, and the status
+// defaults to completed. It returns a copy of what was stored.
+func (s *Server) AddDocument(workflowID string, doc nanonets.Document) (*nanonets.Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.workflows[workflowID]; !ok {
+		return nil, fmt.Errorf("workflow %s not found", workflowID)
+	}
+	stored := new(nanonets.Document)
+	clone(&doc, stored)
+	if stored.Status == "" {
+		stored.Status = nanonets.DocumentStatusCompleted
+	}
+	s.addDocument(workflowID, stored, nil)
+	out := new(nanonets.Document)
+	clone(stored, out)
+	return out, nil
+}
+
+func (s *Server) addDocument(workflowID string, doc *nanonets.Document, original []byte) *document {
+	if doc.DocumentID == "" {
+		doc.DocumentID = s.newID("doc")
+	}
+	if doc.UploadedAt.IsZero() {
+		doc.UploadedAt = nanonets.Time{Time: time.Now().UTC()}
+	}
+	if doc.VerificationStatus == "" {
+		doc.VerificationStatus = nanonets.VerificationStatusUnverified
+	}
+	if len(doc.Pages) == 0 {
+		doc.Pages = []nanonets.Page{{PageNumber: 1}}
+	}
+	for i := range doc.Pages {
+		s.fillPage(&doc.Pages[i], i+1)
+	}
+	s.nextID++
+	entry := &document{workflowID: workflowID, doc: doc, original: original, seq: s.nextID}
+	s.documents[doc.DocumentID] = entry
+	return entry
+}
+
+// fillPage assigns the IDs missing from a page and its data
+func (s *Server) fillPage(p *nanonets.Page, number int) {
+	if p.PageID == "" {
+		p.PageID = s.newID("page")
+	}
+	if p.PageNumber == 0 {
+		p.PageNumber = number
+	}
+	if p.Data.Fields == nil {
+		p.Data.Fields = make(map[string][]nanonets.FieldData)
+	}
+	if p.Data.Tables == nil {
+		p.Data.Tables = []nanonets.Table{}
+	}
+	for name, values := range p.Data.Fields {
+		for i := range values {
+			if values[i].FieldDataID == "" {
+				values[i].FieldDataID = s.newID("fd")
+			}
+			if values[i].VerificationStatus == "" {
+				values[i].VerificationStatus = nanonets.VerificationStatusUnverified
+			}
+		}
+		p.Data.Fields[name] = values
+	}
+	for i := range p.Data.Tables {
+		t := &p.Data.Tables[i]
+		if t.TableID == "" {
+			t.TableID = s.newID("table")
+		}
+		for j := range t.Cells {
+			if t.Cells[j].CellID == "" {
+				t.Cells[j].CellID = s.newID("cell")
+			}
+			if t.Cells[j].VerificationStatus == "" {
+				t.Cells[j].VerificationStatus = nanonets.VerificationStatusUnverified
+			}
+		}
+	}
+}
+
+// Document returns a copy of a stored document
+func (s *Server) Document(documentID string) (*nanonets.Document, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.documents[documentID]
+	if !ok {
+		return nil, false
+	}
+	out := new(nanonets.Document)
+	clone(s.view(entry), out)
+	return out, true
+}
+
+// SetExtraction sets the canned extraction result for documents uploaded
+// to a workflow. Each upload gets a copy with fresh IDs. Without one,
+// uploads produce a single page with no data.
+func (s *Server) SetExtraction(workflowID string, result nanonets.Document) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := new(nanonets.Document)
+	clone(&result, stored)
+	s.extraction[workflowID] = stored
+}
+
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s_%d", prefix, s.nextID)
+}
+
+// view returns a document as seen by clients at the current time
+func (s *Server) view(entry *document) *nanonets.Document {
+	if entry.doc.Status == nanonets.DocumentStatusProcessing && !time.Now().Before(entry.readyAt) {
+		entry.doc.Status = nanonets.DocumentStatusCompleted
+	}
+	return entry.doc
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	p := strings.TrimPrefix(r.URL.Path, BasePath)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: p, Query: r.URL.RawQuery, Body: body})
+	latency := s.latency
+	var fault *Fault
+	for i, f := range s.faults {
+		if !f.matches(r, p) {
+			continue
+		}
+		copied := *f
+		fault = &copied
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		break
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		case <-s.closed:
+			return
+		}
+	}
+	if fault != nil {
+		if fault.Timeout {
+			select {
+			case <-r.Context().Done():
+			case <-s.closed:
+			}
+			return
+		}
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(fault.RetryAfter.Seconds()+0.5)))
+		}
+		if fault.Body != "" {
+			w.WriteHeader(fault.Status)
+			io.WriteString(w, fault.Body)
+			return
+		}
+		writeError(w, fault.Status, http.StatusText(fault.Status))
+		return
+	}
+
+	if s.APIKey != "" {
+		if user, _, ok := r.BasicAuth(); !ok || user != s.APIKey {
+			writeError(w, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	etags := s.ETags
+	status, result := s.route(r, p, body)
+	if status >= 400 {
+		writeError(w, status, fmt.Sprint(result))
+		return
+	}
+	if raw, ok := result.([]byte); ok {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(status)
+		w.Write(raw)
+		return
+	}
+	if etags && r.Method == http.MethodGet && status == http.StatusOK {
+		writeJSONWithETag(w, r, result)
+		return
+	}
+	writeJSON(w, status, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONWithETag writes a 200 response tagged with a hash of its body,
+// or 304 if the request already has that version
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, v interface{}) {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(v)
+	sum := sha256.Sum256(buf.Bytes())
+	etag := fmt.Sprintf(`"%x"`, sum[:8])
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
+
+// clone deep-copies src into dst through JSON, which keeps Extra
+func clone(src, dst interface{}) {
+	data, err := json.Marshal(src)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		panic(err)
+	}
+}
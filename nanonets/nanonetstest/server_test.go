@@ -0,0 +1,202 @@
+package nanonetstest_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+func TestFaultRateLimit(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	s.InjectFault(nanonetstest.Fault{
+		Method:     http.MethodGet,
+		Path:       "/workflows/*",
+		Status:     http.StatusTooManyRequests,
+		RetryAfter: 3 * time.Second,
+		Times:      2,
+	})
+	c := s.Client()
+
+	for i := 0; i < 2; i++ {
+		_, err := c.Workflows.Get(wf.ID)
+		var apiErr *nanonets.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("request %d: err = %v, want an APIError", i+1, err)
+		}
+		if apiErr.Class() != nanonets.ErrorClassRateLimit || apiErr.RetryAfter != 3*time.Second {
+			t.Errorf("request %d: class %s, retry after %s; want rate_limit after 3s", i+1, apiErr.Class(), apiErr.RetryAfter)
+		}
+	}
+	// The fault is used up after Times requests
+	if _, err := c.Workflows.Get(wf.ID); err != nil {
+		t.Errorf("third request: %v", err)
+	}
+	// Other methods and paths never matched
+	if _, err := c.Workflows.List(); err != nil {
+		t.Errorf("list: %v", err)
+	}
+}
+
+func TestFaultBodyAndClear(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	s.InjectFault(nanonetstest.Fault{Status: http.StatusServiceUnavailable, Body: "maintenance"})
+	c := s.Client()
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Workflows.List()
+		var apiErr *nanonets.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Body != "maintenance" {
+			t.Fatalf("request %d: err = %v, want the injected 503", i+1, err)
+		}
+	}
+	s.ClearFaults()
+	if _, err := c.Workflows.List(); err != nil {
+		t.Errorf("after ClearFaults: %v", err)
+	}
+}
+
+func TestFaultTimeout(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	s.InjectFault(nanonetstest.Fault{Path: "/workflows", Timeout: true, Times: 1})
+	c := s.Client()
+	hc := *c.Client
+	hc.Timeout = 50 * time.Millisecond
+	c.Client = &hc
+
+	start := time.Now()
+	if _, err := c.Workflows.List(); err == nil {
+		t.Fatal("request held open by a Timeout fault succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("client gave up after %s", elapsed)
+	}
+	if _, err := c.Workflows.List(); err != nil {
+		t.Errorf("request after the timeout: %v", err)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	c := s.Client()
+	c.APIKey = "wrong"
+	_, err := c.Workflows.List()
+	if nanonets.ErrorClassOf(err) != nanonets.ErrorClassAuth {
+		t.Errorf("err = %v, want an auth error", err)
+	}
+
+	s.APIKey = ""
+	if _, err := c.Workflows.List(); err != nil {
+		t.Errorf("with APIKey unset: %v", err)
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	var ids []string
+	for i := 0; i < 5; i++ {
+		doc, err := s.AddDocument(wf.ID, nanonets.Document{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, doc.DocumentID)
+	}
+	c := s.Client()
+
+	all, err := c.Documents.List(wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 5 {
+		t.Errorf("List returned %d documents, want 5", len(all))
+	}
+	for _, tt := range []struct {
+		page, limit int
+		want        []string
+	}{
+		{1, 2, ids[0:2]},
+		{3, 2, ids[4:5]},
+		{4, 2, nil},
+	} {
+		docs, err := c.Documents.ListWithPagination(wf.ID, tt.page, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range docs {
+			got = append(got, d.DocumentID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("page %d of %d: %v, want %v", tt.page, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestETags(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	s.ETags = true
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	get := func(etag string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, s.BaseURL()+"/workflows/"+wf.ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetBasicAuth(nanonetstest.DefaultAPIKey, "")
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := s.Server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	first := get("")
+	etag := first.Header.Get("ETag")
+	if first.StatusCode != http.StatusOK || etag == "" {
+		t.Fatalf("first response: status %d, ETag %q", first.StatusCode, etag)
+	}
+	if resp := get(etag); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("matching If-None-Match: status %d, want 304", resp.StatusCode)
+	}
+
+	if err := s.Client().Workflows.UpdateMetadata(wf.ID, nanonets.UpdateMetadataRequest{Description: "Bills"}); err != nil {
+		t.Fatal(err)
+	}
+	resp := get(etag)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+		t.Errorf("after a change: status %d, ETag %q; want 200 with a new ETag", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+}
+
+func TestRequestsAndLatency(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	s.SetLatency(20 * time.Millisecond)
+	start := time.Now()
+	if _, err := s.Client().Workflows.GetTypes(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("response after %s, want at least the 20ms latency", elapsed)
+	}
+	requests := s.Requests()
+	if len(requests) != 1 || requests[0].Method != http.MethodGet || requests[0].Path != "/workflows/types" {
+		t.Errorf("requests %+v, want one GET /workflows/types", requests)
+	}
+}
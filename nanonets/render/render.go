@@ -0,0 +1,320 @@
+// Package render draws the fields and table cells extracted from a page
+// on top of the page image, as a PNG or an SVG, so that reviewers can see
+// where extraction went wrong.
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register decoders for page images
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Default confidence bands
+const (
+	DefaultHighConfidence = 0.9
+	DefaultLowConfidence  = 0.6
+)
+
+// Colors used for boxes
+var (
+	ColorVerified       = color.NRGBA{R: 0x1e, G: 0x6f, B: 0xe8, A: 0xff}
+	ColorRejected       = color.NRGBA{R: 0x8e, G: 0x44, B: 0xad, A: 0xff}
+	ColorHighConfidence = color.NRGBA{R: 0x2e, G: 0xa0, B: 0x43, A: 0xff}
+	ColorMedConfidence  = color.NRGBA{R: 0xf0, G: 0x9a, B: 0x1a, A: 0xff}
+	ColorLowConfidence  = color.NRGBA{R: 0xd6, G: 0x2f, B: 0x2f, A: 0xff}
+	ColorTable          = color.NRGBA{R: 0x60, G: 0x60, B: 0x60, A: 0xff}
+)
+
+// Renderer draws page annotations. The zero value is not usable; create
+// one with New.
+type Renderer struct {
+	// HTTPClient fetches page images given by URL
+	HTTPClient *http.Client
+	// HighConfidence and LowConfidence split unverified field values into
+	// high, medium and low confidence bands
+	HighConfidence float64
+	LowConfidence  float64
+	// StrokeWidth is the width of box outlines in pixels
+	StrokeWidth int
+	// Labels draws the field name above each field box
+	Labels bool
+	// CellLabels draws the column header above each table cell
+	CellLabels bool
+}
+
+// New creates a renderer with the default confidence bands and labels on
+func New() *Renderer {
+	return &Renderer{
+		HTTPClient:     http.DefaultClient,
+		HighConfidence: DefaultHighConfidence,
+		LowConfidence:  DefaultLowConfidence,
+		StrokeWidth:    2,
+		Labels:         true,
+	}
+}
+
+// PageImage is a decoded page image along with its encoded bytes
+type PageImage struct {
+	Image       image.Image
+	Data        []byte
+	ContentType string
+}
+
+// LoadImage reads a page image from a URL (such as Page.ImageURL) or a
+// local file
+func (r *Renderer) LoadImage(source string) (*PageImage, error) {
+	var data []byte
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := r.HTTPClient.Get(source)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("fetching page image: status %d", resp.StatusCode)
+		}
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(source); err != nil {
+			return nil, err
+		}
+	}
+	return DecodeImage(data)
+}
+
+// DecodeImage decodes a PNG, JPEG or GIF page image
+func DecodeImage(data []byte) (*PageImage, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding page image: %w", err)
+	}
+	return &PageImage{Image: img, Data: data, ContentType: http.DetectContentType(data)}, nil
+}
+
+// box is a shape to draw, in pixel coordinates
+type box struct {
+	rect  nanonets.BBox
+	color color.NRGBA
+	label string
+	fill  bool
+}
+
+// FieldColor returns the color of a field value: verified and rejected
+// values have their own colors, others are colored by confidence band
+func (r *Renderer) FieldColor(data nanonets.FieldData) color.NRGBA {
+	switch data.VerificationStatus {
+	case nanonets.VerificationStatusVerified:
+		return ColorVerified
+	case nanonets.VerificationStatusRejected:
+		return ColorRejected
+	}
+	switch {
+	case data.Confidence >= r.HighConfidence:
+		return ColorHighConfidence
+	case data.Confidence >= r.LowConfidence:
+		return ColorMedConfidence
+	}
+	return ColorLowConfidence
+}
+
+// CellColor returns the color of a table cell. Cells carry no confidence,
+// so unverified cells use the table color.
+func (r *Renderer) CellColor(cell nanonets.TableCell) color.NRGBA {
+	switch cell.VerificationStatus {
+	case nanonets.VerificationStatusVerified:
+		return ColorVerified
+	case nanonets.VerificationStatusRejected:
+		return ColorRejected
+	}
+	return ColorTable
+}
+
+// boxes lists the shapes for a page's data on an image of the given size.
+// Tables come first so that fields are drawn on top of them.
+func (r *Renderer) boxes(data nanonets.PageData, bounds image.Rectangle) []box {
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	toPixels := func(b nanonets.BBox) nanonets.BBox {
+		if b.IsNormalized() {
+			b = b.Pixels(w, h)
+		}
+		return nanonets.BBox{
+			X1: b.X1 + float64(bounds.Min.X),
+			Y1: b.Y1 + float64(bounds.Min.Y),
+			X2: b.X2 + float64(bounds.Min.X),
+			Y2: b.Y2 + float64(bounds.Min.Y),
+		}
+	}
+
+	var out []box
+	for _, table := range data.Tables {
+		if b, ok := table.Box(); ok {
+			out = append(out, box{rect: toPixels(b), color: ColorTable})
+		}
+		for _, cell := range table.Cells {
+			b, ok := cell.Box()
+			if !ok {
+				continue
+			}
+			shape := box{rect: toPixels(b), color: r.CellColor(cell), fill: true}
+			if r.CellLabels {
+				shape.label = cell.Header
+			}
+			out = append(out, shape)
+		}
+	}
+	for _, name := range nanonets.FieldNames(data.Fields) {
+		for _, fd := range data.Fields[name] {
+			b, ok := fd.Box()
+			if !ok {
+				continue
+			}
+			shape := box{rect: toPixels(b), color: r.FieldColor(fd), fill: true}
+			if r.Labels {
+				shape.label = name
+			}
+			out = append(out, shape)
+		}
+	}
+	return out
+}
+
+// PNG draws the page data over the image and writes the result as a PNG
+func (r *Renderer) PNG(w io.Writer, img *PageImage, data nanonets.PageData) error {
+	bounds := img.Image.Bounds()
+	canvas := image.NewRGBA(bounds)
+	draw.Draw(canvas, bounds, img.Image, bounds.Min, draw.Src)
+
+	shapes := r.boxes(data, bounds)
+	for _, s := range shapes {
+		rect := image.Rect(int(s.rect.X1), int(s.rect.Y1), int(s.rect.X2+0.5), int(s.rect.Y2+0.5))
+		if s.fill {
+			fill := s.color
+			fill.A = 0x30
+			draw.Draw(canvas, rect, image.NewUniform(fill), image.Point{}, draw.Over)
+		}
+		strokeRect(canvas, rect, s.color, r.StrokeWidth)
+	}
+	for _, s := range shapes {
+		if s.label != "" {
+			drawLabel(canvas, int(s.rect.X1), int(s.rect.Y1), s.label, s.color)
+		}
+	}
+	return png.Encode(w, canvas)
+}
+
+// strokeRect draws the outline of rect inside its bounds
+func strokeRect(dst draw.Image, rect image.Rectangle, c color.Color, width int) {
+	if width < 1 {
+		width = 1
+	}
+	src := image.NewUniform(c)
+	edges := []image.Rectangle{
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+width),
+		image.Rect(rect.Min.X, rect.Max.Y-width, rect.Max.X, rect.Max.Y),
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+width, rect.Max.Y),
+		image.Rect(rect.Max.X-width, rect.Min.Y, rect.Max.X, rect.Max.Y),
+	}
+	for _, e := range edges {
+		draw.Draw(dst, e.Intersect(rect), src, image.Point{}, draw.Over)
+	}
+}
+
+// drawLabel writes text on a colored background above (x, y), or below
+// it when there's no room above
+func drawLabel(dst *image.RGBA, x, y int, text string, bg color.NRGBA) {
+	face := basicfont.Face7x13
+	d := &font.Drawer{Dst: dst, Src: image.White, Face: face}
+	width := d.MeasureString(text).Ceil() + 4
+	height := face.Height + 2
+	top := y - height
+	if top < dst.Bounds().Min.Y {
+		top = y
+	}
+	draw.Draw(dst, image.Rect(x, top, x+width, top+height), image.NewUniform(bg), image.Point{}, draw.Over)
+	d.Dot = fixed.P(x+2, top+face.Ascent+1)
+	d.DrawString(text)
+}
+
+// SVG writes an SVG that embeds the image and draws the page data over it
+func (r *Renderer) SVG(w io.Writer, img *PageImage, data nanonets.PageData) error {
+	bounds := img.Image.Bounds()
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="%d %d %d %d">`+"\n",
+		bounds.Dx(), bounds.Dy(), bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy())
+	fmt.Fprintf(&b, `<image x="%d" y="%d" width="%d" height="%d" href="data:%s;base64,%s"/>`+"\n",
+		bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy(), img.ContentType, base64.StdEncoding.EncodeToString(img.Data))
+
+	shapes := r.boxes(data, bounds)
+	for _, s := range shapes {
+		fill := "none"
+		if s.fill {
+			fill = hex(s.color)
+		}
+		fmt.Fprintf(&b, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s" fill-opacity="0.19" stroke="%s" stroke-width="%d"/>`+"\n",
+			s.rect.X1, s.rect.Y1, s.rect.Width(), s.rect.Height(), fill, hex(s.color), r.StrokeWidth)
+	}
+	for _, s := range shapes {
+		if s.label == "" {
+			continue
+		}
+		var label bytes.Buffer
+		if err := xmlEscape(&label, s.label); err != nil {
+			return err
+		}
+		y := s.rect.Y1 - 4
+		if y < float64(bounds.Min.Y)+12 {
+			y = s.rect.Y1 + 12
+		}
+		fmt.Fprintf(&b, `<text x="%g" y="%g" font-family="monospace" font-size="12" fill="%s" stroke="white" stroke-width="3" paint-order="stroke">%s</text>`+"\n",
+			s.rect.X1, y, hex(s.color), label.String())
+	}
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// RenderPage loads a page's image from Page.ImageURL and writes it
+// annotated as "png" or "svg"
+func (r *Renderer) RenderPage(w io.Writer, page nanonets.Page, format string) error {
+	if page.ImageURL == "" {
+		return fmt.Errorf("page %s has no image URL", page.PageID)
+	}
+	img, err := r.LoadImage(page.ImageURL)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "png":
+		return r.PNG(w, img, page.Data)
+	case "svg":
+		return r.SVG(w, img, page.Data)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func hex(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func xmlEscape(w io.Writer, s string) error {
+	return xml.EscapeText(w, []byte(s))
+}
@@ -0,0 +1,155 @@
+package render_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/render"
+)
+
+// whitePage returns a blank 100x100 PNG page image
+func whitePage(t *testing.T) *render.PageImage {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	page, err := render.DecodeImage(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+// pageData returns fields with a normalized box, a pixel box and no box
+func pageData() nanonets.PageData {
+	return nanonets.PageData{
+		Fields: map[string][]nanonets.FieldData{
+			// Normalized coordinates
+			"total": {{FieldDataID: "fd_1", Confidence: 0.95, Bbox: []float64{0.1, 0.1, 0.5, 0.5}}},
+			// Pixel coordinates
+			"vendor <&>": {{FieldDataID: "fd_2", Confidence: 0.3, Bbox: []float64{60, 60, 90, 80}}},
+			"no_box":     {{FieldDataID: "fd_3"}},
+		},
+	}
+}
+
+func TestFieldColor(t *testing.T) {
+	r := render.New()
+	tests := []struct {
+		data nanonets.FieldData
+		want color.NRGBA
+	}{
+		{nanonets.FieldData{Confidence: 0.95}, render.ColorHighConfidence},
+		{nanonets.FieldData{Confidence: 0.6}, render.ColorMedConfidence},
+		{nanonets.FieldData{Confidence: 0.59}, render.ColorLowConfidence},
+		{nanonets.FieldData{Confidence: 0.1, VerificationStatus: nanonets.VerificationStatusVerified}, render.ColorVerified},
+		{nanonets.FieldData{Confidence: 0.99, VerificationStatus: nanonets.VerificationStatusRejected}, render.ColorRejected},
+	}
+	for _, tt := range tests {
+		if got := r.FieldColor(tt.data); got != tt.want {
+			t.Errorf("FieldColor(%+v) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+	if got := r.CellColor(nanonets.TableCell{}); got != render.ColorTable {
+		t.Errorf("unverified cell color %v, want the table color", got)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	r := render.New()
+	r.Labels = false
+	var buf bytes.Buffer
+	if err := r.PNG(&buf, whitePage(t), pageData()); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 100, 100) {
+		t.Fatalf("bounds %v, want the page image size", img.Bounds())
+	}
+
+	rgba := func(x, y int) color.NRGBA {
+		return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	}
+	if got := rgba(10, 30); got != render.ColorHighConfidence {
+		t.Errorf("outline of the normalized box at (10, 30) is %v, want %v", got, render.ColorHighConfidence)
+	}
+	if got := rgba(60, 70); got != render.ColorLowConfidence {
+		t.Errorf("outline of the pixel box at (60, 70) is %v, want %v", got, render.ColorLowConfidence)
+	}
+	if got := rgba(30, 30); got == (color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}) {
+		t.Error("inside of a field box is not tinted")
+	}
+	if got := rgba(95, 5); got != (color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}) {
+		t.Errorf("pixel outside every box is %v, want white", got)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := render.New().SVG(&buf, whitePage(t), pageData()); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	for _, want := range []string{
+		`width="100" height="100"`,
+		`href="data:image/png;base64,`,
+		`<rect x="10" y="10" width="40" height="40" fill="#2ea043"`,
+		`<rect x="60" y="60" width="30" height="20" fill="#d62f2f"`,
+		`>vendor &lt;&amp;&gt;</text>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG has no %s:\n%s", want, svg)
+		}
+	}
+	if n := strings.Count(svg, "<rect"); n != 2 {
+		t.Errorf("SVG has %d boxes, want 2", n)
+	}
+}
+
+func TestRenderPage(t *testing.T) {
+	img := whitePage(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/page.png" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(img.Data)
+	}))
+	defer srv.Close()
+
+	r := render.New()
+	page := nanonets.Page{PageID: "p1", ImageURL: srv.URL + "/page.png", Data: pageData()}
+	var buf bytes.Buffer
+	if err := r.RenderPage(&buf, page, "png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(&buf); err != nil {
+		t.Errorf("rendered page is not a PNG: %v", err)
+	}
+
+	if err := r.RenderPage(&buf, page, "gif"); err == nil {
+		t.Error("unknown format: no error")
+	}
+	page.ImageURL = srv.URL + "/missing.png"
+	if err := r.RenderPage(&buf, page, "svg"); err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("missing image: err = %v, want a status 404 error", err)
+	}
+	page.ImageURL = ""
+	if err := r.RenderPage(&buf, page, "svg"); err == nil {
+		t.Error("page without an image URL: no error")
+	}
+}
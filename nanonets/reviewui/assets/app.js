@@ -0,0 +1,412 @@
+// Review UI for nanonets/reviewui. Routes:
+//   #/                        workflow picker
+//   #/wf/{workflow}           documents waiting for review
+//   #/wf/{workflow}/{doc}     page images with overlays and the edit panel
+(function () {
+  "use strict";
+
+  var config = JSON.parse(document.getElementById("config").textContent || "{}");
+  var main = document.getElementById("main");
+  var crumbs = document.getElementById("crumbs");
+  var notice = document.getElementById("notice");
+  var current = null; // {workflowID, documentID, view, selected}
+
+  if (config.read_only) {
+    document.body.classList.add("read-only");
+  }
+
+  // el builds an element. attrs keys starting with "on" become listeners.
+  function el(tag, attrs) {
+    var node = tag === "svg" || tag === "rect" || tag === "title"
+      ? document.createElementNS("http://www.w3.org/2000/svg", tag)
+      : document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (k) {
+      var v = attrs[k];
+      if (v === undefined || v === null || v === false) return;
+      if (k.slice(0, 2) === "on") node.addEventListener(k.slice(2), v);
+      else if (k === "text") node.textContent = v;
+      else if (k === "value") node.value = v;
+      else node.setAttribute(k, v === true ? "" : v);
+    });
+    for (var i = 2; i < arguments.length; i++) {
+      var child = arguments[i];
+      if (child === null || child === undefined) continue;
+      if (Array.isArray(child)) child.forEach(function (c) { if (c) node.appendChild(c); });
+      else node.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+    }
+    return node;
+  }
+
+  function enc(s) { return encodeURIComponent(s); }
+
+  function api(path, body) {
+    var opts = { headers: { Accept: "application/json" } };
+    if (body !== undefined) {
+      opts.method = "POST";
+      opts.headers["Content-Type"] = "application/json";
+      opts.body = JSON.stringify(body);
+    }
+    return fetch("api/" + path, opts).then(function (res) {
+      return res.json().catch(function () { return {}; }).then(function (data) {
+        if (!res.ok && !(data && data.view)) {
+          throw new Error((data && data.error) || res.status + " " + res.statusText);
+        }
+        data = data || {};
+        data.ok = res.ok;
+        return data;
+      });
+    });
+  }
+
+  function say(message, kind) {
+    notice.textContent = message || "";
+    notice.className = kind || "";
+  }
+
+  function setCrumbs(items) {
+    crumbs.replaceChildren.apply(crumbs, items.map(function (it) {
+      return el("span", null, it.href ? el("a", { href: it.href, text: it.text }) : it.text);
+    }));
+  }
+
+  function badge(status) {
+    return el("span", { "class": "badge " + (status || ""), text: status || "unverified" });
+  }
+
+  function fail(err) {
+    say(err.message, "error");
+  }
+
+  // Workflow picker
+
+  function showWorkflows() {
+    current = null;
+    setCrumbs([]);
+    api("workflows").then(function (workflows) {
+      if (workflows.length === 1) {
+        location.replace("#/wf/" + enc(workflows[0].id));
+        return;
+      }
+      main.replaceChildren(el("table", { "class": "list" },
+        el("thead", null, el("tr", null, el("th", { text: "Workflow" }), el("th", { text: "Description" }))),
+        el("tbody", null, workflows.map(function (wf) {
+          return el("tr", { onclick: function () { location.hash = "#/wf/" + enc(wf.id); } },
+            el("td", { text: wf.id }), el("td", { text: wf.description }));
+        }))));
+    }).catch(fail);
+  }
+
+  // Document list
+
+  function showDocuments(workflowID) {
+    current = null;
+    setCrumbs([{ text: "Workflows", href: "#/" }, { text: workflowID }]);
+    api("workflows/" + enc(workflowID) + "/documents?pending=1").then(function (docs) {
+      if (docs.length === 0) {
+        main.replaceChildren(el("p", { text: "Nothing to review." }));
+        return;
+      }
+      main.replaceChildren(el("table", { "class": "list" },
+        el("thead", null, el("tr", null, ["Name", "Document", "Status", "Review", "Pages", "Uploaded"].map(function (h) {
+          return el("th", { text: h });
+        }))),
+        el("tbody", null, docs.map(function (d) {
+          return el("tr", { onclick: function () { location.hash = "#/wf/" + enc(workflowID) + "/" + enc(d.document_id); } },
+            el("td", { text: d.original_document_name }),
+            el("td", { text: d.document_id }),
+            el("td", null, badge(d.status)),
+            el("td", null, badge(d.verification_status)),
+            el("td", { text: String(d.pages) }),
+            el("td", { text: d.uploaded_at ? new Date(d.uploaded_at).toLocaleString() : "" }));
+        }))));
+    }).catch(fail);
+  }
+
+  // Document view
+
+  function showDocument(workflowID, documentID) {
+    setCrumbs([
+      { text: "Workflows", href: "#/" },
+      { text: workflowID, href: "#/wf/" + enc(workflowID) },
+      { text: documentID }
+    ]);
+    main.replaceChildren(el("p", { text: "Loading…" }));
+    api("workflows/" + enc(workflowID) + "/documents/" + enc(documentID)).then(function (view) {
+      current = { workflowID: workflowID, documentID: documentID, view: view, selected: null };
+      renderDocument(true);
+    }).catch(fail);
+  }
+
+  function docPath(suffix) {
+    return "workflows/" + enc(current.workflowID) + "/documents/" + enc(current.documentID) + suffix;
+  }
+
+  // renderDocument draws the pages and the panel. Page images are kept when
+  // only the data changed so they don't flicker after every edit.
+  function renderDocument(reloadImages) {
+    var doc = current.view.document;
+    var pagesNode = main.querySelector(".pages");
+    if (reloadImages || !pagesNode) {
+      pagesNode = el("div", { "class": "pages" }, (doc.pages || []).map(renderPage));
+    } else {
+      (doc.pages || []).forEach(function (p) {
+        var svg = pagesNode.querySelector('svg[data-page="' + CSS.escape(p.page_id) + '"]');
+        if (svg) drawOverlays(svg, p.page_id);
+      });
+    }
+    main.replaceChildren(el("div", { "class": "review" }, pagesNode, renderPanel(doc)));
+    if (current.selected) select(current.selected, false);
+  }
+
+  function renderPage(page) {
+    var svg = el("svg", { "data-page": page.page_id, preserveAspectRatio: "none" });
+    var body;
+    if (page.image_url) {
+      body = el("img", {
+        alt: "Page " + page.page_number,
+        src: "api/" + docPath("/pages/" + enc(page.page_id) + "/image"),
+        onload: function () {
+          svg.dataset.width = this.naturalWidth;
+          svg.dataset.height = this.naturalHeight;
+          svg.setAttribute("viewBox", "0 0 " + this.naturalWidth + " " + this.naturalHeight);
+          drawOverlays(svg, page.page_id);
+        },
+        onerror: function () { this.replaceWith(el("div", { "class": "missing", text: "Image unavailable" })); }
+      });
+    } else {
+      body = el("div", { "class": "missing", text: "No image" });
+    }
+    return el("div", null,
+      el("div", { "class": "page-label", text: "Page " + page.page_number }),
+      el("div", { "class": "page" }, body, svg));
+  }
+
+  function drawOverlays(svg, pageID) {
+    var w = Number(svg.dataset.width), h = Number(svg.dataset.height);
+    if (!w || !h) return; // drawn when the image loads
+    var overlays = current.view.overlays[pageID] || [];
+    svg.replaceChildren.apply(svg, overlays.map(function (o) {
+      var b = o.box.slice();
+      if (o.normalized) b = [b[0] * w, b[1] * h, b[2] * w, b[3] * h];
+      var key = o.kind + ":" + o.id;
+      return el("rect", {
+        "class": o.kind,
+        "data-key": key,
+        x: b[0], y: b[1], width: Math.max(b[2] - b[0], 1), height: Math.max(b[3] - b[1], 1),
+        fill: o.color, stroke: o.color,
+        onclick: o.kind === "table" ? null : function () { select(key, true); }
+      }, el("title", { text: o.label || o.id }));
+    }));
+  }
+
+  // select highlights a box and its panel entry
+  function select(key, scroll) {
+    current.selected = key;
+    main.querySelectorAll(".active").forEach(function (n) { n.classList.remove("active"); });
+    main.querySelectorAll('[data-key="' + CSS.escape(key) + '"]').forEach(function (n) {
+      n.classList.add("active");
+      if (scroll && n.tagName !== "rect") {
+        n.scrollIntoView({ block: "nearest", behavior: "smooth" });
+        var input = n.querySelector("input");
+        if (input) input.focus();
+      }
+    });
+  }
+
+  function hover(key, on) {
+    main.querySelectorAll('rect[data-key="' + CSS.escape(key) + '"]').forEach(function (n) {
+      n.classList.toggle("active", on || current.selected === key);
+    });
+  }
+
+  function renderPanel(doc) {
+    var fields = [], tables = [];
+    (doc.pages || []).forEach(function (p) {
+      Object.keys((p.data && p.data.fields) || {}).sort().forEach(function (name) {
+        p.data.fields[name].forEach(function (f) { fields.push({ page: p, name: name, field: f }); });
+      });
+      ((p.data && p.data.tables) || []).forEach(function (t) { tables.push({ page: p, table: t }); });
+    });
+
+    return el("div", { "class": "panel" },
+      el("h2", { text: doc.original_document_name || doc.document_id }),
+      el("div", { "class": "doc-actions" },
+        badge(doc.status), badge(doc.verification_status),
+        el("button", { "class": "primary edit-only", text: "Verify document", onclick: function () { verifyDocument("verified"); } }),
+        el("button", { "class": "edit-only", text: "Reject document", onclick: function () { verifyDocument("rejected"); } })),
+      doc.verification_message ? el("p", { text: doc.verification_message }) : null,
+      el("h2", { text: "Fields" }),
+      fields.length ? fields.map(renderField) : el("p", { text: "No fields." }),
+      tables.map(function (t, i) { return renderTable(t.page, t.table, i); }));
+  }
+
+  function renderField(entry) {
+    var f = entry.field, pageID = entry.page.page_id;
+    var key = "field:" + f.field_data_id;
+    var input = el("input", {
+      value: f.value,
+      readonly: config.read_only,
+      oninput: function () { input.classList.toggle("dirty", input.value !== f.value); },
+      onkeydown: function (e) { if (e.key === "Enter") save(); },
+      onfocus: function () { select(key, false); }
+    });
+    function save() {
+      if (input.value === f.value) return;
+      edit([{ kind: "update_field", page_id: pageID, field_data_id: f.field_data_id, value: input.value }]);
+    }
+    function verify(status) {
+      edit([{ kind: "verify_field", page_id: pageID, field_data_id: f.field_data_id, verification_status: status }]);
+    }
+    return el("div", {
+      "class": "item", "data-key": key,
+      onmouseenter: function () { hover(key, true); },
+      onmouseleave: function () { hover(key, false); }
+    },
+      el("div", { "class": "name", title: entry.name, text: entry.name }),
+      input,
+      el("div", { "class": "buttons edit-only" },
+        el("button", { text: "Save", onclick: save }),
+        el("button", { title: "Verify", text: "✓", onclick: function () { verify("verified"); } }),
+        el("button", { title: "Reject", text: "✗", onclick: function () { verify("rejected"); } }),
+        el("button", {
+          "class": "danger", title: "Delete", text: "🗑",
+          onclick: function () {
+            if (confirm("Delete " + entry.name + "?")) edit([{ kind: "delete_field", page_id: pageID, field_data_id: f.field_data_id }]);
+          }
+        })),
+      el("div", { "class": "meta" },
+        (f.verification_status || "unverified") +
+        (f.confidence ? " · " + Math.round(f.confidence * 100) + "%" : "") +
+        (f.is_moderated ? " · edited" : "") +
+        (f.verification_message ? " · " + f.verification_message : "")));
+  }
+
+  function renderTable(page, table, index) {
+    var pageID = page.page_id, cells = table.cells || [];
+    var rows = 0, cols = 0, headers = {}, byPos = {};
+    cells.forEach(function (c) {
+      rows = Math.max(rows, c.row + 1);
+      cols = Math.max(cols, c.col + 1);
+      if (c.header && !headers[c.col]) headers[c.col] = c.header;
+      byPos[c.row + ":" + c.col] = c;
+    });
+
+    var head = [];
+    for (var c = 0; c < cols; c++) head.push(el("th", { text: headers[c] || "#" + c }));
+    var body = [];
+    for (var r = 0; r < rows; r++) {
+      var tds = [];
+      for (c = 0; c < cols; c++) {
+        var cell = byPos[r + ":" + c];
+        tds.push(cell ? renderCell(pageID, table, cell) : el("td"));
+      }
+      body.push(el("tr", null, tds));
+    }
+
+    var form = el("form", {
+      "class": "add-cell edit-only",
+      onsubmit: function (e) {
+        e.preventDefault();
+        var data = new FormData(form);
+        edit([{
+          kind: "add_table_cell", page_id: pageID, table_id: table.table_id,
+          add_table_cell: {
+            row: Number(data.get("row")), col: Number(data.get("col")),
+            header: data.get("header") || headers[Number(data.get("col"))] || "",
+            text: data.get("text"), bbox: [0, 0, 0, 0]
+          }
+        }]);
+      }
+    },
+      el("input", { name: "row", type: "number", min: "0", placeholder: "row", value: String(rows), required: true }),
+      el("input", { name: "col", type: "number", min: "0", placeholder: "col", value: "0", required: true }),
+      el("input", { name: "header", placeholder: "header" }),
+      el("input", { name: "text", placeholder: "text" }),
+      el("button", { type: "submit", text: "Add cell" }));
+
+    return el("div", null,
+      el("h2", null, "Table " + (index + 1) + " ",
+        el("button", {
+          "class": "edit-only", text: "Verify table",
+          onclick: function () {
+            edit([{ kind: "verify_table", page_id: pageID, table_id: table.table_id, verification_status: "verified" }]);
+          }
+        })),
+      el("table", { "class": "grid" }, el("thead", null, el("tr", null, head)), el("tbody", null, body)),
+      form);
+  }
+
+  function renderCell(pageID, table, cell) {
+    var key = "cell:" + cell.cell_id;
+    var op = { page_id: pageID, table_id: table.table_id, cell_id: cell.cell_id };
+    function cellOp(extra) { return Object.assign({}, op, extra); }
+    var input = el("input", {
+      value: cell.text,
+      readonly: config.read_only,
+      title: cell.verification_message || null,
+      oninput: function () { input.classList.toggle("dirty", input.value !== cell.text); },
+      onkeydown: function (e) { if (e.key === "Enter") input.blur(); },
+      onblur: function () {
+        if (input.value !== cell.text && !config.read_only) edit([cellOp({ kind: "update_table_cell", value: input.value })]);
+      },
+      onfocus: function () { select(key, false); }
+    });
+    return el("td", {
+      "class": cell.verification_status || "", "data-key": key,
+      onmouseenter: function () { hover(key, true); },
+      onmouseleave: function () { hover(key, false); }
+    },
+      input,
+      el("div", { "class": "cell-buttons edit-only" },
+        el("button", { title: "Verify", text: "✓", onclick: function () { edit([cellOp({ kind: "verify_table_cell", verification_status: "verified" })]); } }),
+        el("button", { title: "Reject", text: "✗", onclick: function () { edit([cellOp({ kind: "verify_table_cell", verification_status: "rejected" })]); } }),
+        el("button", {
+          "class": "danger", title: "Delete", text: "×",
+          onclick: function () { if (confirm("Delete this cell?")) edit([cellOp({ kind: "delete_table_cell" })]); }
+        })));
+  }
+
+  // edit applies operations and redraws the document from the response
+  function edit(operations) {
+    if (config.read_only) return;
+    var target = current;
+    say("Saving…");
+    api(docPath("/edits"), { operations: operations }).then(function (resp) {
+      applyResponse(target, resp, "Saved");
+    }).catch(fail);
+  }
+
+  function verifyDocument(status) {
+    var target = current;
+    var message = status === "rejected" ? prompt("Reason for rejecting (optional)") : "";
+    if (message === null) return;
+    api(docPath("/verify"), { verification_status: status, verification_message: message }).then(function (resp) {
+      applyResponse(target, resp, status === "verified" ? "Document verified" : "Document rejected");
+    }).catch(fail);
+  }
+
+  function applyResponse(target, resp, okMessage) {
+    if (current !== target) return; // the user moved on
+    if (resp.view) {
+      current.view = resp.view;
+      renderDocument(false);
+    }
+    if (resp.ok && !resp.error) {
+      say(okMessage, "ok");
+      return;
+    }
+    var failed = (resp.results || []).filter(function (r) { return r.error; }).map(function (r) { return r.error; });
+    say((failed[0] || resp.error) + (resp.rolled_back ? " (changes rolled back)" : ""), "error");
+  }
+
+  function route() {
+    say("");
+    var parts = location.hash.replace(/^#\/?/, "").split("/").filter(Boolean).map(decodeURIComponent);
+    if (parts[0] === "wf" && parts.length === 2) showDocuments(parts[1]);
+    else if (parts[0] === "wf" && parts.length === 3) showDocument(parts[1], parts[2]);
+    else showWorkflows();
+  }
+
+  window.addEventListener("hashchange", route);
+  route();
+})();
@@ -0,0 +1,553 @@
+// Package reviewui serves a browser-based review tool for Nanonets
+// documents. It shows each page with the extracted field and table boxes
+// drawn over it next to an editable panel, and applies every edit through
+// the Moderation service.
+//
+// The handler doesn't authenticate users; mount it behind the
+// authentication your internal tools already use:
+//
+//	ui := reviewui.New(client)
+//	ui.WorkflowIDs = []string{"wf_invoices"}
+//	http.Handle("/review/", http.StripPrefix("/review", requireLogin(ui)))
+package reviewui
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/render"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// MaxEditBytes is the largest edit request body the handler accepts
+const MaxEditBytes = 1 << 20
+
+// Handler is an http.Handler that serves the review UI and its JSON API:
+//
+//	GET  /                                           the UI
+//	GET  /api/workflows                              workflows to review
+//	GET  /api/workflows/{wf}/documents               documents in a workflow
+//	GET  /api/workflows/{wf}/documents/{doc}         a document with its overlays
+//	GET  /api/workflows/{wf}/documents/{doc}/pages/{page}/image
+//	                                                 the page image; ?annotated=png|svg
+//	                                                 draws the boxes on the server
+//	POST /api/workflows/{wf}/documents/{doc}/edits   apply {"operations": [...]}
+//	POST /api/workflows/{wf}/documents/{doc}/verify  verify or reject the document
+//
+// Edits are nanonets.Operation values applied as one ChangeSet, so they are
+// validated against the current document and rolled back together if one
+// fails. POST requests must have a JSON content type, which browsers don't
+// allow cross-origin forms to send.
+type Handler struct {
+	Workflows  nanonets.WorkflowsAPI
+	Documents  nanonets.DocumentsAPI
+	Moderation nanonets.ModerationAPI
+	// Renderer colors the overlays and loads and annotates page images
+	Renderer *render.Renderer
+	// WorkflowIDs restricts the handler to these workflows. If empty, any
+	// workflow the API key can access may be reviewed.
+	WorkflowIDs []string
+	// ReadOnly rejects edits and hides the editing controls
+	ReadOnly bool
+	// Title is shown in the page header
+	Title string
+
+	staticOnce sync.Once
+	static     http.Handler
+}
+
+// New creates a handler that uses the client's services
+func New(client *nanonets.Client) *Handler {
+	return &Handler{
+		Workflows:  client.Workflows,
+		Documents:  client.Documents,
+		Moderation: client.Moderation,
+		Renderer:   render.New(),
+		Title:      "Nanonets review",
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := "/" + strings.Trim(r.URL.Path, "/")
+	if path == "/api" || strings.HasPrefix(path, "/api/") {
+		h.serveAPI(w, r, strings.Split(strings.TrimPrefix(path, "/api/"), "/"))
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if path == "/" {
+		h.serveIndex(w)
+		return
+	}
+	h.staticHandler().ServeHTTP(w, r)
+}
+
+func (h *Handler) staticHandler() http.Handler {
+	h.staticOnce.Do(func() {
+		sub, err := fs.Sub(assets, "assets")
+		if err != nil {
+			panic(err)
+		}
+		h.static = http.FileServer(http.FS(sub))
+	})
+	return h.static
+}
+
+// serveIndex writes the UI page with the handler settings filled in
+func (h *Handler) serveIndex(w http.ResponseWriter) {
+	page, err := assets.ReadFile("assets/index.html")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	config, err := json.Marshal(map[string]interface{}{"title": h.Title, "read_only": h.ReadOnly})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	title := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(h.Title)
+	// The config is inside a <script> element, where "</" must not appear
+	html := strings.NewReplacer(
+		"{{title}}", title,
+		"{{config}}", strings.ReplaceAll(string(config), "</", `<\/`),
+	).Replace(string(page))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	io.WriteString(w, html)
+}
+
+func (h *Handler) serveAPI(w http.ResponseWriter, r *http.Request, parts []string) {
+	// workflows [/{wf} /documents [/{doc} [/edits | /verify | /pages/{page}/image]]]
+	if len(parts) == 0 || parts[0] != "workflows" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if len(parts) == 1 {
+		if allowMethod(w, r, http.MethodGet) {
+			h.listWorkflows(w)
+		}
+		return
+	}
+	if len(parts) < 3 || parts[2] != "documents" || !h.allowed(parts[1]) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	workflowID := parts[1]
+	switch {
+	case len(parts) == 3:
+		if allowMethod(w, r, http.MethodGet) {
+			h.listDocuments(w, r, workflowID)
+		}
+	case len(parts) == 4:
+		if allowMethod(w, r, http.MethodGet) {
+			h.getDocument(w, workflowID, parts[3])
+		}
+	case len(parts) == 5 && parts[4] == "edits":
+		if allowMethod(w, r, http.MethodPost) && h.allowEdit(w, r) {
+			h.applyEdits(w, r, workflowID, parts[3])
+		}
+	case len(parts) == 5 && parts[4] == "verify":
+		if allowMethod(w, r, http.MethodPost) && h.allowEdit(w, r) {
+			h.verifyDocument(w, r, workflowID, parts[3])
+		}
+	case len(parts) == 7 && parts[4] == "pages" && parts[6] == "image":
+		if allowMethod(w, r, http.MethodGet) {
+			h.pageImage(w, r, workflowID, parts[3], parts[5])
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (h *Handler) allowed(workflowID string) bool {
+	if workflowID == "" {
+		return false
+	}
+	if len(h.WorkflowIDs) == 0 {
+		return true
+	}
+	for _, id := range h.WorkflowIDs {
+		if id == workflowID {
+			return true
+		}
+	}
+	return false
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method || (method == http.MethodGet && r.Method == http.MethodHead) {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+// allowEdit rejects edits in read-only mode and requests that a plain HTML
+// form could have sent from another site
+func (h *Handler) allowEdit(w http.ResponseWriter, r *http.Request) bool {
+	if h.ReadOnly {
+		writeError(w, http.StatusForbidden, "the review UI is read-only")
+		return false
+	}
+	if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		writeError(w, http.StatusUnsupportedMediaType, "expected a JSON request body")
+		return false
+	}
+	return true
+}
+
+// workflowSummary is a workflow in the picker
+type workflowSummary struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+func (h *Handler) listWorkflows(w http.ResponseWriter) {
+	workflows, err := h.Workflows.List()
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	out := []workflowSummary{}
+	for _, wf := range workflows {
+		if len(h.WorkflowIDs) == 0 || h.allowed(wf.ID) {
+			out = append(out, workflowSummary{ID: wf.ID, Description: wf.Description})
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// documentSummary is a row of the document list
+type documentSummary struct {
+	DocumentID           string                      `json:"document_id"`
+	OriginalDocumentName string                      `json:"original_document_name"`
+	Status               nanonets.DocumentStatus     `json:"status"`
+	VerificationStatus   nanonets.VerificationStatus `json:"verification_status"`
+	UploadedAt           nanonets.Time               `json:"uploaded_at"`
+	Pages                int                         `json:"pages"`
+}
+
+// listDocuments lists a workflow's documents, oldest first. ?pending=1
+// limits the list to completed documents that aren't verified or
+// rejected.
+func (h *Handler) listDocuments(w http.ResponseWriter, r *http.Request, workflowID string) {
+	docs, err := h.Documents.List(workflowID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	pending := r.URL.Query().Get("pending") != ""
+	nanonets.SortDocumentsByUploadedAt(docs, false)
+	out := []documentSummary{}
+	for _, d := range docs {
+		if pending && (d.Status != nanonets.DocumentStatusCompleted || d.VerificationStatus.IsTerminal()) {
+			continue
+		}
+		out = append(out, documentSummary{
+			DocumentID:           d.DocumentID,
+			OriginalDocumentName: d.OriginalDocumentName,
+			Status:               d.Status,
+			VerificationStatus:   d.VerificationStatus,
+			UploadedAt:           d.UploadedAt,
+			Pages:                len(d.Pages),
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// documentView is a document with the boxes to draw over each page
+type documentView struct {
+	WorkflowID string               `json:"workflow_id"`
+	Document   *nanonets.Document   `json:"document"`
+	Overlays   map[string][]overlay `json:"overlays"`
+	ReadOnly   bool                 `json:"read_only"`
+}
+
+// overlay is a box drawn over a page image. Normalized boxes are given
+// as fractions of the page size, others in pixels.
+type overlay struct {
+	Kind       string     `json:"kind"`
+	ID         string     `json:"id"`
+	TableID    string     `json:"table_id,omitempty"`
+	Label      string     `json:"label"`
+	Box        [4]float64 `json:"box"`
+	Normalized bool       `json:"normalized"`
+	Color      string     `json:"color"`
+}
+
+func (h *Handler) view(workflowID string, doc *nanonets.Document) *documentView {
+	v := &documentView{WorkflowID: workflowID, Document: doc, Overlays: map[string][]overlay{}, ReadOnly: h.ReadOnly}
+	add := func(pageID, kind, id, tableID, label string, b nanonets.BBox, c color.NRGBA) {
+		v.Overlays[pageID] = append(v.Overlays[pageID], overlay{
+			Kind:       kind,
+			ID:         id,
+			TableID:    tableID,
+			Label:      label,
+			Box:        [4]float64{b.X1, b.Y1, b.X2, b.Y2},
+			Normalized: b.IsNormalized(),
+			Color:      hex(c),
+		})
+	}
+	// Tables come first so that fields are drawn on top of them
+	for _, p := range doc.Pages {
+		v.Overlays[p.PageID] = []overlay{}
+		for _, t := range p.Data.Tables {
+			if b, ok := t.Box(); ok {
+				add(p.PageID, "table", t.TableID, t.TableID, "", b, render.ColorTable)
+			}
+			for _, c := range t.Cells {
+				if b, ok := c.Box(); ok {
+					add(p.PageID, "cell", c.CellID, t.TableID, c.Header, b, h.Renderer.CellColor(c))
+				}
+			}
+		}
+		for _, name := range nanonets.FieldNames(p.Data.Fields) {
+			for _, f := range p.Data.Fields[name] {
+				if b, ok := f.Box(); ok {
+					add(p.PageID, "field", f.FieldDataID, "", name, b, h.Renderer.FieldColor(f))
+				}
+			}
+		}
+	}
+	return v
+}
+
+func (h *Handler) getDocument(w http.ResponseWriter, workflowID, documentID string) {
+	doc, err := h.Documents.Get(workflowID, documentID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.view(workflowID, doc))
+}
+
+// pageImage serves a page image as loaded or with the boxes drawn on it.
+// Only images are served, with sniffing disabled, since the image comes
+// from a URL in the document and is shown on this handler's origin.
+func (h *Handler) pageImage(w http.ResponseWriter, r *http.Request, workflowID, documentID, pageID string) {
+	format := r.URL.Query().Get("annotated")
+	switch format {
+	case "", "png", "svg":
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown format %q", format))
+		return
+	}
+	doc, err := h.Documents.Get(workflowID, documentID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	var page *nanonets.Page
+	for i := range doc.Pages {
+		if doc.Pages[i].PageID == pageID {
+			page = &doc.Pages[i]
+		}
+	}
+	if page == nil {
+		writeError(w, http.StatusNotFound, "page not found")
+		return
+	}
+	if page.ImageURL == "" {
+		writeError(w, http.StatusNotFound, "page has no image")
+		return
+	}
+	img, err := h.Renderer.LoadImage(page.ImageURL)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if !strings.HasPrefix(img.ContentType, "image/") {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("page image has content type %s", img.ContentType))
+		return
+	}
+
+	// Annotated images are rendered in full first, so that a failure can
+	// still be reported with an error status
+	contentType, body := img.ContentType, img.Data
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		contentType = "image/png"
+		err = h.Renderer.PNG(&buf, img, page.Data)
+		body = buf.Bytes()
+	case "svg":
+		contentType = "image/svg+xml"
+		err = h.Renderer.SVG(&buf, img, page.Data)
+		body = buf.Bytes()
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "rendering page image: "+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Write(body)
+}
+
+// editRequest is the body of an edits request
+type editRequest struct {
+	Operations []nanonets.Operation `json:"operations"`
+}
+
+// editResult reports the outcome of each operation
+type editResult struct {
+	Operation nanonets.Operation `json:"operation"`
+	Status    nanonets.OpStatus  `json:"status"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// editResponse is returned by the edits and verify endpoints along with
+// the document as it is after the edits
+type editResponse struct {
+	Results    []editResult  `json:"results,omitempty"`
+	RolledBack bool          `json:"rolled_back,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	View       *documentView `json:"view,omitempty"`
+}
+
+func (h *Handler) applyEdits(w http.ResponseWriter, r *http.Request, workflowID, documentID string) {
+	var req editRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Operations) == 0 {
+		writeError(w, http.StatusBadRequest, "no operations")
+		return
+	}
+	doc, err := h.Documents.Get(workflowID, documentID)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	cs := nanonets.NewChangeSet(workflowID, doc).Add(req.Operations...)
+	if err := cs.Validate(); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	result, applyErr := cs.Apply(r.Context(), h.Moderation, 1)
+	resp := editResponse{}
+	if result != nil {
+		resp.RolledBack = result.RolledBack
+		for _, res := range result.Results {
+			er := editResult{Operation: res.Operation, Status: res.Status}
+			if res.Err != nil {
+				er.Error = res.Err.Error()
+			}
+			resp.Results = append(resp.Results, er)
+		}
+	}
+	status := http.StatusOK
+	if applyErr != nil {
+		resp.Error = applyErr.Error()
+		status = statusFor(firstErr(result, applyErr))
+	}
+	h.respondWithDocument(w, r.Context(), status, workflowID, documentID, &resp)
+}
+
+// verifyDocument verifies or rejects the whole document
+func (h *Handler) verifyDocument(w http.ResponseWriter, r *http.Request, workflowID, documentID string) {
+	var req nanonets.VerifyDocumentRequest
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !req.VerificationStatus.IsValid() {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("invalid verification status %q", req.VerificationStatus))
+		return
+	}
+	if err := h.Moderation.VerifyDocument(workflowID, documentID, req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	h.respondWithDocument(w, r.Context(), http.StatusOK, workflowID, documentID, &editResponse{})
+}
+
+// respondWithDocument adds the refreshed document to a response. A failure
+// to fetch it is reported in the response rather than masking the
+// outcome of the edits.
+func (h *Handler) respondWithDocument(w http.ResponseWriter, ctx context.Context, status int, workflowID, documentID string, resp *editResponse) {
+	if ctx.Err() == nil {
+		doc, err := h.Documents.Get(workflowID, documentID)
+		if err == nil {
+			resp.View = h.view(workflowID, doc)
+		} else if resp.Error == "" {
+			resp.Error = "edits applied, but reloading the document failed: " + err.Error()
+		}
+	}
+	writeJSON(w, status, resp)
+}
+
+// firstErr returns the error of the first failed operation, which
+// carries the API status
+func firstErr(result *nanonets.ChangeSetResult, fallback error) error {
+	if result != nil {
+		if failed := result.Failed(); len(failed) > 0 {
+			return failed[0].Err
+		}
+	}
+	return fallback
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, MaxEditBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// statusFor passes client errors from the API through and reports other
+// failures as a bad gateway
+func statusFor(err error) int {
+	var apiErr *nanonets.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 {
+		return apiErr.StatusCode
+	}
+	return http.StatusBadGateway
+}
+
+func writeAPIError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	var apiErr *nanonets.APIError
+	if errors.As(err, &apiErr) && apiErr.Message != "" {
+		msg = apiErr.Message
+	}
+	writeError(w, statusFor(err), msg)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func hex(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,257 @@
+package reviewui_test
+
+import (
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+	"github.com/NanoNets/nanonets-go/nanonets/reviewui"
+)
+
+// fixture is a handler served over HTTP in front of a fake server
+type fixture struct {
+	s   *nanonetstest.Server
+	h   *reviewui.Handler
+	srv *httptest.Server
+	wf  *nanonets.Workflow
+	doc *nanonets.Document
+}
+
+// url returns the handler URL of a path below a document of the workflow
+func (f *fixture) url(doc *nanonets.Document, path string) string {
+	return f.srv.URL + "/api/workflows/" + f.wf.ID + "/documents/" + doc.DocumentID + path
+}
+
+// newHandler serves a handler for a fake server holding one workflow with
+// a document whose page image is a PNG file
+func newHandler(t *testing.T) *fixture {
+	t.Helper()
+	s := nanonetstest.NewServer()
+	t.Cleanup(s.Close)
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+
+	imagePath := filepath.Join(t.TempDir(), "page.png")
+	file, err := os.Create(imagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, 100, 100))); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	doc, err := s.AddDocument(wf.ID, nanonets.Document{Pages: []nanonets.Page{{ImageURL: imagePath, Data: nanonets.PageData{
+		Fields: map[string][]nanonets.FieldData{
+			"vendor": {{Value: "Acme", Bbox: []float64{10, 10, 50, 20}}},
+		},
+	}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := reviewui.New(s.Client())
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return &fixture{s: s, h: h, srv: srv, wf: wf, doc: doc}
+}
+
+// postEdits posts operations to the edits endpoint with a content type
+func (f *fixture) postEdits(t *testing.T, contentType string, ops ...nanonets.Operation) *http.Response {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"operations": ops})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(f.url(f.doc, "/edits"), contentType, strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// fieldValue returns the value of a field stored on the server
+func (f *fixture) fieldValue(t *testing.T, name string) string {
+	t.Helper()
+	got, ok := f.s.Document(f.doc.DocumentID)
+	if !ok {
+		t.Fatal("document gone")
+	}
+	return got.Pages[0].Data.Fields[name][0].Value
+}
+
+// updateField returns an operation that sets a field's value
+func updateField(doc *nanonets.Document, name, value string) nanonets.Operation {
+	page := doc.Pages[0]
+	return nanonets.Operation{Kind: nanonets.OpUpdateField, PageID: page.PageID, FieldDataID: page.Data.Fields[name][0].FieldDataID, Value: value}
+}
+
+func TestEditsRequireJSON(t *testing.T) {
+	f := newHandler(t)
+	resp := f.postEdits(t, "text/plain", updateField(f.doc, "vendor", "Globex"))
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("status %d, want %d", resp.StatusCode, http.StatusUnsupportedMediaType)
+	}
+	if got := f.fieldValue(t, "vendor"); got != "Acme" {
+		t.Errorf("vendor %q after a rejected edit", got)
+	}
+
+	resp = f.postEdits(t, "application/json; charset=utf-8", updateField(f.doc, "vendor", "Globex"))
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := f.fieldValue(t, "vendor"); got != "Globex" {
+		t.Errorf("vendor %q, want Globex", got)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	f := newHandler(t)
+	f.h.ReadOnly = true
+	resp := f.postEdits(t, "application/json", updateField(f.doc, "vendor", "Globex"))
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	if got := f.fieldValue(t, "vendor"); got != "Acme" {
+		t.Errorf("vendor %q after an edit of a read-only handler", got)
+	}
+	// Reads are still allowed
+	resp, err := http.Get(f.url(f.doc, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("document read: status %d", resp.StatusCode)
+	}
+}
+
+func TestWorkflowIDs(t *testing.T) {
+	f := newHandler(t)
+	other := f.s.AddWorkflow(nanonets.Workflow{Description: "Receipts"})
+	f.h.WorkflowIDs = []string{other.ID}
+
+	for _, url := range []string{
+		f.srv.URL + "/api/workflows/" + f.wf.ID + "/documents",
+		f.url(f.doc, ""),
+		f.url(f.doc, "/pages/"+f.doc.Pages[0].PageID+"/image"),
+	} {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want %d", url, resp.StatusCode, http.StatusNotFound)
+		}
+	}
+	resp := f.postEdits(t, "application/json", updateField(f.doc, "vendor", "Globex"))
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("edits: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	resp, err := http.Get(f.srv.URL + "/api/workflows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var workflows []struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&workflows); err != nil {
+		t.Fatal(err)
+	}
+	if len(workflows) != 1 || workflows[0].ID != other.ID {
+		t.Errorf("workflows %+v, want only %s", workflows, other.ID)
+	}
+}
+
+func TestEditsRollBack(t *testing.T) {
+	f := newHandler(t)
+	// Operations on one field are applied in order, so the edit is made
+	// before the verification fails
+	f.s.InjectFault(nanonetstest.Fault{Method: http.MethodPost, Path: "/workflows/*/documents/*/pages/*/fields/*/verify", Status: http.StatusConflict})
+	verify := updateField(f.doc, "vendor", "")
+	verify.Kind = nanonets.OpVerifyField
+	verify.VerificationStatus = nanonets.VerificationStatusVerified
+	resp := f.postEdits(t, "application/json", updateField(f.doc, "vendor", "Globex"), verify)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("status %d, want the API's %d", resp.StatusCode, http.StatusConflict)
+	}
+	var body struct {
+		RolledBack bool `json:"rolled_back"`
+		Results    []struct {
+			Status nanonets.OpStatus `json:"status"`
+		} `json:"results"`
+		Error string          `json:"error"`
+		View  json.RawMessage `json:"view"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if !body.RolledBack || body.Error == "" || len(body.View) == 0 {
+		t.Errorf("response %+v, want the rollback reported with the document", body)
+	}
+	if len(body.Results) != 2 || body.Results[0].Status != nanonets.OpRolledBack || body.Results[1].Status != nanonets.OpFailed {
+		t.Errorf("results %+v", body.Results)
+	}
+	if got := f.fieldValue(t, "vendor"); got != "Acme" {
+		t.Errorf("vendor %q, want the first edit rolled back", got)
+	}
+}
+
+func TestPageImage(t *testing.T) {
+	f := newHandler(t)
+	get := func(url string) (*http.Response, []byte) {
+		t.Helper()
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, data
+	}
+
+	page := f.url(f.doc, "/pages/"+f.doc.Pages[0].PageID+"/image")
+	for query, contentType := range map[string]string{"": "image/png", "?annotated=png": "image/png", "?annotated=svg": "image/svg+xml"} {
+		resp, data := get(page + query)
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != contentType || len(data) == 0 {
+			t.Errorf("%q: status %d, content type %q, %d bytes", query, resp.StatusCode, resp.Header.Get("Content-Type"), len(data))
+		}
+		if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("%q: X-Content-Type-Options %q", query, got)
+		}
+	}
+	if resp, _ := get(page + "?annotated=gif"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown format: status %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if resp, _ := get(f.url(f.doc, "/pages/p_missing/image")); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing page: status %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	// A page image that is not an image is never served
+	html := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(html, []byte("<html><script>alert(1)</script></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	other, err := f.s.AddDocument(f.wf.ID, nanonets.Document{Pages: []nanonets.Page{{ImageURL: html}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, data := get(f.url(other, "/pages/"+other.Pages[0].PageID+"/image"))
+	if resp.StatusCode != http.StatusBadGateway || strings.Contains(string(data), "<script>") {
+		t.Errorf("non-image page: status %d, body %s", resp.StatusCode, data)
+	}
+}
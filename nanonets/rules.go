@@ -0,0 +1,397 @@
+package nanonets
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rule names reported in Violation.Rule
+const (
+	RuleRequiredField  = "required_field"
+	RuleFieldSum       = "field_sum"
+	RuleLineItemsSum   = "line_items_sum"
+	RuleLineArithmetic = "line_arithmetic"
+)
+
+// DefaultTolerance is the absolute tolerance used when a rule is given a
+// tolerance of zero
+const DefaultTolerance = 0.01
+
+// Violation describes a single failed check. FieldDataID or CellID point at
+// the offending value so the violation can be passed to the Moderation API.
+// Expected and Actual are always reported, since zero is a valid amount;
+// they are both zero for rules that don't compare amounts.
+type Violation struct {
+	Rule        string  `json:"rule"`
+	Message     string  `json:"message"`
+	PageID      string  `json:"page_id,omitempty"`
+	FieldName   string  `json:"field_name,omitempty"`
+	FieldDataID string  `json:"field_data_id,omitempty"`
+	TableID     string  `json:"table_id,omitempty"`
+	CellID      string  `json:"cell_id,omitempty"`
+	Expected    float64 `json:"expected"`
+	Actual      float64 `json:"actual"`
+}
+
+// Report represents the result of validating a document
+type Report struct {
+	Violations []Violation `json:"violations"`
+}
+
+// OK reports whether the document passed every rule
+func (r *Report) OK() bool {
+	return len(r.Violations) == 0
+}
+
+// ForField returns the violations that point at a field value
+func (r *Report) ForField(fieldDataID string) []Violation {
+	var out []Violation
+	for _, v := range r.Violations {
+		if v.FieldDataID == fieldDataID {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// ForCell returns the violations that point at a table cell
+func (r *Report) ForCell(cellID string) []Violation {
+	var out []Violation
+	for _, v := range r.Violations {
+		if v.CellID == cellID {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Rule checks a document and returns any violations found
+type Rule interface {
+	Check(doc *Document) []Violation
+}
+
+// RuleFunc adapts an ordinary function to the Rule interface
+type RuleFunc func(doc *Document) []Violation
+
+// Check calls f(doc)
+func (f RuleFunc) Check(doc *Document) []Violation {
+	return f(doc)
+}
+
+// Validate runs every rule against a document and collects the violations
+func Validate(doc *Document, rules ...Rule) *Report {
+	report := &Report{}
+	for _, rule := range rules {
+		report.Violations = append(report.Violations, rule.Check(doc)...)
+	}
+	return report
+}
+
+// RequiredFields checks that each named field has a non-empty value
+func RequiredFields(names ...string) Rule {
+	return RuleFunc(func(doc *Document) []Violation {
+		var out []Violation
+		for _, name := range names {
+			ref, ok := findField(doc, name)
+			if !ok {
+				out = append(out, Violation{
+					Rule:      RuleRequiredField,
+					Message:   fmt.Sprintf("required field %q is missing", name),
+					FieldName: name,
+				})
+				continue
+			}
+			if strings.TrimSpace(ref.data.Value) == "" {
+				out = append(out, Violation{
+					Rule:        RuleRequiredField,
+					Message:     fmt.Sprintf("required field %q is empty", name),
+					PageID:      ref.pageID,
+					FieldName:   name,
+					FieldDataID: ref.data.FieldDataID,
+				})
+			}
+		}
+		return out
+	})
+}
+
+// FieldSum checks that the sum of the part fields matches the total field,
+// e.g. subtotal + tax = total. Missing parts count as zero; the rule is
+// skipped if the total field is missing.
+func FieldSum(totalField string, partFields []string, tolerance float64) Rule {
+	return RuleFunc(func(doc *Document) []Violation {
+		total, ok := findField(doc, totalField)
+		if !ok {
+			return nil
+		}
+		actual, ok := parseAmount(total.data.Value)
+		if !ok {
+			return nil
+		}
+		var expected float64
+		for _, name := range partFields {
+			if ref, ok := findField(doc, name); ok {
+				if v, ok := parseAmount(ref.data.Value); ok {
+					expected += v
+				}
+			}
+		}
+		if withinTolerance(expected, actual, tolerance) {
+			return nil
+		}
+		return []Violation{{
+			Rule:        RuleFieldSum,
+			Message:     fmt.Sprintf("%s (%s) does not equal %s (%s)", totalField, formatAmount(actual), strings.Join(partFields, " + "), formatAmount(expected)),
+			PageID:      total.pageID,
+			FieldName:   totalField,
+			FieldDataID: total.data.FieldDataID,
+			Expected:    expected,
+			Actual:      actual,
+		}}
+	})
+}
+
+// LineItemsSum checks that the sum of a table column across all line item
+// tables matches a field, e.g. sum(total) = subtotal
+func LineItemsSum(lineTotalHeader, targetField string, tolerance float64) Rule {
+	return RuleFunc(func(doc *Document) []Violation {
+		target, ok := findField(doc, targetField)
+		if !ok {
+			return nil
+		}
+		actual, ok := parseAmount(target.data.Value)
+		if !ok {
+			return nil
+		}
+		var expected float64
+		var found bool
+		for _, page := range doc.Pages {
+			for _, table := range page.Data.Tables {
+				for _, cell := range table.Cells {
+					if cell.Header != lineTotalHeader {
+						continue
+					}
+					if v, ok := parseAmount(cell.Text); ok {
+						expected += v
+						found = true
+					}
+				}
+			}
+		}
+		if !found || withinTolerance(expected, actual, tolerance) {
+			return nil
+		}
+		return []Violation{{
+			Rule:        RuleLineItemsSum,
+			Message:     fmt.Sprintf("%s (%s) does not equal the sum of %q line items (%s)", targetField, formatAmount(actual), lineTotalHeader, formatAmount(expected)),
+			PageID:      target.pageID,
+			FieldName:   targetField,
+			FieldDataID: target.data.FieldDataID,
+			Expected:    expected,
+			Actual:      actual,
+		}}
+	})
+}
+
+// LineArithmetic checks quantity × unit price = line total for every table
+// row that has all three columns
+func LineArithmetic(quantityHeader, unitPriceHeader, lineTotalHeader string, tolerance float64) Rule {
+	return RuleFunc(func(doc *Document) []Violation {
+		var out []Violation
+		for _, page := range doc.Pages {
+			for _, table := range page.Data.Tables {
+				rows := make(map[int]map[string]TableCell)
+				for _, cell := range table.Cells {
+					if rows[cell.Row] == nil {
+						rows[cell.Row] = make(map[string]TableCell)
+					}
+					rows[cell.Row][cell.Header] = cell
+				}
+				indexes := make([]int, 0, len(rows))
+				for row := range rows {
+					indexes = append(indexes, row)
+				}
+				sort.Ints(indexes)
+
+				for _, row := range indexes {
+					cells := rows[row]
+					qtyCell, ok1 := cells[quantityHeader]
+					priceCell, ok2 := cells[unitPriceHeader]
+					totalCell, ok3 := cells[lineTotalHeader]
+					if !ok1 || !ok2 || !ok3 {
+						continue
+					}
+					qty, ok1 := parseAmount(qtyCell.Text)
+					price, ok2 := parseAmount(priceCell.Text)
+					actual, ok3 := parseAmount(totalCell.Text)
+					if !ok1 || !ok2 || !ok3 {
+						continue
+					}
+					expected := qty * price
+					if withinTolerance(expected, actual, tolerance) {
+						continue
+					}
+					out = append(out, Violation{
+						Rule:     RuleLineArithmetic,
+						Message:  fmt.Sprintf("row %d: %s × %s = %s, but %s is %s", row, qtyCell.Text, priceCell.Text, formatAmount(expected), lineTotalHeader, formatAmount(actual)),
+						PageID:   page.PageID,
+						TableID:  table.TableID,
+						CellID:   totalCell.CellID,
+						Expected: expected,
+						Actual:   actual,
+					})
+				}
+			}
+		}
+		return out
+	})
+}
+
+// InvoiceRuleConfig names the fields and table headers used by InvoiceRules.
+// Empty names fall back to the defaults used in the README example.
+type InvoiceRuleConfig struct {
+	SubtotalField   string
+	TaxField        string
+	TotalField      string
+	QuantityHeader  string
+	UnitPriceHeader string
+	LineTotalHeader string
+	RequiredFields  []string
+	Tolerance       float64
+}
+
+// InvoiceRules returns the standard invoice reconciliation rules:
+// required fields, sum(line totals) = subtotal, subtotal + tax = total and
+// quantity × unit price = line total
+func InvoiceRules(cfg InvoiceRuleConfig) []Rule {
+	if cfg.SubtotalField == "" {
+		cfg.SubtotalField = "subtotal"
+	}
+	if cfg.TaxField == "" {
+		cfg.TaxField = "tax_amount"
+	}
+	if cfg.TotalField == "" {
+		cfg.TotalField = "total_amount"
+	}
+	if cfg.QuantityHeader == "" {
+		cfg.QuantityHeader = "quantity"
+	}
+	if cfg.UnitPriceHeader == "" {
+		cfg.UnitPriceHeader = "unit_price"
+	}
+	if cfg.LineTotalHeader == "" {
+		cfg.LineTotalHeader = "total"
+	}
+
+	rules := []Rule{
+		LineItemsSum(cfg.LineTotalHeader, cfg.SubtotalField, cfg.Tolerance),
+		FieldSum(cfg.TotalField, []string{cfg.SubtotalField, cfg.TaxField}, cfg.Tolerance),
+		LineArithmetic(cfg.QuantityHeader, cfg.UnitPriceHeader, cfg.LineTotalHeader, cfg.Tolerance),
+	}
+	if len(cfg.RequiredFields) > 0 {
+		rules = append([]Rule{RequiredFields(cfg.RequiredFields...)}, rules...)
+	}
+	return rules
+}
+
+// fieldRef locates a field value within a document
+type fieldRef struct {
+	pageID string
+	data   FieldData
+}
+
+// findField returns the first non-empty value of a field across all pages,
+// or the first value if all of them are empty
+func findField(doc *Document, name string) (fieldRef, bool) {
+	var first fieldRef
+	var found bool
+	for _, page := range doc.Pages {
+		for _, data := range page.Data.Fields[name] {
+			if strings.TrimSpace(data.Value) != "" {
+				return fieldRef{pageID: page.PageID, data: data}, true
+			}
+			if !found {
+				first = fieldRef{pageID: page.PageID, data: data}
+				found = true
+			}
+		}
+	}
+	return first, found
+}
+
+// FieldNames returns the names of a page's fields, such as
+// PageData.Fields, in sorted order
+func FieldNames(fields map[string][]FieldData) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseAmount parses a monetary or numeric value such as "$1,234.56",
+// "1.234,56 €" or "(12.00)"
+func parseAmount(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == ',':
+			b.WriteRune(r)
+		case r == '-':
+			negative = !negative
+		}
+	}
+	s = b.String()
+	if s == "" {
+		return 0, false
+	}
+
+	lastDot := strings.LastIndex(s, ".")
+	lastComma := strings.LastIndex(s, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastComma > lastDot {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case lastComma >= 0:
+		if strings.Count(s, ",") == 1 && len(s)-lastComma-1 != 3 {
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	if negative {
+		v = -v
+	}
+	return v, true
+}
+
+func withinTolerance(expected, actual, tolerance float64) bool {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	return math.Abs(expected-actual) <= tolerance+1e-9
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
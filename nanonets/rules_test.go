@@ -0,0 +1,120 @@
+package nanonets_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+// invoiceDocument builds a one-page invoice with the given field values and
+// line item rows of quantity, unit price and total
+func invoiceDocument(fields map[string]string, rows ...[3]string) *nanonets.Document {
+	page := nanonets.Page{PageID: "p1", Data: nanonets.PageData{Fields: map[string][]nanonets.FieldData{}}}
+	for name, value := range fields {
+		page.Data.Fields[name] = []nanonets.FieldData{{FieldDataID: "fd_" + name, Value: value}}
+	}
+	table := nanonets.Table{TableID: "t1"}
+	for i, row := range rows {
+		for j, header := range []string{"quantity", "unit_price", "total"} {
+			table.Cells = append(table.Cells, nanonets.TableCell{
+				CellID: fmt.Sprintf("c%d%d", i, j),
+				Row:    i,
+				Col:    j,
+				Header: header,
+				Text:   row[j],
+			})
+		}
+	}
+	if len(rows) > 0 {
+		page.Data.Tables = []nanonets.Table{table}
+	}
+	return &nanonets.Document{Pages: []nanonets.Page{page}}
+}
+
+func TestInvoiceRulesPass(t *testing.T) {
+	doc := invoiceDocument(map[string]string{
+		"invoice_number": "INV-1",
+		"subtotal":       "$1,234.50",
+		"tax_amount":     "123.45",
+		"total_amount":   "1.357,95 €",
+	}, [3]string{"2", "500.00", "1,000.00"}, [3]string{"1", "234.50", "234.50"})
+
+	report := nanonets.Validate(doc, nanonets.InvoiceRules(nanonets.InvoiceRuleConfig{RequiredFields: []string{"invoice_number"}})...)
+	if !report.OK() {
+		t.Errorf("violations on a consistent invoice: %+v", report.Violations)
+	}
+}
+
+func TestInvoiceRulesViolations(t *testing.T) {
+	doc := invoiceDocument(map[string]string{
+		"subtotal":     "1200.00",
+		"tax_amount":   "(10.00)",
+		"total_amount": "1200.00",
+	}, [3]string{"2", "500.00", "900.00"}, [3]string{"1", "234.50", "234.50"})
+
+	report := nanonets.Validate(doc, nanonets.InvoiceRules(nanonets.InvoiceRuleConfig{RequiredFields: []string{"invoice_number"}})...)
+	byRule := make(map[string]nanonets.Violation)
+	for _, v := range report.Violations {
+		byRule[v.Rule] = v
+	}
+	if len(report.Violations) != 4 {
+		t.Errorf("got %d violations, want 4: %+v", len(report.Violations), report.Violations)
+	}
+
+	if v := byRule[nanonets.RuleRequiredField]; v.FieldName != "invoice_number" {
+		t.Errorf("required field violation %+v", v)
+	}
+	// sum(line totals) = 1134.50, but subtotal is 1200.00
+	if v := byRule[nanonets.RuleLineItemsSum]; v.FieldDataID != "fd_subtotal" || v.Expected != 1134.5 || v.Actual != 1200 {
+		t.Errorf("line items sum violation %+v", v)
+	}
+	// subtotal + tax = 1190.00, where (10.00) is negative
+	if v := byRule[nanonets.RuleFieldSum]; v.FieldDataID != "fd_total_amount" || v.Expected != 1190 || v.Actual != 1200 {
+		t.Errorf("field sum violation %+v", v)
+	}
+	// 2 × 500.00 = 1000.00, but the line total is 900.00
+	if v := byRule[nanonets.RuleLineArithmetic]; v.CellID != "c02" || v.TableID != "t1" || v.Expected != 1000 || v.Actual != 900 {
+		t.Errorf("line arithmetic violation %+v", v)
+	}
+	if got := report.ForCell("c02"); len(got) != 1 {
+		t.Errorf("ForCell returned %d violations, want 1", len(got))
+	}
+	if got := report.ForField("fd_total_amount"); len(got) != 1 {
+		t.Errorf("ForField returned %d violations, want 1", len(got))
+	}
+}
+
+func TestFieldSumTolerance(t *testing.T) {
+	doc := invoiceDocument(map[string]string{"subtotal": "10.00", "tax_amount": "1.00", "total_amount": "11.04"})
+	rule := nanonets.FieldSum("total_amount", []string{"subtotal", "tax_amount"}, 0)
+	if got := rule.Check(doc); len(got) != 1 {
+		t.Errorf("default tolerance: %d violations, want 1", len(got))
+	}
+	rule = nanonets.FieldSum("total_amount", []string{"subtotal", "tax_amount"}, 0.05)
+	if got := rule.Check(doc); len(got) != 0 {
+		t.Errorf("tolerance 0.05: %d violations, want 0", len(got))
+	}
+}
+
+func TestViolationJSONKeepsZeroAmounts(t *testing.T) {
+	doc := invoiceDocument(map[string]string{"subtotal": "0", "tax_amount": "0", "total_amount": "5.00"})
+	report := nanonets.Validate(doc, nanonets.FieldSum("total_amount", []string{"subtotal", "tax_amount"}, 0))
+	data, err := json.Marshal(report.Violations[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"expected":0`) {
+		t.Errorf("violation JSON %s has no zero expected amount", data)
+	}
+}
+
+func TestFieldNames(t *testing.T) {
+	fields := map[string][]nanonets.FieldData{"vendor": nil, "total": nil, "invoice_number": nil}
+	got := nanonets.FieldNames(fields)
+	if want := []string{"invoice_number", "total", "vendor"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("FieldNames = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,261 @@
+package nanonets
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType is the data type of a field or table header
+type FieldType string
+
+// Field data types understood by SchemaRule
+const (
+	FieldTypeString   FieldType = "string"
+	FieldTypeNumber   FieldType = "number"
+	FieldTypeCurrency FieldType = "currency"
+	FieldTypeDate     FieldType = "date"
+	FieldTypeBoolean  FieldType = "boolean"
+	FieldTypeEmail    FieldType = "email"
+)
+
+// Rule names reported by SchemaRule
+const (
+	RuleSchemaRequired      = "schema_required"
+	RuleSchemaType          = "schema_type"
+	RuleSchemaAllowedValues = "schema_allowed_values"
+	RuleSchemaPattern       = "schema_pattern"
+)
+
+// dateLayouts are the date formats accepted for FieldTypeDate
+var dateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006/01/02",
+	"01/02/2006",
+	"02/01/2006",
+	"02.01.2006",
+	"01-02-2006",
+	"02-01-2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"02-Jan-2006",
+}
+
+// definition is the part of a Field or TableHeader that SchemaRule checks
+type definition struct {
+	name          string
+	typ           FieldType
+	required      bool
+	allowedValues []string
+	pattern       *regexp.Regexp
+}
+
+func newDefinition(name string, typ FieldType, required bool, allowed []string, pattern string) (definition, error) {
+	def := definition{name: name, typ: typ, required: required, allowedValues: allowed}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return def, fmt.Errorf("field %q: invalid pattern: %w", name, err)
+		}
+		def.pattern = re
+	}
+	switch typ {
+	case "", FieldTypeString, FieldTypeNumber, FieldTypeCurrency, FieldTypeDate, FieldTypeBoolean, FieldTypeEmail:
+	default:
+		return def, fmt.Errorf("field %q: unknown type %q", name, typ)
+	}
+	return def, nil
+}
+
+// check returns the rule and message of the first problem with a
+// non-empty value
+func (def definition) check(value string) (string, string) {
+	value = strings.TrimSpace(value)
+	if !valueHasType(value, def.typ) {
+		return RuleSchemaType, fmt.Sprintf("%s: %q is not a valid %s", def.name, value, def.typ)
+	}
+	if len(def.allowedValues) > 0 {
+		allowed := false
+		for _, v := range def.allowedValues {
+			if strings.EqualFold(v, value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return RuleSchemaAllowedValues, fmt.Sprintf("%s: %q is not one of %s", def.name, value, strings.Join(def.allowedValues, ", "))
+		}
+	}
+	if def.pattern != nil && !def.pattern.MatchString(value) {
+		return RuleSchemaPattern, fmt.Sprintf("%s: %q does not match %s", def.name, value, def.pattern)
+	}
+	return "", ""
+}
+
+func valueHasType(value string, typ FieldType) bool {
+	switch typ {
+	case FieldTypeNumber:
+		_, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+		return err == nil
+	case FieldTypeCurrency:
+		_, ok := parseAmount(value)
+		return ok
+	case FieldTypeDate:
+		for _, layout := range dateLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				return true
+			}
+		}
+		return false
+	case FieldTypeBoolean:
+		switch strings.ToLower(value) {
+		case "true", "false", "yes", "no", "y", "n", "1", "0":
+			return true
+		}
+		return false
+	case FieldTypeEmail:
+		_, err := mail.ParseAddress(value)
+		return err == nil
+	}
+	return true
+}
+
+// SchemaRule returns a rule that checks a document against field and table
+// header definitions: required fields and columns must have a value, and
+// every value must match its type, allowed values and pattern. It returns
+// an error if a definition has an unknown type or an invalid pattern.
+func SchemaRule(fields []Field, headers []TableHeader) (Rule, error) {
+	var fieldDefs, headerDefs []definition
+	for _, f := range fields {
+		def, err := newDefinition(f.Name, f.Type, f.Required, f.AllowedValues, f.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		fieldDefs = append(fieldDefs, def)
+	}
+	for _, h := range headers {
+		def, err := newDefinition(h.Name, h.Type, h.Required, h.AllowedValues, h.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		headerDefs = append(headerDefs, def)
+	}
+
+	return RuleFunc(func(doc *Document) []Violation {
+		var out []Violation
+		for _, def := range fieldDefs {
+			found := false
+			for _, page := range doc.Pages {
+				for _, data := range page.Data.Fields[def.name] {
+					if strings.TrimSpace(data.Value) == "" {
+						continue
+					}
+					found = true
+					if rule, msg := def.check(data.Value); rule != "" {
+						out = append(out, Violation{
+							Rule:        rule,
+							Message:     msg,
+							PageID:      page.PageID,
+							FieldName:   def.name,
+							FieldDataID: data.FieldDataID,
+						})
+					}
+				}
+			}
+			if def.required && !found {
+				v := Violation{
+					Rule:      RuleSchemaRequired,
+					Message:   fmt.Sprintf("required field %q has no value", def.name),
+					FieldName: def.name,
+				}
+				if ref, ok := findField(doc, def.name); ok {
+					v.PageID = ref.pageID
+					v.FieldDataID = ref.data.FieldDataID
+				}
+				out = append(out, v)
+			}
+		}
+
+		for _, page := range doc.Pages {
+			for _, table := range page.Data.Tables {
+				out = append(out, checkTable(page.PageID, table, headerDefs)...)
+			}
+		}
+		return out
+	}), nil
+}
+
+// checkTable checks the cells of a table against the header definitions.
+// A required column must have a value in every row of a table that uses
+// any of the defined headers.
+func checkTable(pageID string, table Table, defs []definition) []Violation {
+	defined := make(map[string]bool)
+	for _, def := range defs {
+		defined[def.name] = true
+	}
+	relevant := false
+	rows := make(map[int]map[string]TableCell)
+	for _, cell := range table.Cells {
+		relevant = relevant || defined[cell.Header]
+		if rows[cell.Row] == nil {
+			rows[cell.Row] = make(map[string]TableCell)
+		}
+		rows[cell.Row][cell.Header] = cell
+	}
+	if !relevant {
+		return nil
+	}
+	indexes := make([]int, 0, len(rows))
+	for row := range rows {
+		indexes = append(indexes, row)
+	}
+	sort.Ints(indexes)
+
+	var out []Violation
+	for _, row := range indexes {
+		cells := rows[row]
+		for _, def := range defs {
+			cell, ok := cells[def.name]
+			if !ok || strings.TrimSpace(cell.Text) == "" {
+				if def.required {
+					out = append(out, Violation{
+						Rule:    RuleSchemaRequired,
+						Message: fmt.Sprintf("row %d: required column %q has no value", row, def.name),
+						PageID:  pageID,
+						TableID: table.TableID,
+						CellID:  cell.CellID,
+					})
+				}
+				continue
+			}
+			if rule, msg := def.check(cell.Text); rule != "" {
+				out = append(out, Violation{
+					Rule:    rule,
+					Message: fmt.Sprintf("row %d: %s", row, msg),
+					PageID:  pageID,
+					TableID: table.TableID,
+					CellID:  cell.CellID,
+				})
+			}
+		}
+	}
+	return out
+}
+
+// ValidateDocument checks a document against the workflow's field and table
+// header definitions
+func (wf *Workflow) ValidateDocument(doc *Document) (*Report, error) {
+	rule, err := SchemaRule(wf.Fields, wf.TableHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return Validate(doc, rule), nil
+}
@@ -0,0 +1,130 @@
+package nanonets_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+func TestSchemaRuleTypes(t *testing.T) {
+	tests := []struct {
+		typ   nanonets.FieldType
+		valid []string
+		bad   []string
+	}{
+		{nanonets.FieldTypeNumber, []string{"42", "1,234.5", " -3 "}, []string{"12abc", "N/A"}},
+		{nanonets.FieldTypeCurrency, []string{"$1,234.50", "1.234,50 €", "(10.00)"}, []string{"ten dollars"}},
+		{nanonets.FieldTypeDate, []string{"2024-01-31", "31.01.2024", "Jan 31, 2024", "2024-01-31T10:00:00Z"}, []string{"2024-13-45", "yesterday"}},
+		{nanonets.FieldTypeBoolean, []string{"Yes", "false", "0"}, []string{"maybe"}},
+		{nanonets.FieldTypeEmail, []string{"ap@example.com", "Accounts <ap@example.com>"}, []string{"ap.example.com"}},
+		{nanonets.FieldTypeString, []string{"anything"}, nil},
+	}
+	for _, tt := range tests {
+		rule, err := nanonets.SchemaRule([]nanonets.Field{{Name: "value", Type: tt.typ}}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range tt.valid {
+			if got := rule.Check(invoiceDocument(map[string]string{"value": v})); len(got) != 0 {
+				t.Errorf("%s %q: %+v, want valid", tt.typ, v, got)
+			}
+		}
+		for _, v := range tt.bad {
+			got := rule.Check(invoiceDocument(map[string]string{"value": v}))
+			if len(got) != 1 || got[0].Rule != nanonets.RuleSchemaType || got[0].FieldDataID != "fd_value" {
+				t.Errorf("%s %q: %+v, want one schema_type violation", tt.typ, v, got)
+			}
+		}
+	}
+}
+
+func TestSchemaRuleFields(t *testing.T) {
+	rule, err := nanonets.SchemaRule([]nanonets.Field{
+		{Name: "invoice_number", Required: true, Pattern: `^INV-\d+$`},
+		{Name: "currency", AllowedValues: []string{"USD", "EUR"}},
+		{Name: "po_number", Required: true},
+		{Name: "notes"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rule.Check(invoiceDocument(map[string]string{
+		"invoice_number": "12345",
+		"currency":       "usd",
+		"po_number":      "  ",
+	}))
+	rules := make(map[string]string)
+	for _, v := range got {
+		rules[v.FieldName] = v.Rule
+	}
+	want := map[string]string{
+		"invoice_number": nanonets.RuleSchemaPattern,
+		"po_number":      nanonets.RuleSchemaRequired,
+	}
+	if len(rules) != len(want) || rules["invoice_number"] != want["invoice_number"] || rules["po_number"] != want["po_number"] {
+		t.Errorf("violations %+v, want %v", got, want)
+	}
+	// The required violation points at the blank value so it can be
+	// flagged in the document
+	for _, v := range got {
+		if v.FieldName == "po_number" && v.FieldDataID != "fd_po_number" {
+			t.Errorf("required violation %+v has no field data ID", v)
+		}
+	}
+
+	got = rule.Check(invoiceDocument(map[string]string{"invoice_number": "INV-1", "po_number": "1", "currency": "GBP"}))
+	if len(got) != 1 || got[0].Rule != nanonets.RuleSchemaAllowedValues {
+		t.Errorf("violations %+v, want one schema_allowed_values", got)
+	}
+}
+
+func TestSchemaRuleTables(t *testing.T) {
+	rule, err := nanonets.SchemaRule(nil, []nanonets.TableHeader{
+		{Name: "quantity", Type: nanonets.FieldTypeNumber},
+		{Name: "total", Type: nanonets.FieldTypeCurrency, Required: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := invoiceDocument(nil, [3]string{"two", "5.00", "10.00"}, [3]string{"1", "5.00", ""})
+	got := rule.Check(doc)
+	if len(got) != 2 {
+		t.Fatalf("violations %+v, want 2", got)
+	}
+	if got[0].Rule != nanonets.RuleSchemaType || got[0].CellID != "c00" || !strings.HasPrefix(got[0].Message, "row 0:") {
+		t.Errorf("first violation %+v, want a schema_type violation on c00", got[0])
+	}
+	if got[1].Rule != nanonets.RuleSchemaRequired || got[1].CellID != "c12" || got[1].TableID != "t1" {
+		t.Errorf("second violation %+v, want a schema_required violation on c12", got[1])
+	}
+
+	// Tables that use none of the defined headers are not checked
+	other, err := nanonets.SchemaRule(nil, []nanonets.TableHeader{{Name: "sku", Required: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := other.Check(doc); len(got) != 0 {
+		t.Errorf("violations %+v on an unrelated table", got)
+	}
+}
+
+func TestSchemaRuleInvalidDefinition(t *testing.T) {
+	if _, err := nanonets.SchemaRule([]nanonets.Field{{Name: "total", Type: "money"}}, nil); err == nil {
+		t.Error("unknown type accepted")
+	}
+	if _, err := nanonets.SchemaRule(nil, []nanonets.TableHeader{{Name: "sku", Pattern: "("}}); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
+
+func TestWorkflowValidateDocument(t *testing.T) {
+	wf := &nanonets.Workflow{Fields: []nanonets.Field{{Name: "total_amount", Type: nanonets.FieldTypeCurrency, Required: true}}}
+	report, err := wf.ValidateDocument(invoiceDocument(map[string]string{"total_amount": "$12.00"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Errorf("violations %+v on a valid document", report.Violations)
+	}
+}
@@ -0,0 +1,181 @@
+package nanonets
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Bool returns a pointer to v, for use in UpdateSettingsRequest
+func Bool(v bool) *bool {
+	return &v
+}
+
+// String returns a pointer to v, for use in UpdateFieldRequest
+func String(v string) *string {
+	return &v
+}
+
+// UnmarshalJSON keeps unknown settings in Extra
+func (s *WorkflowSettings) UnmarshalJSON(data []byte) error {
+	type plain WorkflowSettings
+	var p plain
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*s = WorkflowSettings(p)
+	s.Extra = extra
+	return nil
+}
+
+// MarshalJSON writes the modeled settings followed by those in Extra
+func (s WorkflowSettings) MarshalJSON() ([]byte, error) {
+	type plain WorkflowSettings
+	return marshalExtra(plain(s), s.Extra)
+}
+
+// Get decodes the setting with the given JSON key into v. It reports
+// whether the setting is present.
+func (s WorkflowSettings) Get(key string, v interface{}) (bool, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return false, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return false, err
+	}
+	raw, ok := all[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// UpdateRequest returns a request that sets every setting, including those
+// in Extra, to its current value, so that a Get → modify → UpdateSettings
+// round trip doesn't drop settings the SDK doesn't model
+func (s WorkflowSettings) UpdateRequest() UpdateSettingsRequest {
+	req := UpdateSettingsRequest{TableCapture: Bool(s.TableCapture)}
+	for key, value := range s.Extra {
+		if req.Extra == nil {
+			req.Extra = make(map[string]json.RawMessage)
+		}
+		req.Extra[key] = value
+	}
+	return req
+}
+
+// Set sets a setting by its JSON key. Modeled settings are set on their
+// field; any other key goes to Extra.
+func (r *UpdateSettingsRequest) Set(key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if knownKeys(reflect.TypeOf(*r))[key] {
+		patch, err := json.Marshal(map[string]json.RawMessage{key: raw})
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(patch, r)
+	}
+	if r.Extra == nil {
+		r.Extra = make(map[string]json.RawMessage)
+	}
+	r.Extra[key] = raw
+	return nil
+}
+
+// UnmarshalJSON keeps settings the SDK doesn't model in Extra
+func (r *UpdateSettingsRequest) UnmarshalJSON(data []byte) error {
+	type plain UpdateSettingsRequest
+	p := plain(*r)
+	extra, err := unmarshalExtra(data, &p)
+	if err != nil {
+		return err
+	}
+	*r = UpdateSettingsRequest(p)
+	for key, value := range extra {
+		if r.Extra == nil {
+			r.Extra = make(map[string]json.RawMessage)
+		}
+		r.Extra[key] = value
+	}
+	return nil
+}
+
+// MarshalJSON writes only the settings that are set
+func (r UpdateSettingsRequest) MarshalJSON() ([]byte, error) {
+	type plain UpdateSettingsRequest
+	return marshalExtra(plain(r), r.Extra)
+}
+
+// Empty reports whether the request sets no settings
+func (r UpdateSettingsRequest) Empty() bool {
+	data, err := json.Marshal(r)
+	return err == nil && string(data) == "{}"
+}
+
+// changes returns the settings in r whose value differs from current, as
+// a request containing only those settings
+func (r UpdateSettingsRequest) changes(current WorkflowSettings) (UpdateSettingsRequest, error) {
+	var diff UpdateSettingsRequest
+	desired, err := json.Marshal(r)
+	if err != nil {
+		return diff, err
+	}
+	var want map[string]json.RawMessage
+	if err := json.Unmarshal(desired, &want); err != nil {
+		return diff, err
+	}
+
+	keys := make([]string, 0, len(want))
+	for key := range want {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var have, target interface{}
+		if ok, err := current.Get(key, &have); err != nil {
+			return diff, err
+		} else if ok {
+			if err := json.Unmarshal(want[key], &target); err != nil {
+				return diff, err
+			}
+			if reflect.DeepEqual(have, target) {
+				continue
+			}
+		}
+		if err := diff.Set(key, want[key]); err != nil {
+			return diff, err
+		}
+	}
+	return diff, nil
+}
+
+// describe formats the settings in r for a plan
+func (r UpdateSettingsRequest) describe(current WorkflowSettings) (string, string) {
+	data, _ := json.Marshal(r)
+	var want map[string]json.RawMessage
+	json.Unmarshal(data, &want)
+
+	keys := make([]string, 0, len(want))
+	for key := range want {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var from, to []string
+	for _, key := range keys {
+		var have json.RawMessage
+		if ok, _ := current.Get(key, &have); ok {
+			from = append(from, key+"="+string(have))
+		} else {
+			from = append(from, key+"=(unset)")
+		}
+		to = append(to, key+"="+string(want[key]))
+	}
+	return strings.Join(from, ", "), strings.Join(to, ", ")
+}
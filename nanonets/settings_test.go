@@ -0,0 +1,71 @@
+package nanonets_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+func TestUpdateSettingsRequestPatch(t *testing.T) {
+	tests := []struct {
+		req  nanonets.UpdateSettingsRequest
+		want string
+	}{
+		{nanonets.UpdateSettingsRequest{}, `{}`},
+		{nanonets.UpdateSettingsRequest{TableCapture: nanonets.Bool(false)}, `{"table_capture":false}`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("got %s, want %s", data, tt.want)
+		}
+	}
+
+	var req nanonets.UpdateSettingsRequest
+	if err := req.Set("table_capture", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Set("custom_setting", []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if req.TableCapture == nil || !*req.TableCapture {
+		t.Error("Set did not set the modeled field")
+	}
+	data, _ := json.Marshal(req)
+	if want := `{"table_capture":true,"custom_setting":["a"]}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestWorkflowSettingsRoundTrip(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	c := s.Client()
+	wf := s.AddWorkflow(nanonets.Workflow{Description: "Invoices"})
+	if err := c.Workflows.UpdateSettings(wf.ID, nanonets.UpdateSettingsRequest{
+		TableCapture: nanonets.Bool(true),
+		Extra:        map[string]json.RawMessage{"custom_setting": json.RawMessage(`{"enabled":true}`)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.Workflows.Get(wf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var custom struct{ Enabled bool }
+	if ok, err := got.Settings.Get("custom_setting", &custom); !ok || err != nil || !custom.Enabled {
+		t.Errorf("custom_setting = %+v, %v, %v after update", custom, ok, err)
+	}
+
+	// A Get → UpdateSettings round trip sends back every setting
+	data, _ := json.Marshal(got.Settings.UpdateRequest())
+	if want := `{"table_capture":true,"custom_setting":{"enabled":true}}`; string(data) != want {
+		t.Errorf("update request %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,453 @@
+package nanonets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrApplyCancelled is returned by Apply when the confirmation step
+// declines the plan
+var ErrApplyCancelled = errors.New("nanonets: apply cancelled")
+
+// WorkflowSpec declares the desired configuration of a workflow. Specs use
+// the same keys as the API in both JSON and YAML. Only the settings listed
+// in Settings are managed; any other setting is left as it is.
+type WorkflowSpec struct {
+	Description  string                 `json:"description"`
+	WorkflowType string                 `json:"workflow_type"`
+	Fields       []FieldSpec            `json:"fields"`
+	TableHeaders []FieldSpec            `json:"table_headers"`
+	Settings     *UpdateSettingsRequest `json:"settings,omitempty"`
+}
+
+// FieldSpec declares a field or table header. RenamedFrom lists names the
+// field had before, so that a rename is planned instead of a delete and a
+// create. Attributes left empty, and Required when false, are not
+// managed: a plan never changes them on an existing field.
+type FieldSpec struct {
+	Name          string    `json:"name"`
+	Type          FieldType `json:"type,omitempty"`
+	Required      bool      `json:"required,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	AllowedValues []string  `json:"allowed_values,omitempty"`
+	Pattern       string    `json:"pattern,omitempty"`
+	RenamedFrom   []string  `json:"renamed_from,omitempty"`
+}
+
+// Field returns the field the spec declares
+func (f FieldSpec) Field() Field {
+	return Field{
+		Name:          f.Name,
+		Type:          f.Type,
+		Required:      f.Required,
+		Description:   f.Description,
+		AllowedValues: f.AllowedValues,
+		Pattern:       f.Pattern,
+	}
+}
+
+// TableHeader returns the table header the spec declares
+func (f FieldSpec) TableHeader() TableHeader {
+	return TableHeader(f.Field())
+}
+
+// LoadWorkflowSpec reads a spec from a .json, .yaml or .yml file
+func LoadWorkflowSpec(path string) (*WorkflowSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format := "json"
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = "yaml"
+	}
+	spec, err := ParseWorkflowSpec(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// ParseWorkflowSpec decodes a spec in the given format ("json" or "yaml")
+func ParseWorkflowSpec(data []byte, format string) (*WorkflowSpec, error) {
+	if format == "yaml" {
+		// Convert YAML to JSON so that both formats share the JSON field names
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	} else if format != "json" {
+		return nil, fmt.Errorf("unsupported spec format %q", format)
+	}
+
+	var spec WorkflowSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	return &spec, spec.validate()
+}
+
+func (s *WorkflowSpec) validate() error {
+	for _, list := range []struct {
+		kind   string
+		fields []FieldSpec
+	}{{"field", s.Fields}, {"table header", s.TableHeaders}} {
+		seen := make(map[string]bool)
+		for _, f := range list.fields {
+			if f.Name == "" {
+				return fmt.Errorf("%s without a name", list.kind)
+			}
+			if seen[f.Name] {
+				return fmt.Errorf("duplicate %s %q", list.kind, f.Name)
+			}
+			seen[f.Name] = true
+		}
+	}
+	return nil
+}
+
+// PlanAction identifies a change in a Plan
+type PlanAction string
+
+// Changes a Plan can contain
+const (
+	PlanCreateWorkflow PlanAction = "create_workflow"
+	PlanCreate         PlanAction = "create"
+	PlanRename         PlanAction = "rename"
+	PlanUpdate         PlanAction = "update"
+	PlanDelete         PlanAction = "delete"
+	PlanUpdateSettings PlanAction = "update_settings"
+	PlanUpdateMetadata PlanAction = "update_metadata"
+)
+
+// PlanChange is a single change needed to bring a workflow in line with
+// its spec. Kind is "field" or "table_header" for field changes.
+type PlanChange struct {
+	Action PlanAction `json:"action"`
+	Kind   string     `json:"kind,omitempty"`
+	ID     string     `json:"id,omitempty"`
+	From   string     `json:"from,omitempty"`
+	To     string     `json:"to,omitempty"`
+}
+
+// Plan lists the changes needed to bring a workflow in line with a spec
+type Plan struct {
+	WorkflowID string       `json:"workflow_id,omitempty"`
+	Spec       WorkflowSpec `json:"spec"`
+	Changes    []PlanChange `json:"changes"`
+
+	current *Workflow
+}
+
+// Empty reports whether the workflow already matches the spec
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String returns the plan in a human-readable form
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "No changes. The workflow matches the spec.\n"
+	}
+	var b strings.Builder
+	for _, c := range p.Changes {
+		kind := strings.ReplaceAll(c.Kind, "_", " ")
+		switch c.Action {
+		case PlanCreateWorkflow:
+			fmt.Fprintf(&b, "+ workflow %q (type %q)\n", c.To, c.From)
+		case PlanCreate:
+			fmt.Fprintf(&b, "+ %s %q\n", kind, c.To)
+		case PlanRename:
+			fmt.Fprintf(&b, "~ %s %q -> %q\n", kind, c.From, c.To)
+		case PlanDelete:
+			fmt.Fprintf(&b, "- %s %q\n", kind, c.From)
+		case PlanUpdate:
+			fmt.Fprintf(&b, "~ %s %q definition\n", kind, c.To)
+		case PlanUpdateSettings:
+			fmt.Fprintf(&b, "~ settings %s -> %s\n", c.From, c.To)
+		case PlanUpdateMetadata:
+			fmt.Fprintf(&b, "~ description %q -> %q\n", c.From, c.To)
+		}
+	}
+	var creates, renames, deletes, updates int
+	for _, c := range p.Changes {
+		switch c.Action {
+		case PlanCreateWorkflow, PlanCreate:
+			creates++
+		case PlanRename:
+			renames++
+		case PlanDelete:
+			deletes++
+		default:
+			updates++
+		}
+	}
+	fmt.Fprintf(&b, "\nPlan: %d to create, %d to rename, %d to update, %d to delete.\n", creates, renames, updates, deletes)
+	return b.String()
+}
+
+// Plan compares a spec with the current state of a workflow and lists the
+// changes needed to apply it. If workflowID is empty the plan creates a
+// new workflow.
+func (w *Workflows) Plan(workflowID string, spec WorkflowSpec) (*Plan, error) {
+	return PlanWorkflow(w, workflowID, spec)
+}
+
+// PlanWorkflow is Plan through any WorkflowsAPI, such as a stub
+func PlanWorkflow(w WorkflowsAPI, workflowID string, spec WorkflowSpec) (*Plan, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	plan := &Plan{WorkflowID: workflowID, Spec: spec}
+
+	current := &Workflow{}
+	if workflowID == "" {
+		plan.Changes = append(plan.Changes, PlanChange{Action: PlanCreateWorkflow, From: spec.WorkflowType, To: spec.Description})
+	} else {
+		var err error
+		current, err = w.Get(workflowID)
+		if err != nil {
+			return nil, err
+		}
+		if spec.WorkflowType != "" && current.WorkflowType != "" && spec.WorkflowType != current.WorkflowType {
+			return nil, fmt.Errorf("workflow type can't be changed from %q to %q", current.WorkflowType, spec.WorkflowType)
+		}
+		if spec.Description != current.Description {
+			plan.Changes = append(plan.Changes, PlanChange{Action: PlanUpdateMetadata, From: current.Description, To: spec.Description})
+		}
+	}
+	plan.current = current
+
+	plan.Changes = append(plan.Changes, planFields("field", current.Fields, spec.Fields)...)
+	headers := make([]Field, len(current.TableHeaders))
+	for i, h := range current.TableHeaders {
+		headers[i] = Field(h)
+	}
+	plan.Changes = append(plan.Changes, planFields("table_header", headers, spec.TableHeaders)...)
+
+	if spec.Settings != nil {
+		changed, err := spec.Settings.changes(current.Settings)
+		if err != nil {
+			return nil, err
+		}
+		if !changed.Empty() {
+			from, to := changed.describe(current.Settings)
+			plan.Changes = append(plan.Changes, PlanChange{Action: PlanUpdateSettings, From: from, To: to})
+		}
+	}
+	return plan, nil
+}
+
+// planFields diffs the existing fields of one kind (table headers are
+// converted to fields) against the spec
+func planFields(kind string, existing []Field, desired []FieldSpec) []PlanChange {
+	byName := make(map[string]Field)
+	for _, e := range existing {
+		byName[e.Name] = e
+	}
+
+	var changes []PlanChange
+	kept := make(map[string]bool)
+	for _, f := range desired {
+		if e, ok := byName[f.Name]; ok {
+			kept[f.Name] = true
+			if !sameDefinition(e, f.Field()) {
+				changes = append(changes, PlanChange{Action: PlanUpdate, Kind: kind, ID: e.ID, From: f.Name, To: f.Name})
+			}
+			continue
+		}
+		renamed := false
+		for _, old := range f.RenamedFrom {
+			if e, ok := byName[old]; ok && !kept[old] {
+				kept[old] = true
+				changes = append(changes, PlanChange{Action: PlanRename, Kind: kind, ID: e.ID, From: old, To: f.Name})
+				if !sameDefinition(e, f.Field()) {
+					changes = append(changes, PlanChange{Action: PlanUpdate, Kind: kind, ID: e.ID, From: f.Name, To: f.Name})
+				}
+				renamed = true
+				break
+			}
+		}
+		if !renamed {
+			changes = append(changes, PlanChange{Action: PlanCreate, Kind: kind, To: f.Name})
+		}
+	}
+	for _, e := range existing {
+		if !kept[e.Name] {
+			changes = append(changes, PlanChange{Action: PlanDelete, Kind: kind, ID: e.ID, From: e.Name})
+		}
+	}
+	return changes
+}
+
+// sameDefinition reports whether an existing field already has every
+// attribute the desired one sets, ignoring IDs and names. Attributes the
+// spec leaves empty aren't managed, since Workflows.Get may not return
+// them and every plan would otherwise update them forever.
+func sameDefinition(existing, desired Field) bool {
+	if desired.Type != "" && desired.Type != existing.Type {
+		return false
+	}
+	if desired.Required && !existing.Required {
+		return false
+	}
+	if desired.Description != "" && desired.Description != existing.Description {
+		return false
+	}
+	if desired.Pattern != "" && desired.Pattern != existing.Pattern {
+		return false
+	}
+	if len(desired.AllowedValues) == 0 {
+		return true
+	}
+	if len(desired.AllowedValues) != len(existing.AllowedValues) {
+		return false
+	}
+	for i := range desired.AllowedValues {
+		if desired.AllowedValues[i] != existing.AllowedValues[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyOptions controls Apply
+type ApplyOptions struct {
+	// DryRun stops after the confirmation step without calling the API
+	DryRun bool
+	// Confirm, if set, is called with the plan before any change is made;
+	// returning false cancels the apply with ErrApplyCancelled
+	Confirm func(plan *Plan) bool
+}
+
+// Apply executes a plan returned by Plan: renames and deletes go through
+// UpdateField and DeleteField, new and updated fields through SetFields
+// with the full desired list, then settings and metadata are updated. It
+// returns the workflow as it is after the changes, or nil for a dry run.
+func (w *Workflows) Apply(plan *Plan, opts ApplyOptions) (*Workflow, error) {
+	return ApplyPlan(w, plan, opts)
+}
+
+// ApplyPlan is Apply through any WorkflowsAPI
+func ApplyPlan(w WorkflowsAPI, plan *Plan, opts ApplyOptions) (*Workflow, error) {
+	if plan.Empty() {
+		if plan.current != nil {
+			return plan.current, nil
+		}
+		return w.Get(plan.WorkflowID)
+	}
+	if opts.Confirm != nil && !opts.Confirm(plan) {
+		return nil, ErrApplyCancelled
+	}
+	if opts.DryRun {
+		return nil, nil
+	}
+
+	workflowID := plan.WorkflowID
+	needsFields := false
+	for _, c := range plan.Changes {
+		switch c.Action {
+		case PlanCreateWorkflow:
+			created, err := w.Create(CreateWorkflowRequest{
+				Description:  plan.Spec.Description,
+				WorkflowType: plan.Spec.WorkflowType,
+			})
+			if err != nil {
+				return nil, err
+			}
+			workflowID = created.ID
+			plan.WorkflowID = workflowID
+		case PlanRename:
+			if err := w.UpdateField(workflowID, c.ID, UpdateFieldRequest{Name: c.To}); err != nil {
+				return nil, fmt.Errorf("rename %s %q: %w", c.Kind, c.From, err)
+			}
+		case PlanDelete:
+			if err := w.DeleteField(workflowID, c.ID); err != nil {
+				return nil, fmt.Errorf("delete %s %q: %w", c.Kind, c.From, err)
+			}
+		case PlanCreate, PlanUpdate:
+			needsFields = true
+		}
+	}
+
+	if needsFields {
+		if err := w.SetFields(workflowID, plan.setFieldsRequest()); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range plan.Changes {
+		switch c.Action {
+		case PlanUpdateSettings:
+			if err := w.UpdateSettings(workflowID, *plan.Spec.Settings); err != nil {
+				return nil, err
+			}
+		case PlanUpdateMetadata:
+			if err := w.UpdateMetadata(workflowID, UpdateMetadataRequest{Description: plan.Spec.Description}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return w.Get(workflowID)
+}
+
+// setFieldsRequest builds the full desired field list, keeping the IDs of
+// fields that already exist or were renamed and the attributes the spec
+// doesn't manage
+func (p *Plan) setFieldsRequest() SetFieldsRequest {
+	existing := make(map[string]Field)
+	if p.current != nil {
+		for _, f := range p.current.Fields {
+			existing["field/"+f.Name] = f
+		}
+		for _, h := range p.current.TableHeaders {
+			existing["table_header/"+h.Name] = Field(h)
+		}
+	}
+	for _, c := range p.Changes {
+		if c.Action == PlanRename {
+			existing[c.Kind+"/"+c.To] = existing[c.Kind+"/"+c.From]
+		}
+	}
+
+	req := SetFieldsRequest{Fields: []Field{}, TableHeaders: []TableHeader{}}
+	for _, f := range p.Spec.Fields {
+		req.Fields = append(req.Fields, mergeField(existing["field/"+f.Name], f.Field()))
+	}
+	for _, h := range p.Spec.TableHeaders {
+		req.TableHeaders = append(req.TableHeaders, TableHeader(mergeField(existing["table_header/"+h.Name], h.Field())))
+	}
+	return req
+}
+
+// mergeField returns the desired field with the ID of the existing one and
+// its value for every attribute the desired field leaves empty
+func mergeField(existing, desired Field) Field {
+	desired.ID = existing.ID
+	if desired.Type == "" {
+		desired.Type = existing.Type
+	}
+	if !desired.Required {
+		desired.Required = existing.Required
+	}
+	if desired.Description == "" {
+		desired.Description = existing.Description
+	}
+	if desired.Pattern == "" {
+		desired.Pattern = existing.Pattern
+	}
+	if len(desired.AllowedValues) == 0 {
+		desired.AllowedValues = existing.AllowedValues
+	}
+	return desired
+}
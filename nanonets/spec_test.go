@@ -0,0 +1,175 @@
+package nanonets_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+	"github.com/NanoNets/nanonets-go/nanonets/nanonetstest"
+)
+
+// planActions returns the actions of a plan keyed by the field they
+// change, or by the action for workflow changes
+func planActions(plan *nanonets.Plan) map[string]nanonets.PlanAction {
+	out := make(map[string]nanonets.PlanAction)
+	for _, c := range plan.Changes {
+		switch {
+		case c.Kind == "":
+			out[string(c.Action)] = c.Action
+		case c.Action == nanonets.PlanDelete:
+			out[c.Kind+"/"+c.From] = c.Action
+		default:
+			out[c.Kind+"/"+c.To] = c.Action
+		}
+	}
+	return out
+}
+
+func TestPlanCreatesWorkflow(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	workflows := s.Client().Workflows
+	spec := nanonets.WorkflowSpec{
+		Description:  "Invoices",
+		WorkflowType: "invoice",
+		Fields:       []nanonets.FieldSpec{{Name: "invoice_number", Required: true}, {Name: "total", Type: "number"}},
+		TableHeaders: []nanonets.FieldSpec{{Name: "description"}},
+	}
+
+	plan, err := workflows.Plan("", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Changes[0].Action != nanonets.PlanCreateWorkflow {
+		t.Fatalf("first change is %s, want create_workflow", plan.Changes[0].Action)
+	}
+	wf, err := workflows.Apply(plan, nanonets.ApplyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wf.Description != "Invoices" || len(wf.Fields) != 2 || len(wf.TableHeaders) != 1 {
+		t.Fatalf("created workflow %+v", wf)
+	}
+
+	again, err := workflows.Plan(wf.ID, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Empty() {
+		t.Errorf("plan after apply is not empty:\n%s", again)
+	}
+}
+
+func TestPlanRenamesAndDeletes(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	workflows := s.Client().Workflows
+	wf := s.AddWorkflow(nanonets.Workflow{
+		Description: "Invoices",
+		Fields:      []nanonets.Field{{Name: "inv_no"}, {Name: "notes"}, {Name: "vendor"}},
+	})
+	var renamedID string
+	for _, f := range wf.Fields {
+		if f.Name == "inv_no" {
+			renamedID = f.ID
+		}
+	}
+	spec := nanonets.WorkflowSpec{
+		Description: "Supplier invoices",
+		Fields: []nanonets.FieldSpec{
+			{Name: "invoice_number", RenamedFrom: []string{"inv_no"}},
+			{Name: "vendor"},
+			{Name: "total"},
+		},
+	}
+
+	plan, err := workflows.Plan(wf.ID, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]nanonets.PlanAction{
+		"update_metadata":      nanonets.PlanUpdateMetadata,
+		"field/invoice_number": nanonets.PlanRename,
+		"field/notes":          nanonets.PlanDelete,
+		"field/total":          nanonets.PlanCreate,
+	}
+	got := planActions(plan)
+	if len(got) != len(want) {
+		t.Errorf("plan has %d changes, want %d:\n%s", len(got), len(want), plan)
+	}
+	for key, action := range want {
+		if got[key] != action {
+			t.Errorf("%s: %q, want %q", key, got[key], action)
+		}
+	}
+
+	// A declined or dry-run plan changes nothing
+	if _, err := workflows.Apply(plan, nanonets.ApplyOptions{Confirm: func(*nanonets.Plan) bool { return false }}); !errors.Is(err, nanonets.ErrApplyCancelled) {
+		t.Errorf("declined apply: %v, want ErrApplyCancelled", err)
+	}
+	if updated, err := workflows.Apply(plan, nanonets.ApplyOptions{DryRun: true}); updated != nil || err != nil {
+		t.Errorf("dry run returned %v, %v", updated, err)
+	}
+	if current, _ := s.Workflow(wf.ID); current.Description != "Invoices" || len(current.Fields) != 3 {
+		t.Fatalf("workflow changed without an apply: %+v", current)
+	}
+
+	updated, err := workflows.Apply(plan, nanonets.ApplyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]string)
+	for _, f := range updated.Fields {
+		names[f.Name] = f.ID
+	}
+	if len(names) != 3 || names["invoice_number"] != renamedID || names["vendor"] == "" || names["total"] == "" {
+		t.Errorf("fields after apply: %v; invoice_number should keep ID %s", names, renamedID)
+	}
+	if updated.Description != "Supplier invoices" {
+		t.Errorf("description %q after apply", updated.Description)
+	}
+}
+
+func TestPlanAddsAttributesToExistingField(t *testing.T) {
+	s := nanonetstest.NewServer()
+	defer s.Close()
+	workflows := s.Client().Workflows
+	wf := s.AddWorkflow(nanonets.Workflow{
+		Description: "Invoices",
+		Fields:      []nanonets.Field{{Name: "total"}, {Name: "vendor", Description: "Supplier name"}},
+	})
+	spec := nanonets.WorkflowSpec{
+		Description: "Invoices",
+		Fields:      []nanonets.FieldSpec{{Name: "total", Type: "number", Required: true}, {Name: "vendor"}},
+	}
+
+	plan, err := workflows.Plan(wf.ID, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// vendor's description isn't in the spec, so it is left alone
+	want := map[string]nanonets.PlanAction{"field/total": nanonets.PlanUpdate}
+	if got := planActions(plan); len(got) != 1 || got["field/total"] != want["field/total"] {
+		t.Fatalf("plan actions %v, want %v", got, want)
+	}
+
+	updated, err := workflows.Apply(plan, nanonets.ApplyOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range updated.Fields {
+		if f.Name == "total" && (f.Type != "number" || !f.Required) {
+			t.Errorf("total after apply: %+v, want a required number", f)
+		}
+		if f.Name == "vendor" && f.Description != "Supplier name" {
+			t.Errorf("vendor description %q after apply, want it kept", f.Description)
+		}
+	}
+	again, err := workflows.Plan(wf.ID, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Empty() {
+		t.Errorf("plan after apply is not empty:\n%s", again)
+	}
+}
@@ -0,0 +1,130 @@
+package nanonets
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// DocumentStatus is the processing status of a document
+type DocumentStatus string
+
+// Document processing statuses
+const (
+	DocumentStatusPending    DocumentStatus = "pending"
+	DocumentStatusProcessing DocumentStatus = "processing"
+	DocumentStatusCompleted  DocumentStatus = "completed"
+	DocumentStatusFailed     DocumentStatus = "failed"
+)
+
+var documentStatuses = []DocumentStatus{
+	DocumentStatusPending,
+	DocumentStatusProcessing,
+	DocumentStatusCompleted,
+	DocumentStatusFailed,
+}
+
+// IsValid reports whether s is one of the known statuses
+func (s DocumentStatus) IsValid() bool {
+	for _, known := range documentStatuses {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether processing has finished, successfully or not
+func (s DocumentStatus) IsTerminal() bool {
+	return s == DocumentStatusCompleted || s == DocumentStatusFailed
+}
+
+// UnmarshalJSON accepts any string or null. Known statuses are matched
+// case-insensitively; unknown ones are kept as sent.
+func (s *DocumentStatus) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalEnum(data)
+	if err != nil {
+		return err
+	}
+	*s = DocumentStatus(value)
+	for _, known := range documentStatuses {
+		if strings.EqualFold(value, string(known)) {
+			*s = known
+		}
+	}
+	return nil
+}
+
+// VerificationStatus is the verification status of a document, field,
+// table or table cell
+type VerificationStatus string
+
+// Verification statuses
+const (
+	VerificationStatusUnverified VerificationStatus = "unverified"
+	VerificationStatusVerified   VerificationStatus = "verified"
+	VerificationStatusRejected   VerificationStatus = "rejected"
+)
+
+var verificationStatuses = []VerificationStatus{
+	VerificationStatusUnverified,
+	VerificationStatusVerified,
+	VerificationStatusRejected,
+}
+
+// IsValid reports whether s is one of the known statuses
+func (s VerificationStatus) IsValid() bool {
+	for _, known := range verificationStatuses {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether a reviewer has made a final decision
+func (s VerificationStatus) IsTerminal() bool {
+	return s == VerificationStatusVerified || s == VerificationStatusRejected
+}
+
+// UnmarshalJSON accepts any string or null. Known statuses are matched
+// case-insensitively; unknown ones are kept as sent.
+func (s *VerificationStatus) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalEnum(data)
+	if err != nil {
+		return err
+	}
+	*s = VerificationStatus(value)
+	for _, known := range verificationStatuses {
+		if strings.EqualFold(value, string(known)) {
+			*s = known
+		}
+	}
+	return nil
+}
+
+// VerificationStage is the name of the review stage a document is in.
+// Stages are configured per workflow, so there are no predefined values.
+type VerificationStage string
+
+// UnmarshalJSON accepts any string or null
+func (s *VerificationStage) UnmarshalJSON(data []byte) error {
+	value, err := unmarshalEnum(data)
+	if err != nil {
+		return err
+	}
+	*s = VerificationStage(value)
+	return nil
+}
+
+// unmarshalEnum decodes a JSON string, treating null as empty and
+// surrounding whitespace as insignificant
+func unmarshalEnum(data []byte) (string, error) {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+	if value == nil {
+		return "", nil
+	}
+	return strings.TrimSpace(*value), nil
+}
@@ -0,0 +1,86 @@
+package nanonets_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+// statuses holds the status types as they appear in API responses
+type statuses struct {
+	Status             nanonets.DocumentStatus     `json:"status"`
+	VerificationStatus nanonets.VerificationStatus `json:"verification_status"`
+	VerificationStage  nanonets.VerificationStage  `json:"verification_stage"`
+}
+
+func TestStatusUnmarshal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want statuses
+	}{
+		{`{"status": "Completed", "verification_status": " VERIFIED ", "verification_stage": "Finance"}`,
+			statuses{nanonets.DocumentStatusCompleted, nanonets.VerificationStatusVerified, "Finance"}},
+		{`{"status": null, "verification_status": null, "verification_stage": null}`, statuses{}},
+		{`{"status": "archived", "verification_status": "escalated", "verification_stage": ""}`, statuses{"archived", "escalated", ""}},
+	}
+	for _, tt := range tests {
+		// Start from other values to check that null clears them
+		got := statuses{"x", "x", "x"}
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Fatalf("%s: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	var got statuses
+	if err := json.Unmarshal([]byte(`{"status": 3}`), &got); err == nil {
+		t.Error("numeric status accepted")
+	}
+}
+
+func TestStatusPredicates(t *testing.T) {
+	documentStatuses := map[nanonets.DocumentStatus][2]bool{
+		nanonets.DocumentStatusPending:    {true, false},
+		nanonets.DocumentStatusProcessing: {true, false},
+		nanonets.DocumentStatusCompleted:  {true, true},
+		nanonets.DocumentStatusFailed:     {true, true},
+		"archived":                        {false, false},
+		"":                                {false, false},
+	}
+	for s, want := range documentStatuses {
+		if s.IsValid() != want[0] || s.IsTerminal() != want[1] {
+			t.Errorf("%q: IsValid %v, IsTerminal %v; want %v", s, s.IsValid(), s.IsTerminal(), want)
+		}
+	}
+
+	verificationStatuses := map[nanonets.VerificationStatus][2]bool{
+		nanonets.VerificationStatusUnverified: {true, false},
+		nanonets.VerificationStatusVerified:   {true, true},
+		nanonets.VerificationStatusRejected:   {true, true},
+		"escalated":                           {false, false},
+	}
+	for s, want := range verificationStatuses {
+		if s.IsValid() != want[0] || s.IsTerminal() != want[1] {
+			t.Errorf("%q: IsValid %v, IsTerminal %v; want %v", s, s.IsValid(), s.IsTerminal(), want)
+		}
+	}
+}
+
+func TestVerifyPassesUnknownStatus(t *testing.T) {
+	s, workflowID, doc := seedDocument(t, invoice())
+	err := s.Client().Moderation.VerifyDocument(workflowID, doc.DocumentID, nanonets.VerifyDocumentRequest{VerificationStatus: "escalated"})
+	// The fake server rejects the status, as the API does; the point is
+	// that the client sent it rather than failing locally
+	var apiErr *nanonets.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("err = %v, want a 400 from the server", err)
+	}
+	if n := len(s.Requests()); n != 1 {
+		t.Errorf("%d requests, want the status to be sent to the API", n)
+	}
+}
@@ -0,0 +1,306 @@
+package nanonets
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSnapshotNotFound is returned when a store has no matching snapshot
+var ErrSnapshotNotFound = errors.New("nanonets: snapshot not found")
+
+// SnapshotInfo identifies a stored version of a document
+type SnapshotInfo struct {
+	WorkflowID string    `json:"workflow_id"`
+	DocumentID string    `json:"document_id"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// Store saves versions of documents as they move through moderation.
+// Snapshots are keyed by workflow, document and timestamp.
+type Store interface {
+	// Save stores a snapshot of doc taken at the given time
+	Save(ctx context.Context, workflowID string, doc *Document, at time.Time) (SnapshotInfo, error)
+	// History lists a document's snapshots, oldest first
+	History(ctx context.Context, workflowID, documentID string) ([]SnapshotInfo, error)
+	// Load returns the snapshot taken at the given time
+	Load(ctx context.Context, workflowID, documentID string, at time.Time) (*Document, error)
+	// Latest returns the most recent snapshot of a document
+	Latest(ctx context.Context, workflowID, documentID string) (*Document, SnapshotInfo, error)
+}
+
+// DiffVersions compares two stored versions of a document
+func DiffVersions(ctx context.Context, store Store, workflowID, documentID string, from, to time.Time) (*DocumentDiff, error) {
+	a, err := store.Load(ctx, workflowID, documentID, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := store.Load(ctx, workflowID, documentID, to)
+	if err != nil {
+		return nil, err
+	}
+	return DiffDocuments(a, b), nil
+}
+
+// SyncResult represents the outcome of SyncWorkflow
+type SyncResult struct {
+	Saved     []SnapshotInfo `json:"saved"`
+	Unchanged []string       `json:"unchanged"`
+}
+
+// SyncWorkflow fetches every document in a workflow with docs.List and
+// saves a snapshot of each one that is new or differs from its latest
+// stored version. Pass a client's Documents field as docs.
+func SyncWorkflow(ctx context.Context, docs DocumentsAPI, store Store, workflowID string) (*SyncResult, error) {
+	list, err := docs.List(workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{}
+	now := time.Now().UTC()
+	for i := range list {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		doc := &list[i]
+		latest, _, err := store.Latest(ctx, workflowID, doc.DocumentID)
+		switch {
+		case errors.Is(err, ErrSnapshotNotFound):
+		case err != nil:
+			return result, err
+		case DiffDocuments(latest, doc).Empty():
+			result.Unchanged = append(result.Unchanged, doc.DocumentID)
+			continue
+		}
+		info, err := store.Save(ctx, workflowID, doc, now)
+		if err != nil {
+			return result, err
+		}
+		result.Saved = append(result.Saved, info)
+	}
+	return result, nil
+}
+
+// FileStore is a Store that keeps each snapshot in its own JSON file under
+// dir/<workflow>/<document>/<unix nanoseconds>.json
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a file store rooted at dir
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) documentDir(workflowID, documentID string) string {
+	return filepath.Join(s.dir, url.PathEscape(workflowID), url.PathEscape(documentID))
+}
+
+// Save stores a snapshot of doc taken at the given time
+func (s *FileStore) Save(ctx context.Context, workflowID string, doc *Document, at time.Time) (SnapshotInfo, error) {
+	info := SnapshotInfo{WorkflowID: workflowID, DocumentID: doc.DocumentID, Timestamp: at.UTC()}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return info, err
+	}
+
+	dir := s.documentDir(workflowID, doc.DocumentID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return info, err
+	}
+	tmp, err := os.CreateTemp(dir, ".snapshot-*")
+	if err != nil {
+		return info, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return info, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return info, err
+	}
+	name := filepath.Join(dir, strconv.FormatInt(info.Timestamp.UnixNano(), 10)+".json")
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		os.Remove(tmp.Name())
+		return info, err
+	}
+	return info, nil
+}
+
+// History lists a document's snapshots, oldest first
+func (s *FileStore) History(ctx context.Context, workflowID, documentID string) ([]SnapshotInfo, error) {
+	entries, err := os.ReadDir(s.documentDir(workflowID, documentID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var history []SnapshotInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		nanos, err := strconv.ParseInt(strings.TrimSuffix(name, ".json"), 10, 64)
+		if err != nil {
+			continue
+		}
+		history = append(history, SnapshotInfo{
+			WorkflowID: workflowID,
+			DocumentID: documentID,
+			Timestamp:  time.Unix(0, nanos).UTC(),
+		})
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Timestamp.Before(history[j].Timestamp)
+	})
+	return history, nil
+}
+
+// Load returns the snapshot taken at the given time
+func (s *FileStore) Load(ctx context.Context, workflowID, documentID string, at time.Time) (*Document, error) {
+	name := filepath.Join(s.documentDir(workflowID, documentID), strconv.FormatInt(at.UnixNano(), 10)+".json")
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", name, err)
+	}
+	return &doc, nil
+}
+
+// Latest returns the most recent snapshot of a document
+func (s *FileStore) Latest(ctx context.Context, workflowID, documentID string) (*Document, SnapshotInfo, error) {
+	history, err := s.History(ctx, workflowID, documentID)
+	if err != nil {
+		return nil, SnapshotInfo{}, err
+	}
+	if len(history) == 0 {
+		return nil, SnapshotInfo{}, ErrSnapshotNotFound
+	}
+	info := history[len(history)-1]
+	doc, err := s.Load(ctx, workflowID, documentID, info.Timestamp)
+	return doc, info, err
+}
+
+// SQLiteStore is a Store backed by a SQLite database. The caller opens the
+// database with the SQLite driver of their choice, e.g.
+//
+//	import _ "modernc.org/sqlite"
+//	db, err := sql.Open("sqlite", "snapshots.db")
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates a SQLite store, creating its table if needed
+func NewSQLiteStore(ctx context.Context, db *sql.DB) (*SQLiteStore, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS nanonets_document_snapshots (
+	workflow_id TEXT NOT NULL,
+	document_id TEXT NOT NULL,
+	taken_at INTEGER NOT NULL,
+	data BLOB NOT NULL,
+	PRIMARY KEY (workflow_id, document_id, taken_at)
+)`)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Save stores a snapshot of doc taken at the given time
+func (s *SQLiteStore) Save(ctx context.Context, workflowID string, doc *Document, at time.Time) (SnapshotInfo, error) {
+	info := SnapshotInfo{WorkflowID: workflowID, DocumentID: doc.DocumentID, Timestamp: at.UTC()}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return info, err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO nanonets_document_snapshots (workflow_id, document_id, taken_at, data) VALUES (?, ?, ?, ?)`,
+		workflowID, doc.DocumentID, info.Timestamp.UnixNano(), data)
+	return info, err
+}
+
+// History lists a document's snapshots, oldest first
+func (s *SQLiteStore) History(ctx context.Context, workflowID, documentID string) ([]SnapshotInfo, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT taken_at FROM nanonets_document_snapshots WHERE workflow_id = ? AND document_id = ? ORDER BY taken_at`,
+		workflowID, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []SnapshotInfo
+	for rows.Next() {
+		var nanos int64
+		if err := rows.Scan(&nanos); err != nil {
+			return nil, err
+		}
+		history = append(history, SnapshotInfo{
+			WorkflowID: workflowID,
+			DocumentID: documentID,
+			Timestamp:  time.Unix(0, nanos).UTC(),
+		})
+	}
+	return history, rows.Err()
+}
+
+// Load returns the snapshot taken at the given time
+func (s *SQLiteStore) Load(ctx context.Context, workflowID, documentID string, at time.Time) (*Document, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT data FROM nanonets_document_snapshots WHERE workflow_id = ? AND document_id = ? AND taken_at = ?`,
+		workflowID, documentID, at.UnixNano()).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Latest returns the most recent snapshot of a document
+func (s *SQLiteStore) Latest(ctx context.Context, workflowID, documentID string) (*Document, SnapshotInfo, error) {
+	info := SnapshotInfo{WorkflowID: workflowID, DocumentID: documentID}
+	var nanos int64
+	var data []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT taken_at, data FROM nanonets_document_snapshots WHERE workflow_id = ? AND document_id = ? ORDER BY taken_at DESC LIMIT 1`,
+		workflowID, documentID).Scan(&nanos, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, info, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, info, err
+	}
+	info.Timestamp = time.Unix(0, nanos).UTC()
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, info, err
+	}
+	return &doc, info, nil
+}
@@ -0,0 +1,225 @@
+package nanonets_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+func TestFileStore(t *testing.T) {
+	testStore(t, nanonets.NewFileStore(t.TempDir()))
+}
+
+func TestSQLiteStore(t *testing.T) {
+	db, err := sql.Open("nanonets-memsql", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store, err := nanonets.NewSQLiteStore(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+}
+
+func testStore(t *testing.T, store nanonets.Store) {
+	ctx := context.Background()
+	if _, _, err := store.Latest(ctx, "wf_1", "doc_1"); !errors.Is(err, nanonets.ErrSnapshotNotFound) {
+		t.Errorf("Latest on an empty store: err = %v, want ErrSnapshotNotFound", err)
+	}
+	if history, err := store.History(ctx, "wf_1", "doc_1"); err != nil || len(history) != 0 {
+		t.Errorf("History on an empty store = %v, %v", history, err)
+	}
+
+	first := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	second := first.Add(time.Minute)
+	// Save out of order to check that History sorts by time
+	for _, s := range []struct {
+		at    time.Time
+		value string
+	}{{second, "B"}, {first, "A"}} {
+		doc := diffPage([]nanonets.FieldData{{FieldDataID: "fd_1", Value: s.value}})
+		if _, err := store.Save(ctx, "wf_1", doc, s.at.In(time.FixedZone("CET", 3600))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := store.History(ctx, "wf_1", "doc_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || !history[0].Timestamp.Equal(first) || !history[1].Timestamp.Equal(second) {
+		t.Errorf("history %+v, want %s and %s", history, first, second)
+	}
+	if history[0].Timestamp.Location() != time.UTC {
+		t.Errorf("timestamp location %s, want UTC", history[0].Timestamp.Location())
+	}
+
+	latest, info, err := store.Latest(ctx, "wf_1", "doc_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Timestamp.Equal(second) || latest.Pages[0].Data.Fields["number"][0].Value != "B" {
+		t.Errorf("latest snapshot %+v at %s, want B at %s", latest.Pages[0].Data.Fields, info.Timestamp, second)
+	}
+	if _, err := store.Load(ctx, "wf_1", "doc_1", first.Add(time.Second)); !errors.Is(err, nanonets.ErrSnapshotNotFound) {
+		t.Errorf("Load of a missing snapshot: err = %v, want ErrSnapshotNotFound", err)
+	}
+
+	diff, err := nanonets.DiffVersions(ctx, store, "wf_1", "doc_1", first, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Entries) != 1 || diff.Entries[0].Old != "A" || diff.Entries[0].New != "B" {
+		t.Errorf("diff entries %+v, want A -> B", diff.Entries)
+	}
+}
+
+func TestSyncWorkflow(t *testing.T) {
+	ctx := context.Background()
+	s, workflowID, doc := seedDocument(t, invoice())
+	store := nanonets.NewFileStore(t.TempDir())
+	c := s.Client()
+
+	result, err := nanonets.SyncWorkflow(ctx, c.Documents, store, workflowID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Saved) != 1 || result.Saved[0].DocumentID != doc.DocumentID {
+		t.Errorf("first sync saved %+v, want %s", result.Saved, doc.DocumentID)
+	}
+
+	result, err = nanonets.SyncWorkflow(ctx, c.Documents, store, workflowID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Saved) != 0 || len(result.Unchanged) != 1 {
+		t.Errorf("second sync: saved %+v, unchanged %v; want only unchanged", result.Saved, result.Unchanged)
+	}
+
+	number := doc.Pages[0].Data.Fields["invoice_number"][0]
+	if err := c.Moderation.UpdateField(workflowID, doc.DocumentID, doc.Pages[0].PageID, number.FieldDataID, nanonets.UpdateFieldRequest{Value: nanonets.String("INV-1")}); err != nil {
+		t.Fatal(err)
+	}
+	result, err = nanonets.SyncWorkflow(ctx, c.Documents, store, workflowID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Saved) != 1 {
+		t.Errorf("sync after an edit saved %+v, want one snapshot", result.Saved)
+	}
+}
+
+// memSQL is a database/sql driver that understands just the statements
+// SQLiteStore issues, so the store can be tested without a SQLite driver
+type memSQL struct {
+	mu  sync.Mutex
+	dbs map[string]map[[2]string]map[int64][]byte
+}
+
+func init() {
+	sql.Register("nanonets-memsql", &memSQL{dbs: make(map[string]map[[2]string]map[int64][]byte)})
+}
+
+func (d *memSQL) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dbs[name] == nil {
+		d.dbs[name] = make(map[[2]string]map[int64][]byte)
+	}
+	return &memSQLConn{driver: d, snapshots: d.dbs[name]}, nil
+}
+
+type memSQLConn struct {
+	driver    *memSQL
+	snapshots map[[2]string]map[int64][]byte
+}
+
+func (c *memSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("memsql: prepared statements are not supported")
+}
+
+func (c *memSQLConn) Close() error { return nil }
+
+func (c *memSQLConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("memsql: transactions are not supported")
+}
+
+func (c *memSQLConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE"):
+	case strings.HasPrefix(query, "INSERT OR REPLACE"):
+		key := [2]string{args[0].Value.(string), args[1].Value.(string)}
+		if c.snapshots[key] == nil {
+			c.snapshots[key] = make(map[int64][]byte)
+		}
+		c.snapshots[key][args[2].Value.(int64)] = args[3].Value.([]byte)
+	default:
+		return nil, errors.New("memsql: unsupported statement " + query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *memSQLConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	snapshots := c.snapshots[[2]string{args[0].Value.(string), args[1].Value.(string)}]
+	var times []int64
+	for nanos := range snapshots {
+		times = append(times, nanos)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	rows := &memSQLRows{}
+	switch {
+	case strings.HasPrefix(query, "SELECT taken_at FROM"):
+		rows.columns = []string{"taken_at"}
+		for _, nanos := range times {
+			rows.values = append(rows.values, []driver.Value{nanos})
+		}
+	case strings.HasPrefix(query, "SELECT data FROM"):
+		rows.columns = []string{"data"}
+		if data, ok := snapshots[args[2].Value.(int64)]; ok {
+			rows.values = append(rows.values, []driver.Value{data})
+		}
+	case strings.HasPrefix(query, "SELECT taken_at, data FROM"):
+		rows.columns = []string{"taken_at", "data"}
+		if len(times) > 0 {
+			latest := times[len(times)-1]
+			rows.values = append(rows.values, []driver.Value{latest, snapshots[latest]})
+		}
+	default:
+		return nil, errors.New("memsql: unsupported query " + query)
+	}
+	return rows, nil
+}
+
+type memSQLRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *memSQLRows) Columns() []string { return r.columns }
+
+func (r *memSQLRows) Close() error { return nil }
+
+func (r *memSQLRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
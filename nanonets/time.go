@@ -0,0 +1,91 @@
+package nanonets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the timestamp formats accepted by Time, tried in order.
+// Layouts without a zone are read as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// Time is a timestamp returned by the API. It decodes RFC 3339 strings
+// with or without fractional seconds and Unix epochs in seconds or
+// milliseconds, as a number or a string. Null and the empty string decode
+// to the zero time.
+type Time struct {
+	time.Time
+}
+
+// ParseTime parses a timestamp in any of the formats accepted by Time
+func ParseTime(s string) (Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Time{}, nil
+	}
+	if epoch, err := strconv.ParseFloat(s, 64); err == nil {
+		return fromEpoch(epoch), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Time{t}, nil
+		}
+	}
+	return Time{}, fmt.Errorf("cannot parse %q as a timestamp", s)
+}
+
+// fromEpoch converts seconds since the Unix epoch, or milliseconds for
+// values too large to be seconds, to a Time with microsecond precision
+func fromEpoch(epoch float64) Time {
+	micros := epoch * 1e6
+	if math.Abs(epoch) > 1e11 {
+		micros = epoch * 1e3
+	}
+	return Time{time.UnixMicro(int64(math.Round(micros))).UTC()}
+}
+
+// UnmarshalJSON decodes a string or numeric timestamp
+func (t *Time) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*t = Time{}
+		return nil
+	}
+	if len(data) > 0 && data[0] != '"' {
+		var epoch float64
+		if err := json.Unmarshal(data, &epoch); err != nil {
+			return fmt.Errorf("cannot parse %s as a timestamp", data)
+		}
+		*t = fromEpoch(epoch)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseTime(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// MarshalJSON writes the time in RFC 3339 format, or an empty string for
+// the zero time
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(t.Format(time.RFC3339Nano))
+}
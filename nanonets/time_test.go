@@ -0,0 +1,110 @@
+package nanonets_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+func TestTimeUnmarshal(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{`"2024-03-01T12:30:00Z"`, want},
+		{`"2024-03-01T13:30:00+01:00"`, want},
+		{`"2024-03-01T12:30:00.000000"`, want},
+		{`"2024-03-01 12:30:00"`, want},
+		{`1709296200`, want},
+		{`1709296200000`, want},
+		{`"1709296200.5"`, want.Add(500 * time.Millisecond)},
+		{`null`, time.Time{}},
+		{`""`, time.Time{}},
+		{`"0001-01-01T00:00:00Z"`, time.Time{}},
+	}
+	for _, tt := range tests {
+		got := nanonets.Time{Time: time.Now()}
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) || got.IsZero() != tt.want.IsZero() {
+			t.Errorf("%s: got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	var got nanonets.Time
+	for _, in := range []string{`"yesterday"`, `true`, `{}`} {
+		if err := json.Unmarshal([]byte(in), &got); err == nil {
+			t.Errorf("%s: parsed as %s", in, got)
+		}
+	}
+}
+
+func TestTimeZeroValueRoundTrip(t *testing.T) {
+	var wf nanonets.Workflow
+	data, err := json.Marshal(wf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again nanonets.Workflow
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatalf("zero timestamps don't decode: %v in %s", err, data)
+	}
+	if !again.CreatedAt.IsZero() || !again.UpdatedAt.IsZero() {
+		t.Errorf("zero timestamps decoded as %s and %s", again.CreatedAt, again.UpdatedAt)
+	}
+
+	at := nanonets.Time{Time: time.Date(2024, 3, 1, 12, 30, 0, 5, time.UTC)}
+	data, _ = json.Marshal(at)
+	if string(data) != `"2024-03-01T12:30:00.000000005Z"` {
+		t.Errorf("marshaled as %s", data)
+	}
+}
+
+func TestFilterDocuments(t *testing.T) {
+	var docs []nanonets.Document
+	err := json.Unmarshal([]byte(`[
+		{"document_id": "a", "status": "completed", "verification_status": "verified", "uploaded_at": "2024-01-01T00:00:00Z", "metadata": {"batch": "1"}},
+		{"document_id": "b", "status": "completed", "verification_status": "unverified", "uploaded_at": "2024-02-01T00:00:00Z", "metadata": "{\"batch\": \"2\"}"},
+		{"document_id": "c", "status": "failed", "uploaded_at": "2024-03-01T00:00:00Z"},
+		{"document_id": "d", "status": "completed"}
+	]`), &docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter nanonets.DocumentFilter
+		want   string
+	}{
+		{"zero filter", nanonets.DocumentFilter{}, "abcd"},
+		{"status", nanonets.DocumentFilter{Statuses: []nanonets.DocumentStatus{nanonets.DocumentStatusCompleted}}, "abd"},
+		{"verification status", nanonets.DocumentFilter{VerificationStatuses: []nanonets.VerificationStatus{nanonets.VerificationStatusUnverified}}, "b"},
+		{"uploaded after is inclusive", nanonets.DocumentFilter{UploadedAfter: feb}, "bc"},
+		{"uploaded before is exclusive", nanonets.DocumentFilter{UploadedBefore: feb}, "a"},
+		{"metadata", nanonets.DocumentFilter{Metadata: map[string]string{"batch": "2"}}, "b"},
+	}
+	for _, tt := range tests {
+		var got string
+		for _, doc := range nanonets.FilterDocuments(docs, tt.filter) {
+			got += doc.DocumentID
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	nanonets.SortDocumentsByUploadedAt(docs, true)
+	var order string
+	for _, doc := range docs {
+		order += doc.DocumentID
+	}
+	if order != "cbad" {
+		t.Errorf("newest first: %q, want cbad", order)
+	}
+}
@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SignatureHeader is the request header that carries the payload signature,
+// formatted as "t=<unix seconds>,v1=<hex HMAC-SHA256>". The signature is
+// computed over "<t>.<payload>"; during secret rotation the header may
+// carry one v1 entry per active secret.
+const SignatureHeader = "Nanonets-Signature"
+
+// DefaultTolerance is the maximum age of a signed timestamp when
+// Verifier.Tolerance is zero
+const DefaultTolerance = 5 * time.Minute
+
+// Errors returned by Verifier.Verify
+var (
+	ErrMissingSignature = errors.New("webhook: missing or malformed signature header")
+	ErrInvalidSignature = errors.New("webhook: signature does not match")
+	ErrTimestampExpired = errors.New("webhook: timestamp outside tolerance window")
+	ErrReplayed         = errors.New("webhook: payload was already received")
+)
+
+// Sign returns the signature header value for a payload signed with secret
+// at time t
+func Sign(secret string, t time.Time, payload []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + computeSignature(secret, ts, payload)
+}
+
+func computeSignature(secret, ts string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verifier checks webhook signatures against one or more shared secrets.
+// Accepting several secrets allows a secret to be rotated without
+// downtime: add the new secret, switch the sender over, then remove the
+// old one.
+type Verifier struct {
+	// Secrets are the active shared secrets; a signature made with any of
+	// them is accepted
+	Secrets []string
+	// Tolerance is the maximum difference between the signed timestamp and
+	// the current time (DefaultTolerance if zero)
+	Tolerance time.Duration
+	// Cache, if set, rejects a payload that was already received with the
+	// same timestamp
+	Cache ReplayCache
+	// Now returns the current time (time.Now if nil)
+	Now func() time.Time
+}
+
+// NewVerifier creates a verifier for the given secrets
+func NewVerifier(secrets ...string) *Verifier {
+	return &Verifier{Secrets: secrets}
+}
+
+// Verify checks the signature header of a payload
+func (v *Verifier) Verify(header string, payload []byte) error {
+	_, err := v.verify(header, payload)
+	return err
+}
+
+// verify checks the signature and returns the replay cache key it stored
+func (v *Verifier) verify(header string, payload []byte) (string, error) {
+	ts, signatures := parseSignatureHeader(header)
+	if ts == "" || len(signatures) == 0 {
+		return "", ErrMissingSignature
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", ErrMissingSignature
+	}
+
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+	tolerance := v.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	signedAt := time.Unix(unix, 0)
+	if d := now().Sub(signedAt); d > tolerance || d < -tolerance {
+		return "", ErrTimestampExpired
+	}
+
+	if !v.matches(ts, payload, signatures) {
+		return "", ErrInvalidSignature
+	}
+
+	// The key must not depend on which signatures the request carries, or
+	// a delivery signed with two secrets could be replayed with just one
+	sum := sha256.Sum256(payload)
+	key := ts + "." + hex.EncodeToString(sum[:])
+	if v.Cache != nil && v.Cache.Seen(key, signedAt.Add(tolerance)) {
+		return "", ErrReplayed
+	}
+	return key, nil
+}
+
+// matches reports whether any of the signatures was made with one of the
+// secrets
+func (v *Verifier) matches(ts string, payload []byte, signatures []string) bool {
+	for _, secret := range v.Secrets {
+		expected := []byte(computeSignature(secret, ts, payload))
+		for _, sig := range signatures {
+			if hmac.Equal(expected, []byte(strings.ToLower(sig))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseSignatureHeader(header string) (string, []string) {
+	var ts string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	return ts, signatures
+}
+
+// ReplayCache remembers deliveries that were already accepted, keyed by
+// their signed timestamp and payload hash
+type ReplayCache interface {
+	// Seen records key until expiry and reports whether it was already
+	// recorded
+	Seen(key string, expiry time.Time) bool
+	// Forget removes key so that the payload can be delivered again, e.g.
+	// after the handler failed
+	Forget(key string)
+}
+
+// MemoryReplayCache is an in-memory ReplayCache. Expired entries are
+// pruned as new ones are added. The zero value is an empty cache ready to
+// use.
+type MemoryReplayCache struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// NewMemoryReplayCache creates an empty in-memory replay cache
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{}
+}
+
+// Seen records key until expiry and reports whether it was already recorded
+func (c *MemoryReplayCache) Seen(key string, expiry time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]time.Time)
+	}
+	now := time.Now()
+	for k, exp := range c.entries {
+		if now.After(exp) {
+			delete(c.entries, k)
+		}
+	}
+	if _, ok := c.entries[key]; ok {
+		return true
+	}
+	c.entries[key] = expiry
+	return false
+}
+
+// Forget removes key from the cache
+func (c *MemoryReplayCache) Forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
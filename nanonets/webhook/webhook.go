@@ -0,0 +1,273 @@
+// Package webhook receives Nanonets webhook events over HTTP
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/NanoNets/nanonets-go/nanonets"
+)
+
+// DefaultMaxBodyBytes is the largest payload accepted when
+// Handler.MaxBodyBytes is zero
+const DefaultMaxBodyBytes = 1 << 20
+
+// EventType identifies the kind of a webhook event
+type EventType string
+
+// Event types sent by Nanonets
+const (
+	EventDocumentProcessed EventType = "document.processed"
+	EventDocumentVerified  EventType = "document.verified"
+	EventModerationChanged EventType = "moderation.changed"
+)
+
+// ErrUnknownEvent is returned by Parse for event types this package
+// doesn't model
+var ErrUnknownEvent = errors.New("webhook: unknown event type")
+
+// Event contains the fields common to every webhook event
+type Event struct {
+	ID         string            `json:"event_id"`
+	Type       EventType         `json:"event_type"`
+	CreatedAt  nanonets.Time     `json:"created_at"`
+	WorkflowID string            `json:"workflow_id"`
+	Document   nanonets.Document `json:"document"`
+}
+
+// DocumentProcessedEvent is sent when extraction of a document finishes
+type DocumentProcessedEvent struct {
+	Event
+}
+
+// DocumentVerifiedEvent is sent when a document is verified
+type DocumentVerifiedEvent struct {
+	Event
+}
+
+// ModerationChangedEvent is sent when a reviewer edits or verifies a value.
+// Document holds the document after the change.
+type ModerationChangedEvent struct {
+	Event
+	Changes []ModerationChange `json:"changes"`
+}
+
+// ModerationChange describes a single moderation edit
+type ModerationChange struct {
+	Action      string `json:"action"`
+	PageID      string `json:"page_id"`
+	FieldDataID string `json:"field_data_id,omitempty"`
+	TableID     string `json:"table_id,omitempty"`
+	CellID      string `json:"cell_id,omitempty"`
+	OldValue    string `json:"old_value,omitempty"`
+	NewValue    string `json:"new_value,omitempty"`
+}
+
+// Parse decodes a webhook payload into a *DocumentProcessedEvent,
+// *DocumentVerifiedEvent or *ModerationChangedEvent. For other event types
+// it returns the common *Event together with ErrUnknownEvent.
+func Parse(payload []byte) (interface{}, error) {
+	var head Event
+	if err := json.Unmarshal(payload, &head); err != nil {
+		return nil, fmt.Errorf("webhook: invalid payload: %w", err)
+	}
+	if head.Type == "" {
+		return nil, fmt.Errorf("webhook: payload has no event_type")
+	}
+
+	var event interface{}
+	switch head.Type {
+	case EventDocumentProcessed:
+		event = &DocumentProcessedEvent{}
+	case EventDocumentVerified:
+		event = &DocumentVerifiedEvent{}
+	case EventModerationChanged:
+		event = &ModerationChangedEvent{}
+	default:
+		return &head, ErrUnknownEvent
+	}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("webhook: invalid %s payload: %w", head.Type, err)
+	}
+	return event, nil
+}
+
+// Handler is an http.Handler that parses webhook payloads and dispatches
+// them to the functions registered for each event type. It responds with
+//
+//	200 when every handler succeeded
+//	202 when no handler is registered for the event type
+//	400 when the payload can't be parsed
+//	401 when Verifier is set and the signature is invalid, expired or replayed
+//	405 for methods other than POST
+//	413 when the payload exceeds MaxBodyBytes
+//	500 when a handler returned an error, so that the event is retried
+type Handler struct {
+	// MaxBodyBytes limits the payload size (DefaultMaxBodyBytes if zero)
+	MaxBodyBytes int64
+	// Verifier, if set, checks the SignatureHeader of every request before
+	// it is dispatched
+	Verifier *Verifier
+	// OnError, if set, is called with the error a registered function
+	// returned. The sender only gets a generic 500 response.
+	OnError func(r *http.Request, err error)
+
+	mu        sync.RWMutex
+	processed []func(context.Context, *DocumentProcessedEvent) error
+	verified  []func(context.Context, *DocumentVerifiedEvent) error
+	moderated []func(context.Context, *ModerationChangedEvent) error
+	unknown   []func(context.Context, *Event, []byte) error
+}
+
+// NewHandler creates a webhook handler
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// OnDocumentProcessed registers a function for document.processed events
+func (h *Handler) OnDocumentProcessed(fn func(ctx context.Context, e *DocumentProcessedEvent) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.processed = append(h.processed, fn)
+}
+
+// OnDocumentVerified registers a function for document.verified events
+func (h *Handler) OnDocumentVerified(fn func(ctx context.Context, e *DocumentVerifiedEvent) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.verified = append(h.verified, fn)
+}
+
+// OnModerationChanged registers a function for moderation.changed events
+func (h *Handler) OnModerationChanged(fn func(ctx context.Context, e *ModerationChangedEvent) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.moderated = append(h.moderated, fn)
+}
+
+// OnUnknown registers a function for event types this package doesn't
+// model. It receives the common event fields and the raw payload.
+func (h *Handler) OnUnknown(fn func(ctx context.Context, e *Event, payload []byte) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unknown = append(h.unknown, fn)
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := h.readBody(r)
+	if err != nil {
+		if errors.Is(err, errTooLarge) {
+			http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "could not read payload", http.StatusBadRequest)
+		return
+	}
+
+	var replayKey string
+	if h.Verifier != nil {
+		replayKey, err = h.Verifier.verify(r.Header.Get(SignatureHeader), payload)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	status, err := h.dispatch(r.Context(), payload)
+	if err != nil {
+		// Let the sender retry the same delivery
+		if replayKey != "" && h.Verifier.Cache != nil {
+			h.Verifier.Cache.Forget(replayKey)
+		}
+		if status == http.StatusBadRequest {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		if h.OnError != nil {
+			h.OnError(r, err)
+		}
+		http.Error(w, "event handler failed", status)
+		return
+	}
+	w.WriteHeader(status)
+}
+
+var errTooLarge = errors.New("webhook: payload too large")
+
+func (h *Handler) readBody(r *http.Request) ([]byte, error) {
+	limit := h.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	if r.ContentLength > limit {
+		return nil, errTooLarge
+	}
+	payload, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(payload)) > limit {
+		return nil, errTooLarge
+	}
+	return payload, nil
+}
+
+// dispatch parses the payload, runs the registered handlers and returns the
+// status code to respond with
+func (h *Handler) dispatch(ctx context.Context, payload []byte) (int, error) {
+	event, err := Parse(payload)
+	if err != nil && !errors.Is(err, ErrUnknownEvent) {
+		return http.StatusBadRequest, err
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var handled bool
+	switch e := event.(type) {
+	case *DocumentProcessedEvent:
+		for _, fn := range h.processed {
+			handled = true
+			if err := fn(ctx, e); err != nil {
+				return http.StatusInternalServerError, err
+			}
+		}
+	case *DocumentVerifiedEvent:
+		for _, fn := range h.verified {
+			handled = true
+			if err := fn(ctx, e); err != nil {
+				return http.StatusInternalServerError, err
+			}
+		}
+	case *ModerationChangedEvent:
+		for _, fn := range h.moderated {
+			handled = true
+			if err := fn(ctx, e); err != nil {
+				return http.StatusInternalServerError, err
+			}
+		}
+	case *Event:
+		for _, fn := range h.unknown {
+			handled = true
+			if err := fn(ctx, e, payload); err != nil {
+				return http.StatusInternalServerError, err
+			}
+		}
+	}
+	if !handled {
+		return http.StatusAccepted, nil
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,248 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NanoNets/nanonets-go/nanonets/webhook"
+)
+
+const processedPayload = `{"event_id":"evt_1","event_type":"document.processed","workflow_id":"wf_1","document":{"document_id":"doc_1","status":"completed"}}`
+
+// post sends a payload to the server with optional headers and returns the
+// status code and body
+func post(t *testing.T, srv *httptest.Server, payload string, header http.Header) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func signed(secret string, payload string) http.Header {
+	return http.Header{webhook.SignatureHeader: {webhook.Sign(secret, time.Now(), []byte(payload))}}
+}
+
+func TestHandlerMethodNotAllowed(t *testing.T) {
+	srv := httptest.NewServer(webhook.NewHandler())
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", resp.StatusCode)
+	}
+	if allow := resp.Header.Get("Allow"); allow != http.MethodPost {
+		t.Errorf("Allow = %q, want POST", allow)
+	}
+}
+
+func TestHandlerBodyLimit(t *testing.T) {
+	h := webhook.NewHandler()
+	h.MaxBodyBytes = int64(len(processedPayload))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	if status, _ := post(t, srv, processedPayload, nil); status != http.StatusAccepted {
+		t.Errorf("payload at the limit: status = %d, want 202", status)
+	}
+	if status, _ := post(t, srv, processedPayload+" ", nil); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("payload over the limit: status = %d, want 413", status)
+	}
+
+	// Without a Content-Length the limit is enforced while reading
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(processedPayload+" "))
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("chunked payload over the limit: status = %d, want 413", rec.Code)
+	}
+}
+
+func TestHandlerBadPayload(t *testing.T) {
+	srv := httptest.NewServer(webhook.NewHandler())
+	defer srv.Close()
+
+	for _, payload := range []string{`not json`, `{"event_id":"evt_1"}`} {
+		if status, _ := post(t, srv, payload, nil); status != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", payload, status)
+		}
+	}
+}
+
+func TestHandlerSignature(t *testing.T) {
+	h := webhook.NewHandler()
+	h.Verifier = webhook.NewVerifier("secret")
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"missing", nil, http.StatusUnauthorized},
+		{"wrong secret", signed("other", processedPayload), http.StatusUnauthorized},
+		{"expired", http.Header{webhook.SignatureHeader: {webhook.Sign("secret", time.Now().Add(-time.Hour), []byte(processedPayload))}}, http.StatusUnauthorized},
+		{"valid", signed("secret", processedPayload), http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		if status, _ := post(t, srv, processedPayload, tt.header); status != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.want)
+		}
+	}
+}
+
+func TestHandlerTypedDispatch(t *testing.T) {
+	h := webhook.NewHandler()
+	var got []string
+	h.OnDocumentProcessed(func(ctx context.Context, e *webhook.DocumentProcessedEvent) error {
+		got = append(got, "processed:"+e.Document.DocumentID)
+		return nil
+	})
+	h.OnDocumentVerified(func(ctx context.Context, e *webhook.DocumentVerifiedEvent) error {
+		got = append(got, "verified:"+e.Document.DocumentID)
+		return nil
+	})
+	h.OnModerationChanged(func(ctx context.Context, e *webhook.ModerationChangedEvent) error {
+		got = append(got, "moderated:"+e.Changes[0].NewValue)
+		return nil
+	})
+	h.OnUnknown(func(ctx context.Context, e *webhook.Event, payload []byte) error {
+		got = append(got, "unknown:"+string(e.Type))
+		return nil
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	payloads := []string{
+		processedPayload,
+		`{"event_type":"document.verified","document":{"document_id":"doc_2"}}`,
+		`{"event_type":"moderation.changed","changes":[{"action":"update","page_id":"p1","new_value":"42"}]}`,
+		`{"event_type":"workflow.deleted"}`,
+	}
+	for _, p := range payloads {
+		if status, body := post(t, srv, p, nil); status != http.StatusOK {
+			t.Fatalf("%s: status = %d (%s), want 200", p, status, body)
+		}
+	}
+	want := []string{"processed:doc_1", "verified:doc_2", "moderated:42", "unknown:workflow.deleted"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("dispatched %v, want %v", got, want)
+	}
+}
+
+func TestHandlerErrorIsNotEchoed(t *testing.T) {
+	h := webhook.NewHandler()
+	h.OnDocumentProcessed(func(ctx context.Context, e *webhook.DocumentProcessedEvent) error {
+		return errors.New("connect to db.internal:5432: refused")
+	})
+	var reported error
+	h.OnError = func(r *http.Request, err error) { reported = err }
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	status, body := post(t, srv, processedPayload, nil)
+	if status != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", status)
+	}
+	if strings.Contains(body, "db.internal") {
+		t.Errorf("response body %q leaks the handler error", body)
+	}
+	if reported == nil || !strings.Contains(reported.Error(), "db.internal") {
+		t.Errorf("OnError got %v", reported)
+	}
+}
+
+func TestHandlerRetryAfterError(t *testing.T) {
+	h := webhook.NewHandler()
+	h.Verifier = webhook.NewVerifier("secret")
+	h.Verifier.Cache = webhook.NewMemoryReplayCache()
+	calls := 0
+	h.OnDocumentProcessed(func(ctx context.Context, e *webhook.DocumentProcessedEvent) error {
+		calls++
+		if calls == 1 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	header := signed("secret", processedPayload)
+	// The failed delivery is forgotten so the sender's retry is accepted,
+	// and the successful one is remembered so a replay is rejected
+	for i, want := range []int{http.StatusInternalServerError, http.StatusOK, http.StatusUnauthorized} {
+		if status, _ := post(t, srv, processedPayload, header); status != want {
+			t.Errorf("delivery %d: status = %d, want %d", i+1, status, want)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
+
+func TestVerifierReplayDuringRotation(t *testing.T) {
+	v := webhook.NewVerifier("old", "new")
+	v.Cache = webhook.NewMemoryReplayCache()
+	now := time.Now()
+	payload := []byte(processedPayload)
+	both := webhook.Sign("old", now, payload) + "," + strings.SplitN(webhook.Sign("new", now, payload), ",", 2)[1]
+
+	if err := v.Verify(both, payload); err != nil {
+		t.Fatalf("first delivery: %v", err)
+	}
+	// Resending the same delivery with only one of its signatures is
+	// still a replay
+	for _, header := range []string{webhook.Sign("old", now, payload), webhook.Sign("new", now, payload)} {
+		if err := v.Verify(header, payload); !errors.Is(err, webhook.ErrReplayed) {
+			t.Errorf("Verify(%q) = %v, want ErrReplayed", header, err)
+		}
+	}
+}
+
+func TestMemoryReplayCacheZeroValue(t *testing.T) {
+	var c webhook.MemoryReplayCache
+	expiry := time.Now().Add(time.Minute)
+	if c.Seen("a", expiry) {
+		t.Error("first Seen = true, want false")
+	}
+	if !c.Seen("a", expiry) {
+		t.Error("second Seen = false, want true")
+	}
+	c.Forget("a")
+	if c.Seen("a", expiry) {
+		t.Error("Seen after Forget = true, want false")
+	}
+
+	// Expired entries are pruned when the next key is added
+	c.Seen("b", time.Now().Add(-time.Second))
+	c.Seen("c", expiry)
+	if c.Seen("b", expiry) {
+		t.Error("expired key is still seen")
+	}
+}